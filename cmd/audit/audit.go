@@ -0,0 +1,9 @@
+package audit
+
+import "github.com/spf13/cobra"
+
+var AuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "audit",
+	Long:  `audit log of admin and room admin actions`,
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/synctv-org/synctv/internal/bootstrap"
+	"github.com/synctv-org/synctv/internal/db"
+	"gorm.io/gorm"
+)
+
+var (
+	showActor  string
+	showAction string
+	showRoom   string
+	showTarget string
+	showSince  time.Duration
+	showLimit  int
+)
+
+var ShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show audit log",
+	Long:  "show the latest audit log entries, an action ending with a dot matches every action under it",
+	PreRunE: func(cmd *cobra.Command, _ []string) error {
+		return bootstrap.New().Add(
+			bootstrap.InitStdLog,
+			bootstrap.InitConfig,
+			bootstrap.InitDatabase,
+		).Run(cmd.Context())
+	},
+	RunE: func(_ *cobra.Command, _ []string) error {
+		scopes := []func(db *gorm.DB) *gorm.DB{}
+		if showActor != "" {
+			scopes = append(scopes, db.WhereActorID(showActor))
+		}
+		if showAction != "" {
+			scopes = append(scopes, db.WhereAuditAction(showAction))
+		}
+		if showRoom != "" {
+			scopes = append(scopes, db.WhereRoomID(showRoom))
+		}
+		if showTarget != "" {
+			scopes = append(scopes, db.WhereTargetID(showTarget))
+		}
+		if showSince > 0 {
+			scopes = append(
+				scopes,
+				db.WhereCreatedAtBetween(time.Now().Add(-showSince), time.Time{}),
+			)
+		}
+		logs, err := db.GetAuditLogs(append(scopes, db.OrderByIDDesc, db.Paginate(1, showLimit))...)
+		if err != nil {
+			return err
+		}
+		for _, l := range logs {
+			log.Infof(
+				"%s\t%s(%s)\t%s\troom: %s\ttarget: %s\tbefore: %s\tafter: %s\n",
+				l.CreatedAt.Format(time.DateTime),
+				l.ActorName,
+				l.ActorID,
+				l.Action,
+				l.RoomID,
+				l.TargetID,
+				l.Before,
+				l.After,
+			)
+		}
+		return nil
+	},
+}
+
+func init() {
+	ShowCmd.Flags().StringVar(&showActor, "actor", "", "only show actions of this user id")
+	ShowCmd.Flags().
+		StringVar(&showAction, "action", "", "only show this action, e.g. user.ban or room.")
+	ShowCmd.Flags().StringVar(&showRoom, "room", "", "only show actions in this room id")
+	ShowCmd.Flags().
+		StringVar(&showTarget, "target", "", "only show actions on this user id or endpoint")
+	ShowCmd.Flags().
+		DurationVar(&showSince, "since", 0, "only show actions newer than this, e.g. 24h")
+	ShowCmd.Flags().IntVar(&showLimit, "limit", 50, "max number of entries")
+	AuditCmd.AddCommand(ShowCmd)
+}
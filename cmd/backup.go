@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/synctv-org/synctv/internal/bootstrap"
+	"github.com/synctv-org/synctv/internal/db"
+)
+
+var backupOutput string
+
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "backup all data to a zip archive",
+	Long: `backup all data to a zip archive, which can be restored into any supported database type.
+you should first shut down the server, otherwise the backup may be inconsistent.`,
+	PreRunE: func(cmd *cobra.Command, _ []string) error {
+		return bootstrap.New().Add(
+			bootstrap.InitStdLog,
+			bootstrap.InitConfig,
+			bootstrap.InitDatabase,
+		).Run(cmd.Context())
+	},
+	RunE: func(_ *cobra.Command, _ []string) error {
+		output := backupOutput
+		if output == "" {
+			output = fmt.Sprintf("synctv-backup-%s.zip", time.Now().Format("20060102150405"))
+		}
+
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		manifest, err := db.Backup(f)
+		if err != nil {
+			return fmt.Errorf("backup failed: %w", err)
+		}
+
+		for table, count := range manifest.Tables {
+			log.Infof("backup table %s: %d rows", table, count)
+		}
+
+		log.Infof("backup success: %s", output)
+
+		return nil
+	},
+}
+
+func init() {
+	BackupCmd.Flags().
+		StringVarP(&backupOutput, "output", "o", "", "output file, default synctv-backup-<time>.zip")
+	RootCmd.AddCommand(BackupCmd)
+}
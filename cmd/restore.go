@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/synctv-org/synctv/internal/bootstrap"
+	"github.com/synctv-org/synctv/internal/db"
+)
+
+var restoreForce bool
+
+var RestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "restore all data from a backup archive",
+	Long: `restore all data from a backup archive into the configured database.
+you must first shut down the server, the database must be new unless --force is set.`,
+	PreRunE: func(cmd *cobra.Command, _ []string) error {
+		return bootstrap.New().Add(
+			bootstrap.InitStdLog,
+			bootstrap.InitConfig,
+			bootstrap.InitDatabase,
+		).Run(cmd.Context())
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("missing backup file")
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		manifest, err := db.Restore(f, fi.Size(), restoreForce)
+		if err != nil {
+			if errors.Is(err, db.ErrDatabaseNotEmpty) {
+				return fmt.Errorf("%w, use --force to delete all existing data", err)
+			}
+			return fmt.Errorf("restore failed: %w", err)
+		}
+
+		log.Infof(
+			"restore success, backup from %s database version %s",
+			manifest.DatabaseType,
+			manifest.DatabaseVersion,
+		)
+
+		return nil
+	},
+}
+
+func init() {
+	RestoreCmd.Flags().
+		BoolVar(&restoreForce, "force", false, "delete all existing data before restoring")
+	RootCmd.AddCommand(RestoreCmd)
+}
@@ -0,0 +1,68 @@
+package room
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/synctv-org/synctv/internal/bootstrap"
+	"github.com/synctv-org/synctv/internal/roomarchive"
+)
+
+var exportOutput string
+
+var ExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export room with room id to a zip archive",
+	Long:  "export room with room id to a zip archive",
+	PreRunE: func(cmd *cobra.Command, _ []string) error {
+		return bootstrap.New().Add(
+			bootstrap.InitStdLog,
+			bootstrap.InitConfig,
+			bootstrap.InitDatabase,
+		).Run(cmd.Context())
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("missing room id")
+		}
+
+		a, err := roomarchive.Export(args[0])
+		if err != nil {
+			return fmt.Errorf("export room failed: %w", err)
+		}
+
+		output := exportOutput
+		if output == "" {
+			output = fmt.Sprintf("room-%s.zip", args[0])
+		}
+
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err := roomarchive.Write(f, a); err != nil {
+			return fmt.Errorf("write room archive failed: %w", err)
+		}
+
+		log.Infof(
+			"export room success: %s, movies: %d, members: %d, output: %s\n",
+			a.Room.Name,
+			len(a.Movies),
+			len(a.Members),
+			output,
+		)
+
+		return nil
+	},
+}
+
+func init() {
+	ExportCmd.Flags().
+		StringVarP(&exportOutput, "output", "o", "", "output file, default room-<room id>.zip")
+	RoomCmd.AddCommand(ExportCmd)
+}
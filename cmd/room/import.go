@@ -0,0 +1,92 @@
+package room
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/synctv-org/synctv/internal/bootstrap"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/roomarchive"
+)
+
+var (
+	importOwner      string
+	importName       string
+	importMatchUsers bool
+)
+
+var ImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import room from a zip archive",
+	Long:  "import room from a zip archive, the room is owned by the user given with --owner",
+	PreRunE: func(cmd *cobra.Command, _ []string) error {
+		return bootstrap.New().Add(
+			bootstrap.InitStdLog,
+			bootstrap.InitConfig,
+			bootstrap.InitDatabase,
+		).Run(cmd.Context())
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("missing archive file")
+		}
+
+		if importOwner == "" {
+			return errors.New("missing owner")
+		}
+
+		owner, err := db.GetUserByUsername(importOwner)
+		if err != nil {
+			owner, err = db.GetUserByID(importOwner)
+			if err != nil {
+				return fmt.Errorf("get owner failed: %w", err)
+			}
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		a, err := roomarchive.Read(f, fi.Size())
+		if err != nil {
+			return err
+		}
+
+		var conf []roomarchive.ImportConfig
+		if importName != "" {
+			conf = append(conf, roomarchive.WithName(importName))
+		}
+
+		if importMatchUsers {
+			conf = append(conf, roomarchive.WithMatchUsers())
+		}
+
+		r, err := roomarchive.Import(a, owner, conf...)
+		if err != nil {
+			return fmt.Errorf("import room failed: %w", err)
+		}
+
+		log.Infof("import room success: %s, id: %s, owner: %s\n", r.Name, r.ID, owner.Username)
+
+		return nil
+	},
+}
+
+func init() {
+	ImportCmd.Flags().StringVar(&importOwner, "owner", "", "username or user id of the new owner")
+	ImportCmd.Flags().
+		StringVar(&importName, "name", "", "import the room under another name")
+	ImportCmd.Flags().
+		BoolVar(&importMatchUsers, "match-users", false, "map archived members to the users of the same username")
+	RoomCmd.AddCommand(ImportCmd)
+}
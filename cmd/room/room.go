@@ -0,0 +1,9 @@
+package room
+
+import "github.com/spf13/cobra"
+
+var RoomCmd = &cobra.Command{
+	Use:   "room",
+	Short: "room",
+	Long:  `you must first shut down the server, otherwise the changes will not take effect.`,
+}
@@ -13,7 +13,9 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/synctv-org/synctv/cmd/admin"
+	"github.com/synctv-org/synctv/cmd/audit"
 	"github.com/synctv-org/synctv/cmd/flags"
+	"github.com/synctv-org/synctv/cmd/room"
 	"github.com/synctv-org/synctv/cmd/root"
 	"github.com/synctv-org/synctv/cmd/setting"
 	"github.com/synctv-org/synctv/cmd/user"
@@ -119,4 +121,6 @@ func init() {
 	RootCmd.AddCommand(user.UserCmd)
 	RootCmd.AddCommand(setting.SettingCmd)
 	RootCmd.AddCommand(root.RootCmd)
+	RootCmd.AddCommand(room.RoomCmd)
+	RootCmd.AddCommand(audit.AuditCmd)
 }
@@ -28,12 +28,18 @@ var ServerCmd = &cobra.Command{
 			bootstrap.InitConfig,
 			bootstrap.InitGinMode,
 			bootstrap.InitLog,
+			bootstrap.InitTracing,
 			bootstrap.InitDatabase,
 			bootstrap.InitProvider,
+			bootstrap.InitLdap,
 			bootstrap.InitOp,
+			bootstrap.InitMetrics,
 			bootstrap.InitRtmp,
+			bootstrap.InitWHIP,
 			bootstrap.InitVendorBackend,
 			bootstrap.InitSetting,
+			bootstrap.InitScheduler,
+			bootstrap.InitRecording,
 		)
 		if !flags.Server.DisableUpdateCheck {
 			boot.Add(bootstrap.InitCheckUpdate)
@@ -167,6 +173,14 @@ func Server(_ *cobra.Command, _ []string) {
 		log.Infof("rtmp run on tcp://%s:%d", tcpRTMPAddr.IP, tcpRTMPAddr.Port)
 	}
 
+	if conf.Conf.Server.Metrics.Enable && conf.Conf.Server.Metrics.Port == 0 {
+		log.Infof("metrics run on /metrics")
+	}
+
+	if conf.Conf.Server.WHIP.Enable {
+		log.Infof("whip run on /api/room/movie/live/whip/<room id>")
+	}
+
 	if conf.Conf.Server.HTTP.CertPath != "" && conf.Conf.Server.HTTP.KeyPath != "" {
 		log.Infof("website run on https://%s:%d", tcpHTTPAddr.IP, tcpHTTPAddr.Port)
 	} else {
@@ -0,0 +1,61 @@
+package user
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/synctv-org/synctv/internal/bootstrap"
+	"github.com/synctv-org/synctv/internal/db"
+)
+
+var Reset2FACmd = &cobra.Command{
+	Use:   "reset-2fa",
+	Short: "disable 2fa and delete the passkeys of user with user id",
+	Long:  "disable 2fa and delete the passkeys of user with user id, for a user who lost the authenticator, the recovery codes or the passkey device",
+	PreRunE: func(cmd *cobra.Command, _ []string) error {
+		return bootstrap.New().Add(
+			bootstrap.InitStdLog,
+			bootstrap.InitConfig,
+			bootstrap.InitDatabase,
+		).Run(cmd.Context())
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("missing user id")
+		}
+		u, err := db.GetUserByID(args[0])
+		if err != nil {
+			log.Errorf("get user failed: %s\n", err)
+			return nil
+		}
+		passkeys, err := db.CountWebAuthnCredentialsByUserID(u.ID)
+		if err != nil {
+			log.Errorf("get passkeys failed: %s\n", err)
+			return nil
+		}
+		if !u.TotpEnabled() && passkeys == 0 {
+			log.Infof("2fa of user %s is not enabled\n", u.Username)
+			return nil
+		}
+		err = db.ResetUserTotp(u.ID)
+		if err != nil {
+			log.Errorf("reset 2fa failed: %s\n", err)
+			return nil
+		}
+		passkeys, err = db.DeleteWebAuthnCredentialsByUserID(u.ID)
+		if err != nil {
+			log.Errorf("delete passkeys failed: %s\n", err)
+			return nil
+		}
+		if passkeys > 0 {
+			log.Infof("deleted %d passkeys of user %s\n", passkeys, u.Username)
+		}
+		log.Infof("reset 2fa success: %s\n", u.Username)
+		return nil
+	},
+}
+
+func init() {
+	UserCmd.AddCommand(Reset2FACmd)
+}
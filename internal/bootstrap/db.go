@@ -12,6 +12,7 @@ import (
 	"github.com/synctv-org/synctv/internal/conf"
 	"github.com/synctv-org/synctv/internal/db"
 	sysnotify "github.com/synctv-org/synctv/internal/sysnotify"
+	"github.com/synctv-org/synctv/internal/tracing"
 	"github.com/synctv-org/synctv/internal/version"
 	"github.com/synctv-org/synctv/utils"
 	"gorm.io/driver/mysql"
@@ -41,6 +42,13 @@ func InitDatabase(_ context.Context) (err error) {
 		log.Fatalf("failed to connect database: %s", err.Error())
 	}
 
+	if conf.Conf.Tracing.Enable {
+		err = d.Use(tracing.NewGormPlugin())
+		if err != nil {
+			log.Fatalf("failed to init database tracing: %s", err.Error())
+		}
+	}
+
 	sqlDB, err := d.DB()
 	if err != nil {
 		log.Fatalf("failed to get sqlDB: %s", err.Error())
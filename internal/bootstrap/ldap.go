@@ -0,0 +1,23 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/ldap"
+)
+
+func InitLdap(_ context.Context) error {
+	if !conf.Conf.Ldap.Enable {
+		return nil
+	}
+
+	a, err := ldap.New(conf.Conf.Ldap, nil)
+	if err != nil {
+		return err
+	}
+
+	ldap.Init(a)
+
+	return nil
+}
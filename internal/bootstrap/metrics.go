@@ -0,0 +1,62 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/metrics"
+	"github.com/synctv-org/synctv/internal/op"
+	sysnotify "github.com/synctv-org/synctv/internal/sysnotify"
+)
+
+func InitMetrics(_ context.Context) error {
+	c := conf.Conf.Server.Metrics
+	if !c.Enable {
+		return nil
+	}
+
+	if err := metrics.Registry.Register(op.NewCollector(c.PerRoom)); err != nil {
+		return err
+	}
+
+	// without a port the metrics are served by the http server
+	if c.Port == 0 {
+		return nil
+	}
+
+	listen := c.Listen
+	if listen == "" {
+		listen = conf.Conf.Server.HTTP.Listen
+	}
+
+	l, err := net.Listen("tcp", net.JoinHostPort(listen, strconv.Itoa(int(c.Port))))
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(c.Token))
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 3 * time.Second}
+
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("metrics server error: %v", err)
+		}
+	}()
+
+	log.Infof("metrics run on http://%s/metrics", l.Addr())
+
+	return sysnotify.RegisterSysNotifyTask(
+		0,
+		sysnotify.NewSysNotifyTask("metrics", sysnotify.NotifyTypeEXIT, func() error {
+			return srv.Close()
+		}),
+	)
+}
@@ -2,10 +2,56 @@ package bootstrap
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
 	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/pubsub"
+	sysnotify "github.com/synctv-org/synctv/internal/sysnotify"
 )
 
-func InitOp(_ context.Context) error {
-	return op.Init(4096)
+func InitOp(ctx context.Context) error {
+	if err := op.Init(4096); err != nil {
+		return err
+	}
+
+	return initCluster(ctx)
+}
+
+func initCluster(ctx context.Context) error {
+	var ps pubsub.PubSub
+
+	switch conf.Conf.Cluster.Type {
+	case conf.ClusterTypeMemory, "":
+		return nil
+	case conf.ClusterTypeRedis:
+		c := conf.Conf.Cluster.Redis
+
+		client := redis.NewClient(&redis.Options{
+			Addr:     c.Addr,
+			Username: c.Username,
+			Password: c.Password,
+			DB:       c.DB,
+		})
+		if err := client.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("failed to connect cluster redis: %w", err)
+		}
+
+		ps = pubsub.NewRedis(client, c.Channel)
+	default:
+		return fmt.Errorf("unknown cluster type: %s", conf.Conf.Cluster.Type)
+	}
+
+	if err := op.InitPubSub(ps); err != nil {
+		return err
+	}
+
+	log.Infof("cluster: %s, node id: %s", conf.Conf.Cluster.Type, op.NodeID())
+
+	return sysnotify.RegisterSysNotifyTask(
+		0,
+		sysnotify.NewSysNotifyTask("cluster", sysnotify.NotifyTypeEXIT, ps.Close),
+	)
 }
@@ -14,6 +14,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/cmd/flags"
 	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/ldap"
 	"github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/provider"
 	"github.com/synctv-org/synctv/internal/provider/aggregations"
@@ -109,6 +110,20 @@ func InitProvider(_ context.Context) (err error) {
 		}
 	}
 
+	for _, op := range conf.Conf.OidcProviders {
+		log.Infof("load oidc provider: %s", op.Name)
+
+		if op.Name == ldap.Provider {
+			return fmt.Errorf("load oidc provider: %s is reserved for ldap users", op.Name)
+		}
+
+		err := providers.RegisterOidcProvider(op.Name, op.Issuer)
+		if err != nil {
+			log.Fatalf("load oidc provider: %s failed: %s", op.Name, err)
+			return err
+		}
+	}
+
 	for _, pi := range providers.AllProvider() {
 		InitProviderSetting(pi)
 	}
@@ -9,16 +9,16 @@ import (
 	"github.com/synctv-org/synctv/internal/op"
 	"github.com/synctv-org/synctv/internal/rtmp"
 	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/zijiren233/livelib/av"
 	rtmps "github.com/zijiren233/livelib/server"
 )
 
 func InitRtmp(_ context.Context) error {
-	s := rtmps.NewRtmpServer(auth)
-	rtmp.Init(s)
+	rtmp.Init(rtmp.NewRtmpServer(handlePublisher, play))
 	return nil
 }
 
-func auth(reqAppName, reqChannelName string, isPublisher bool) (*rtmps.Channel, error) {
+func play(reqAppName, reqChannelName string) (*rtmps.Channel, error) {
 	roomE, err := op.LoadOrInitRoomByID(reqAppName)
 	if err != nil {
 		log.Errorf("rtmp: get room by id error: %v", err)
@@ -31,10 +31,6 @@ func auth(reqAppName, reqChannelName string, isPublisher bool) (*rtmps.Channel,
 		return nil, err
 	}
 
-	if isPublisher {
-		return handlePublisher(reqAppName, reqChannelName, room)
-	}
-
 	return handlePlayer(reqAppName, reqChannelName, room)
 }
 
@@ -50,16 +46,16 @@ func validateRoom(room *op.Room) error {
 	return nil
 }
 
-func handlePublisher(reqAppName, reqChannelName string, room *op.Room) (*rtmps.Channel, error) {
-	channelName, err := rtmp.AuthRtmpPublish(reqChannelName)
+func handlePublisher(reqAppName, reqChannelName string, r av.ReadCloser) error {
+	m, err := op.PublishMovie(reqAppName, reqChannelName)
 	if err != nil {
 		log.Errorf("rtmp: publish auth to %s error: %v", reqAppName, err)
-		return nil, err
+		return err
 	}
 
-	log.Infof("rtmp: publisher login success: %s/%s", reqAppName, channelName)
+	log.Infof("rtmp: publisher login success: %s", reqAppName)
 
-	return room.GetChannel(channelName)
+	return m.Publish(r)
 }
 
 func handlePlayer(reqAppName, reqChannelName string, room *op.Room) (*rtmps.Channel, error) {
@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/op"
+	sysnotify "github.com/synctv-org/synctv/internal/sysnotify"
+)
+
+func InitScheduler(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	go op.RunScheduler(ctx)
+
+	return sysnotify.RegisterSysNotifyTask(
+		0,
+		sysnotify.NewSysNotifyTask("scheduler", sysnotify.NotifyTypeEXIT, func() error {
+			cancel()
+			return nil
+		}),
+	)
+}
+
+func InitRecording(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	go op.RunRecordingReconciler(ctx)
+
+	return sysnotify.RegisterSysNotifyTask(
+		0,
+		sysnotify.NewSysNotifyTask("recording", sysnotify.NotifyTypeEXIT, func() error {
+			cancel()
+			return nil
+		}),
+	)
+}
@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	sysnotify "github.com/synctv-org/synctv/internal/sysnotify"
+	"github.com/synctv-org/synctv/internal/tracing"
+)
+
+func InitTracing(ctx context.Context) error {
+	if !conf.Conf.Tracing.Enable {
+		return nil
+	}
+
+	shutdown, err := tracing.Init(ctx, conf.Conf.Tracing)
+	if err != nil {
+		return err
+	}
+
+	log.AddHook(tracing.LogHook{})
+
+	log.Infof(
+		"tracing: export to %s over %s",
+		conf.Conf.Tracing.Endpoint,
+		conf.Conf.Tracing.Protocol,
+	)
+
+	return sysnotify.RegisterSysNotifyTask(
+		0,
+		sysnotify.NewSysNotifyTask("tracing", sysnotify.NotifyTypeEXIT, func() error {
+			return shutdown(context.Background())
+		}),
+	)
+}
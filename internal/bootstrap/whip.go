@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/whip"
+)
+
+func InitWHIP(_ context.Context) error {
+	return whip.Init(conf.Conf.Server.WHIP)
+}
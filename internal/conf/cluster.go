@@ -0,0 +1,33 @@
+package conf
+
+type ClusterType string
+
+const (
+	ClusterTypeMemory ClusterType = "memory"
+	ClusterTypeRedis  ClusterType = "redis"
+)
+
+//nolint:tagliatelle
+type ClusterConfig struct {
+	Type ClusterType `env:"CLUSTER_TYPE" hc:"how room events are shared between nodes, support memory, redis. memory only works for a single node" lc:"default: memory" yaml:"type"`
+
+	Redis ClusterRedisConfig `yaml:"redis"`
+}
+
+//nolint:tagliatelle
+type ClusterRedisConfig struct {
+	Addr     string `env:"CLUSTER_REDIS_ADDR"     hc:"host:port of the redis server"                              yaml:"addr"`
+	Username string `env:"CLUSTER_REDIS_USERNAME"                                                                 yaml:"username"`
+	Password string `env:"CLUSTER_REDIS_PASSWORD"                                                                 yaml:"password"`
+	DB       int    `env:"CLUSTER_REDIS_DB"                                                                       yaml:"db"`
+	Channel  string `env:"CLUSTER_REDIS_CHANNEL"  hc:"all nodes of the same deployment must use the same channel" yaml:"channel"  lc:"default: synctv"`
+}
+
+func DefaultClusterConfig() ClusterConfig {
+	return ClusterConfig{
+		Type: ClusterTypeMemory,
+		Redis: ClusterRedisConfig{
+			Channel: "synctv",
+		},
+	}
+}
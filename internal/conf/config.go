@@ -21,8 +21,20 @@ type Config struct {
 	// Oauth2Plugins
 	Oauth2Plugins Oauth2Plugins `yaml:"oauth2_plugins"`
 
+	// OidcProviders
+	OidcProviders OidcProviders `yaml:"oidc_providers"`
+
+	// Ldap
+	Ldap LdapConfig `yaml:"ldap"`
+
 	// RateLimit
 	RateLimit RateLimitConfig `yaml:"rate_limit"`
+
+	// Cluster
+	Cluster ClusterConfig `yaml:"cluster"`
+
+	// Tracing
+	Tracing TracingConfig `yaml:"tracing"`
 }
 
 func (c *Config) Save(file string) error {
@@ -46,7 +58,19 @@ func DefaultConfig() *Config {
 		// OAuth2
 		Oauth2Plugins: DefaultOauth2Plugins(),
 
+		// OIDC
+		OidcProviders: DefaultOidcProviders(),
+
+		// Ldap
+		Ldap: DefaultLdapConfig(),
+
 		// RateLimit
 		RateLimit: DefaultRateLimitConfig(),
+
+		// Cluster
+		Cluster: DefaultClusterConfig(),
+
+		// Tracing
+		Tracing: DefaultTracingConfig(),
 	}
 }
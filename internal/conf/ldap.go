@@ -0,0 +1,30 @@
+package conf
+
+//nolint:tagliatelle
+type LdapConfig struct {
+	Enable             bool   `env:"LDAP_ENABLE"               yaml:"enable"`
+	URL                string `env:"LDAP_URL"                  yaml:"url"                  hc:"ldap://host:389 or ldaps://host:636"`
+	StartTLS           bool   `env:"LDAP_START_TLS"            yaml:"start_tls"            hc:"upgrade a ldap:// connection with StartTLS"`
+	InsecureSkipVerify bool   `env:"LDAP_INSECURE_SKIP_VERIFY" yaml:"insecure_skip_verify"`
+	BindDN             string `env:"LDAP_BIND_DN"              yaml:"bind_dn"              hc:"service account used to search users, empty for an anonymous search"`
+	BindPassword       string `env:"LDAP_BIND_PASSWORD"        yaml:"bind_password"`
+	BaseDN             string `env:"LDAP_BASE_DN"              yaml:"base_dn"              hc:"where users are searched"`
+	UserFilter         string `env:"LDAP_USER_FILTER"          yaml:"user_filter"          hc:"%s is replaced with the escaped username, for active directory use (sAMAccountName=%s)" lc:"default: (uid=%s)"`
+	IDAttribute        string `env:"LDAP_ID_ATTRIBUTE"         yaml:"id_attribute"         hc:"stable id of a user, e.g. entryUUID or objectGUID"                                      lc:"default: uid"`
+	UsernameAttribute  string `env:"LDAP_USERNAME_ATTRIBUTE"   yaml:"username_attribute"   hc:"username of a new user"                                                                 lc:"default: uid"`
+	GroupBaseDN        string `env:"LDAP_GROUP_BASE_DN"        yaml:"group_base_dn"        hc:"where groups are searched, groups are not checked when empty"`
+	GroupFilter        string `env:"LDAP_GROUP_FILTER"         yaml:"group_filter"         hc:"%s is replaced with the escaped dn of the user"                                         lc:"default: (|(member=%s)(uniqueMember=%s))"`
+	RequiredGroupDN    string `env:"LDAP_REQUIRED_GROUP_DN"    yaml:"required_group_dn"    hc:"only members of this group can login"`
+	AdminGroupDN       string `env:"LDAP_ADMIN_GROUP_DN"       yaml:"admin_group_dn"       hc:"members of this group are admins, the role is synced on every login"`
+	AutoProvision      bool   `env:"LDAP_AUTO_PROVISION"       yaml:"auto_provision"       hc:"create a user on the first login, otherwise only users that logged in before can login"`
+}
+
+func DefaultLdapConfig() LdapConfig {
+	return LdapConfig{
+		UserFilter:        "(uid=%s)",
+		IDAttribute:       "uid",
+		UsernameAttribute: "uid",
+		GroupFilter:       "(|(member=%s)(uniqueMember=%s))",
+		AutoProvision:     true,
+	}
+}
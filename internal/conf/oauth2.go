@@ -9,3 +9,16 @@ type Oauth2Plugins []struct {
 func DefaultOauth2Plugins() Oauth2Plugins {
 	return nil
 }
+
+// OidcProviders are generic openid connect providers, the client and the
+// claims are set in the settings of each provider
+//
+//nolint:tagliatelle
+type OidcProviders []struct {
+	Name   string `yaml:"name"   hc:"name of the provider, used in the login url"`
+	Issuer string `yaml:"issuer" hc:"default issuer url, the openid configuration is discovered from it"`
+}
+
+func DefaultOidcProviders() OidcProviders {
+	return nil
+}
@@ -2,10 +2,13 @@ package conf
 
 //nolint:tagliatelle
 type ServerConfig struct {
-	HTTP           HTTPServerConfig `yaml:"http"`
-	RTMP           RTMPServerConfig `yaml:"rtmp"`
-	ProxyCachePath string           `yaml:"proxy_cache_path" env:"SERVER_PROXY_CACHE_PATH" hc:"proxy cache path storage path, empty means use memory cache"`
-	ProxyCacheSize string           `yaml:"proxy_cache_size" env:"SERVER_PROXY_CACHE_SIZE" hc:"proxy cache max size, example: 1MB 1GB, default 1GB"`
+	HTTP           HTTPServerConfig    `yaml:"http"`
+	RTMP           RTMPServerConfig    `yaml:"rtmp"`
+	WHIP           WHIPServerConfig    `yaml:"whip"`
+	Metrics        MetricsServerConfig `yaml:"metrics"`
+	ProxyCachePath string              `yaml:"proxy_cache_path" env:"SERVER_PROXY_CACHE_PATH" hc:"proxy cache path storage path, empty means use memory cache"`
+	ProxyCacheSize string              `yaml:"proxy_cache_size" env:"SERVER_PROXY_CACHE_SIZE" hc:"proxy cache max size, example: 1MB 1GB, default 1GB"`
+	FFmpegPath     string              `yaml:"ffmpeg_path"      env:"SERVER_FFMPEG_PATH"      hc:"ffmpeg binary used to transcode live channels, empty means search PATH"`
 }
 
 //nolint:tagliatelle
@@ -23,6 +26,25 @@ type RTMPServerConfig struct {
 	Port   uint16 `env:"RTMP_PORT"   yaml:"port"   lc:"default use server port"`
 }
 
+// WHIPServerConfig serves WebRTC (WHIP) publishing on the http server,
+// only h264 video is ingested because hls and flv playback need aac audio
+//
+//nolint:tagliatelle
+type WHIPServerConfig struct {
+	Enable   bool   `env:"WHIP_ENABLE"    yaml:"enable"`
+	UDPPort  uint16 `env:"WHIP_UDP_PORT"  yaml:"udp_port"  lc:"default use random ports"`
+	PublicIP string `env:"WHIP_PUBLIC_IP" yaml:"public_ip"                               hc:"ip announced to publishers behind nat"`
+}
+
+// MetricsServerConfig serves prometheus metrics on /metrics
+type MetricsServerConfig struct {
+	Enable  bool   `env:"METRICS_ENABLE"   yaml:"enable"`
+	Listen  string `env:"METRICS_LISTEN"   yaml:"listen"   lc:"default use http listen"`
+	Port    uint16 `env:"METRICS_PORT"     yaml:"port"     lc:"default use the http server"`
+	Token   string `env:"METRICS_TOKEN"    yaml:"token"                                     hc:"bearer token required to scrape, empty means no auth"`
+	PerRoom bool   `env:"METRICS_PER_ROOM" yaml:"per_room"                                  hc:"export the hub metrics of every room with a room_id label"`
+}
+
 func DefaultServerConfig() ServerConfig {
 	return ServerConfig{
 		HTTP: HTTPServerConfig{
@@ -35,6 +57,12 @@ func DefaultServerConfig() ServerConfig {
 			Enable: true,
 			Port:   0,
 		},
+		WHIP: WHIPServerConfig{
+			Enable: false,
+		},
+		Metrics: MetricsServerConfig{
+			Enable: false,
+		},
 		ProxyCachePath: "",
 	}
 }
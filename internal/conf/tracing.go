@@ -0,0 +1,28 @@
+package conf
+
+type TracingProtocol string
+
+const (
+	TracingProtocolGRPC TracingProtocol = "grpc"
+	TracingProtocolHTTP TracingProtocol = "http"
+)
+
+//nolint:tagliatelle
+type TracingConfig struct {
+	Enable      bool            `env:"TRACING_ENABLE"       yaml:"enable"`
+	Endpoint    string          `env:"TRACING_ENDPOINT"     yaml:"endpoint"     hc:"host:port of the otlp collector"`
+	Protocol    TracingProtocol `env:"TRACING_PROTOCOL"     yaml:"protocol"     hc:"otlp protocol, support grpc, http"            lc:"default: grpc"`
+	Insecure    bool            `env:"TRACING_INSECURE"     yaml:"insecure"     hc:"connect to the collector without tls"`
+	ServiceName string          `env:"TRACING_SERVICE_NAME" yaml:"service_name"                                                   lc:"default: synctv"`
+	SampleRatio float64         `env:"TRACING_SAMPLE_RATIO" yaml:"sample_ratio" hc:"fraction of new traces recorded, from 0 to 1"`
+}
+
+func DefaultTracingConfig() TracingConfig {
+	return TracingConfig{
+		Enable:      false,
+		Endpoint:    "localhost:4317",
+		Protocol:    TracingProtocolGRPC,
+		ServiceName: "synctv",
+		SampleRatio: 1,
+	}
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+// audit logs are never updated or deleted
+
+func CreateAuditLog(log *model.AuditLog) error {
+	return db.Create(log).Error
+}
+
+func GetAuditLogs(scopes ...func(*gorm.DB) *gorm.DB) ([]*model.AuditLog, error) {
+	var logs []*model.AuditLog
+
+	err := db.Scopes(scopes...).Find(&logs).Error
+
+	return logs, err
+}
+
+func GetAuditLogsCount(scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+
+	err := db.Model(&model.AuditLog{}).Scopes(scopes...).Count(&count).Error
+
+	return count, err
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, '!' is the escape
+// character because a backslash is read differently by the databases
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// WhereAuditAction matches the action, or every action under it when it
+// ends with a dot
+func WhereAuditAction(action string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if action != "" && action[len(action)-1] == '.' {
+			return db.Where("action LIKE ? ESCAPE '!'", likeEscaper.Replace(action)+"%")
+		}
+
+		return db.Where("action = ?", action)
+	}
+}
+
+func WhereCreatedAtBetween(since, until time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if !since.IsZero() {
+			db = db.Where("created_at >= ?", since)
+		}
+
+		if !until.IsZero() {
+			db = db.Where("created_at < ?", until)
+		}
+
+		return db
+	}
+}
+
+func WhereActorID(actorID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("actor_id = ?", actorID)
+	}
+}
+
+func WhereTargetID(targetID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("target_id = ?", targetID)
+	}
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func TestWhereAuditAction(t *testing.T) {
+	initTestDB(t)
+
+	for _, action := range []model.AuditAction{
+		"room.settings",
+		"room.member.ban",
+		"roomXsettings",
+		"user.delete",
+	} {
+		if err := CreateAuditLog(&model.AuditLog{ActorID: "actor", Action: action}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		action string
+		want   int
+	}{
+		{"room.", 2},
+		{"room.settings", 1},
+		// wildcards in the filter are matched literally
+		{"room%", 0},
+		{"room_", 0},
+		{"room_.", 0},
+		{"%.", 0},
+		{"_.", 0},
+	} {
+		logs, err := GetAuditLogs(WhereAuditAction(tc.action))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(logs) != tc.want {
+			t.Errorf("action %q matched %d logs, want %d", tc.action, len(logs), tc.want)
+		}
+	}
+}
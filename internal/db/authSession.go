@@ -0,0 +1,62 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	ErrAuthSessionNotFound = "auth session"
+)
+
+// SaveAuthSession creates or replaces the session, expired sessions are
+// pruned as new ones come in
+func SaveAuthSession(s *model.AuthSession) error {
+	err := db.Where("expires_at < ?", time.Now()).Delete(&model.AuthSession{}).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Save(s).Error
+}
+
+func GetAuthSession(kind model.AuthSessionKind, id string) (*model.AuthSession, error) {
+	var s model.AuthSession
+
+	err := db.Where("kind = ? AND id = ? AND expires_at > ?", kind, id, time.Now()).
+		First(&s).
+		Error
+
+	return &s, HandleNotFound(err, ErrAuthSessionNotFound)
+}
+
+// TakeAuthSession returns and deletes the session, only one caller gets it
+func TakeAuthSession(kind model.AuthSessionKind, id string) (*model.AuthSession, error) {
+	s, err := GetAuthSession(kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := DeleteAuthSession(kind, id); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// AddAuthSessionAttempt counts an attempt, the session is not found once it
+// has had maxAttempts
+func AddAuthSessionAttempt(kind model.AuthSessionKind, id string, maxAttempts int) error {
+	result := db.Model(&model.AuthSession{}).
+		Where("kind = ? AND id = ? AND attempts < ? AND expires_at > ?", kind, id, maxAttempts, time.Now()).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+
+	return HandleUpdateResult(result, ErrAuthSessionNotFound)
+}
+
+func DeleteAuthSession(kind model.AuthSessionKind, id string) error {
+	result := db.Where("kind = ? AND id = ?", kind, id).Delete(&model.AuthSession{})
+	return HandleUpdateResult(result, ErrAuthSessionNotFound)
+}
@@ -0,0 +1,312 @@
+package db
+
+import (
+	"archive/zip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	json "github.com/json-iterator/go"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BackupVersion is bumped whenever the backup layout changes incompatibly
+const BackupVersion = 1
+
+const (
+	backupManifestFile = "manifest.json"
+	backupBatchSize    = 500
+)
+
+var ErrDatabaseNotEmpty = errors.New("database is not empty")
+
+type BackupManifest struct {
+	Tables          map[string]int64  `json:"tables"`
+	DatabaseVersion string            `json:"databaseVersion"`
+	DatabaseType    conf.DatabaseType `json:"databaseType"`
+	Version         int               `json:"version"`
+	CreatedAt       int64             `json:"createdAt"`
+}
+
+// Backup streams every table into a zip archive, one gob stream of row batches per table.
+// Rows are read without hooks so encrypted vendor secrets are kept as they are stored.
+func Backup(w io.Writer) (*BackupManifest, error) {
+	manifest := &BackupManifest{
+		Version:         BackupVersion,
+		DatabaseVersion: CurrentVersion,
+		DatabaseType:    conf.Conf.Database.Type,
+		CreatedAt:       time.Now().UnixMilli(),
+		Tables:          make(map[string]int64, len(models)),
+	}
+
+	zw := zip.NewWriter(w)
+
+	for _, m := range models {
+		table, err := tableName(m)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := zw.Create(table + ".gob")
+		if err != nil {
+			return nil, err
+		}
+
+		count, err := backupTable(gob.NewEncoder(f), m)
+		if err != nil {
+			return nil, fmt.Errorf("backup table %s failed: %w", table, err)
+		}
+
+		manifest.Tables[table] = count
+	}
+
+	f, err := zw.Create(backupManifestFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.NewEncoder(f).Encode(manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, zw.Close()
+}
+
+func backupTable(enc *gob.Encoder, m any) (int64, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(m); err != nil {
+		return 0, err
+	}
+
+	// ids are time sorted, so parent movies are restored before their children
+	order := clause.OrderBy{}
+	for _, f := range stmt.Schema.PrimaryFields {
+		order.Columns = append(order.Columns, clause.OrderByColumn{
+			Column: clause.Column{Name: f.DBName},
+		})
+	}
+
+	sliceType := reflect.SliceOf(reflect.TypeOf(m))
+
+	var count int64
+
+	for offset := 0; ; offset += backupBatchSize {
+		rows := reflect.New(sliceType)
+
+		err := db.Session(&gorm.Session{SkipHooks: true}).
+			Model(m).
+			Order(order).
+			Offset(offset).
+			Limit(backupBatchSize).
+			Find(rows.Interface()).
+			Error
+		if err != nil {
+			return count, err
+		}
+
+		n := rows.Elem().Len()
+		if n == 0 {
+			return count, nil
+		}
+
+		if err := enc.Encode(rows.Interface()); err != nil {
+			return count, err
+		}
+
+		count += int64(n)
+
+		if n < backupBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// Restore loads a backup into the database, it refuses to overwrite a database
+// that is already in use unless force is set, then all existing rows are deleted.
+func Restore(r io.ReaderAt, size int64, force bool) (*BackupManifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup: %w", err)
+	}
+
+	manifest, err := readBackupManifest(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		empty, err := isFreshDatabase()
+		if err != nil {
+			return nil, err
+		}
+
+		if !empty {
+			return nil, ErrDatabaseNotEmpty
+		}
+	}
+
+	// a failed restore rolls back to the rows that were there before
+	err = withoutForeignKeyChecks(func(conn *gorm.DB) error {
+		return conn.Transaction(func(tx *gorm.DB) error {
+			return restoreTables(tx, zr, manifest)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func restoreTables(tx *gorm.DB, zr *zip.Reader, manifest *BackupManifest) error {
+	for i := len(models) - 1; i >= 0; i-- {
+		err := tx.Session(&gorm.Session{AllowGlobalUpdate: true, SkipHooks: true}).
+			Delete(models[i]).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range models {
+		table, err := tableName(m)
+		if err != nil {
+			return err
+		}
+
+		f, err := zr.Open(table + ".gob")
+		if err != nil {
+			return fmt.Errorf("invalid backup: %w", err)
+		}
+
+		count, err := restoreTable(tx, gob.NewDecoder(f), m)
+		f.Close()
+
+		if err != nil {
+			return fmt.Errorf("restore table %s failed: %w", table, err)
+		}
+
+		if count != manifest.Tables[table] {
+			return fmt.Errorf(
+				"restore table %s failed: expected %d rows, got %d",
+				table,
+				manifest.Tables[table],
+				count,
+			)
+		}
+
+		log.Infof("restored table %s: %d rows", table, count)
+	}
+
+	return nil
+}
+
+func restoreTable(tx *gorm.DB, dec *gob.Decoder, m any) (int64, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(m); err != nil {
+		return 0, err
+	}
+
+	sliceType := reflect.SliceOf(reflect.TypeOf(m))
+
+	var count int64
+
+	for {
+		rows := reflect.New(sliceType)
+
+		err := dec.Decode(rows.Interface())
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+
+		if err != nil {
+			return count, err
+		}
+
+		// insert columns instead of structs, gorm replaces zero values of
+		// fields with a default tag, e.g. a disabled room setting would be enabled
+		values := make([]map[string]any, rows.Elem().Len())
+		for i := range values {
+			rv := rows.Elem().Index(i).Elem()
+
+			values[i] = make(map[string]any, len(stmt.Schema.DBNames))
+			for _, name := range stmt.Schema.DBNames {
+				values[i][name], _ = stmt.Schema.FieldsByDBName[name].ValueOf(
+					tx.Statement.Context,
+					rv,
+				)
+			}
+		}
+
+		if err := tx.Table(stmt.Schema.Table).Create(&values).Error; err != nil {
+			return count, err
+		}
+
+		count += int64(len(values))
+	}
+}
+
+func readBackupManifest(zr *zip.Reader) (*BackupManifest, error) {
+	f, err := zr.Open(backupManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup: %w", err)
+	}
+	defer f.Close()
+
+	manifest := new(BackupManifest)
+	if err := json.NewDecoder(f).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("invalid backup: %w", err)
+	}
+
+	if manifest.Version < 1 || manifest.Version > BackupVersion {
+		return nil, fmt.Errorf("unsupported backup version: %d", manifest.Version)
+	}
+
+	// rows are stored as the models of that database version
+	if manifest.DatabaseVersion != CurrentVersion {
+		return nil, fmt.Errorf(
+			"backup database version %s does not match current version %s, restore it with the same synctv version",
+			manifest.DatabaseVersion,
+			CurrentVersion,
+		)
+	}
+
+	return manifest, nil
+}
+
+// isFreshDatabase reports whether only the initial root and guest users exist
+func isFreshDatabase() (bool, error) {
+	var rooms int64
+	if err := db.Model(&model.Room{}).Count(&rooms).Error; err != nil {
+		return false, err
+	}
+
+	if rooms != 0 {
+		return false, nil
+	}
+
+	var users int64
+
+	err := db.Model(&model.User{}).
+		Where("id <> ? AND role <> ?", GuestUserID, model.RoleRoot).
+		Count(&users).
+		Error
+
+	return users == 0, err
+}
+
+func tableName(m any) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(m); err != nil {
+		return "", err
+	}
+
+	return stmt.Schema.Table, nil
+}
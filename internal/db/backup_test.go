@@ -0,0 +1,178 @@
+package db
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+func initTestDB(t *testing.T) {
+	t.Helper()
+
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.Database.Type = conf.DatabaseTypeSqlite3
+
+	d, err := gorm.Open(
+		sqlite.Open(filepath.Join(t.TempDir(), "synctv.db")),
+		&gorm.Config{TranslateError: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Init(d, conf.DatabaseTypeSqlite3); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createTestRoom(t *testing.T, username string) *model.Room {
+	t.Helper()
+
+	u, err := CreateUser(username, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := CreateRoom(username, "", 0, WithCreator(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func backupTestDB(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if _, err := Backup(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// rewriteManifest returns the backup with the manifest changed by fn
+func rewriteManifest(t *testing.T, backup []byte, fn func(*BackupManifest)) []byte {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(backup), int64(len(backup)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := io.ReadAll(r)
+		r.Close()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if f.Name == backupManifestFile {
+			manifest := new(BackupManifest)
+			if err := json.Unmarshal(data, manifest); err != nil {
+				t.Fatal(err)
+			}
+
+			fn(manifest)
+
+			if data, err = json.Marshal(manifest); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestRestoreRoundTrip(t *testing.T) {
+	initTestDB(t)
+
+	room := createTestRoom(t, "alice")
+	backup := backupTestDB(t)
+
+	initTestDB(t)
+
+	if _, err := Restore(bytes.NewReader(backup), int64(len(backup)), false); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := GetRoomByID(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Name != room.Name || restored.CreatorID != room.CreatorID {
+		t.Fatalf("restored room %+v, want %+v", restored, room)
+	}
+
+	_, err = Restore(bytes.NewReader(backup), int64(len(backup)), false)
+	if !errors.Is(err, ErrDatabaseNotEmpty) {
+		t.Fatalf("restore into a used database: %v", err)
+	}
+}
+
+func TestRestoreFailureRollsBack(t *testing.T) {
+	initTestDB(t)
+
+	createTestRoom(t, "alice")
+
+	// rooms are restored after users, so the users are already in when it fails
+	backup := rewriteManifest(t, backupTestDB(t), func(m *BackupManifest) {
+		m.Tables["rooms"]++
+	})
+
+	bob := createTestRoom(t, "bob")
+
+	if _, err := Restore(bytes.NewReader(backup), int64(len(backup)), true); err == nil {
+		t.Fatal("restore with a row count mismatch succeeded")
+	}
+
+	if _, err := GetUserByUsername("bob"); err != nil {
+		t.Fatalf("user created after the backup was lost: %v", err)
+	}
+
+	if _, err := GetRoomByID(bob.ID); err != nil {
+		t.Fatalf("room created after the backup was lost: %v", err)
+	}
+
+	var fk int
+	if err := db.Raw("PRAGMA foreign_keys").Scan(&fk).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if fk != 1 {
+		t.Fatal("foreign keys were left disabled")
+	}
+}
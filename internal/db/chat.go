@@ -0,0 +1,119 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	ErrChatMessageNotFound = "chat message"
+)
+
+func CreateChatMessage(message *model.ChatMessage) error {
+	return db.Create(message).Error
+}
+
+func GetChatMessages(
+	roomID string,
+	scopes ...func(*gorm.DB) *gorm.DB,
+) ([]*model.ChatMessage, error) {
+	var messages []*model.ChatMessage
+
+	err := db.Where("room_id = ?", roomID).Scopes(scopes...).Find(&messages).Error
+
+	return messages, err
+}
+
+func GetChatMessagesCount(roomID string, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+
+	err := db.Model(&model.ChatMessage{}).
+		Where("room_id = ?", roomID).
+		Scopes(scopes...).
+		Count(&count).
+		Error
+
+	return count, err
+}
+
+// GetLatestChatMessages returns the latest limit messages of the room in ascending order
+func GetLatestChatMessages(roomID string, limit int) ([]*model.ChatMessage, error) {
+	messages, err := GetChatMessages(roomID, OrderByCreatedAtDesc, func(db *gorm.DB) *gorm.DB {
+		return db.Limit(limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+// PruneChatMessages keeps only the latest keep messages of the room
+func PruneChatMessages(roomID string, keep int) error {
+	if keep <= 0 {
+		return DeleteChatMessagesByRoomID(roomID)
+	}
+
+	var oldest model.ChatMessage
+
+	err := db.Select("created_at").
+		Where("room_id = ?", roomID).
+		Order("created_at desc").
+		Offset(keep).
+		First(&oldest).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return db.Where("room_id = ? AND created_at <= ? AND pinned = ?", roomID, oldest.CreatedAt, false).
+		Delete(&model.ChatMessage{}).
+		Error
+}
+
+func GetChatMessage(roomID, id string) (*model.ChatMessage, error) {
+	var message model.ChatMessage
+
+	err := db.Where("room_id = ? AND id = ?", roomID, id).First(&message).Error
+
+	return &message, HandleNotFound(err, ErrChatMessageNotFound)
+}
+
+func DeleteChatMessage(roomID, id string) error {
+	result := db.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.ChatMessage{})
+	return HandleUpdateResult(result, ErrChatMessageNotFound)
+}
+
+func SetChatMessagePinned(roomID, id string, pinned bool) error {
+	result := db.Model(&model.ChatMessage{}).
+		Where("room_id = ? AND id = ?", roomID, id).
+		Update("pinned", pinned)
+
+	return HandleUpdateResult(result, ErrChatMessageNotFound)
+}
+
+func GetPinnedChatMessages(roomID string) ([]*model.ChatMessage, error) {
+	return GetChatMessages(roomID, OrderByCreatedAtAsc, func(db *gorm.DB) *gorm.DB {
+		return db.Where("pinned = ?", true)
+	})
+}
+
+func DeleteChatMessagesByRoomID(roomID string) error {
+	return db.Where("room_id = ?", roomID).Delete(&model.ChatMessage{}).Error
+}
+
+func WhereCreatedAtBefore(t time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("created_at < ?", t)
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"strings"
 
@@ -95,6 +96,14 @@ func Close() {
 	}
 }
 
+// WithContext runs the statement with the context of the request, so that its
+// span is a child of the request span
+func WithContext(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.WithContext(ctx)
+	}
+}
+
 func Paginate(page, pageSize int) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		if page <= 0 {
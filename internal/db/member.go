@@ -1,6 +1,8 @@
 package db
 
 import (
+	"time"
+
 	"github.com/synctv-org/synctv/internal/model"
 	"gorm.io/gorm"
 )
@@ -93,6 +95,20 @@ func RoomUnbanMember(roomID, userID string) error {
 	return HandleUpdateResult(result, ErrRoomMemberNotFound)
 }
 
+func RoomMuteMember(roomID, userID string, until time.Time) error {
+	result := db.Model(&model.RoomMember{}).
+		Where("room_id = ? AND user_id = ?", roomID, userID).
+		Update("muted_until", until)
+	return HandleUpdateResult(result, ErrRoomMemberNotFound)
+}
+
+func RoomUnmuteMember(roomID, userID string) error {
+	result := db.Model(&model.RoomMember{}).
+		Where("room_id = ? AND user_id = ?", roomID, userID).
+		Update("muted_until", nil)
+	return HandleUpdateResult(result, ErrRoomMemberNotFound)
+}
+
 func DeleteRoomMember(roomID, userID string) error {
 	result := db.
 		Where("NOT EXISTS (?)",
@@ -96,6 +96,26 @@ func SaveMovie(movie *model.Movie, columns ...clause.Column) error {
 	return HandleUpdateResult(result, ErrRoomOrMovieNotFound)
 }
 
+func GetMoviePublishKeyVersion(roomID, id string) (uint32, error) {
+	var version uint32
+
+	err := db.Model(&model.Movie{}).
+		Where("room_id = ? AND id = ?", roomID, id).
+		Select("publish_key_version").
+		Take(&version).Error
+
+	return version, HandleNotFound(err, ErrRoomOrMovieNotFound)
+}
+
+// IncrMoviePublishKeyVersion invalidates the publish keys of the movie
+func IncrMoviePublishKeyVersion(roomID, id string) error {
+	result := db.Model(&model.Movie{}).
+		Where("room_id = ? AND id = ?", roomID, id).
+		UpdateColumn("publish_key_version", gorm.Expr("publish_key_version + ?", 1))
+
+	return HandleUpdateResult(result, ErrRoomOrMovieNotFound)
+}
+
 func SwapMoviePositions(roomID, movie1ID, movie2ID string) error {
 	return db.Transaction(func(tx *gorm.DB) error {
 		var movie1, movie2 model.Movie
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	ErrMovieRequestNotFound = "movie request"
+)
+
+var ErrAlreadyVoted = errors.New("already voted")
+
+func CreateMovieRequest(request *model.MovieRequest) error {
+	return db.Create(request).Error
+}
+
+// GetMovieRequests returns the requests of the room, most voted first
+func GetMovieRequests(
+	roomID string,
+	scopes ...func(*gorm.DB) *gorm.DB,
+) ([]*model.MovieRequest, error) {
+	var requests []*model.MovieRequest
+
+	err := db.Where("room_id = ?", roomID).
+		Order("votes desc").
+		Order("created_at asc").
+		Scopes(scopes...).
+		Find(&requests).
+		Error
+
+	return requests, err
+}
+
+func GetMovieRequestsCount(roomID string) (int64, error) {
+	var count int64
+
+	err := db.Model(&model.MovieRequest{}).
+		Where("room_id = ?", roomID).
+		Count(&count).
+		Error
+
+	return count, err
+}
+
+func GetMovieRequest(roomID, id string) (*model.MovieRequest, error) {
+	var request model.MovieRequest
+
+	err := db.Where("room_id = ? AND id = ?", roomID, id).First(&request).Error
+
+	return &request, HandleNotFound(err, ErrMovieRequestNotFound)
+}
+
+func DeleteMovieRequest(roomID, id string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.MovieRequest{})
+		if err := HandleUpdateResult(result, ErrMovieRequestNotFound); err != nil {
+			return err
+		}
+
+		return tx.Where("request_id = ?", id).Delete(&model.MovieRequestVote{}).Error
+	})
+}
+
+// PromoteMovieRequest removes the request and creates its movie in one
+// transaction, the request is claimed by its removal so that it is only
+// promoted once.
+func PromoteMovieRequest(roomID, id string, movie *model.Movie) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.MovieRequest{})
+		if err := HandleUpdateResult(result, ErrMovieRequestNotFound); err != nil {
+			return err
+		}
+
+		if err := tx.Where("request_id = ?", id).Delete(&model.MovieRequestVote{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(movie).Error
+	})
+}
+
+// VoteMovieRequest adds the vote of the user and returns the updated request
+func VoteMovieRequest(roomID, id, userID string) (*model.MovieRequest, error) {
+	var request model.MovieRequest
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("room_id = ? AND id = ?", roomID, id).First(&request).Error; err != nil {
+			return HandleNotFound(err, ErrMovieRequestNotFound)
+		}
+
+		err := tx.Create(&model.MovieRequestVote{
+			RequestID: id,
+			UserID:    userID,
+		}).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrAlreadyVoted
+			}
+			return err
+		}
+
+		request.Votes++
+
+		return tx.Model(&request).Update("votes", gorm.Expr("votes + 1")).Error
+	})
+
+	return &request, err
+}
+
+// UnvoteMovieRequest removes the vote of the user and returns the updated request
+func UnvoteMovieRequest(roomID, id, userID string) (*model.MovieRequest, error) {
+	var request model.MovieRequest
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("room_id = ? AND id = ?", roomID, id).First(&request).Error; err != nil {
+			return HandleNotFound(err, ErrMovieRequestNotFound)
+		}
+
+		result := tx.Where("request_id = ? AND user_id = ?", id, userID).
+			Delete(&model.MovieRequestVote{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.New("not voted")
+		}
+
+		request.Votes--
+
+		return tx.Model(&request).Update("votes", gorm.Expr("votes - 1")).Error
+	})
+
+	return &request, err
+}
+
+// GetVotedMovieRequestIDs returns which of the requests the user voted for
+func GetVotedMovieRequestIDs(userID string, ids []string) (map[string]struct{}, error) {
+	var voted []string
+
+	err := db.Model(&model.MovieRequestVote{}).
+		Where("user_id = ? AND request_id IN ?", userID, ids).
+		Pluck("request_id", &voted).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]struct{}, len(voted))
+	for _, id := range voted {
+		m[id] = struct{}{}
+	}
+
+	return m, nil
+}
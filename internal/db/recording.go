@@ -0,0 +1,95 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+const (
+	ErrRecordingNotFound = "recording"
+)
+
+func CreateRecording(recording *model.Recording) error {
+	return db.Create(recording).Error
+}
+
+func SaveRecording(recording *model.Recording) error {
+	return db.Save(recording).Error
+}
+
+func GetRecording(roomID, id string) (*model.Recording, error) {
+	var recording model.Recording
+
+	err := db.Where("room_id = ? AND id = ?", roomID, id).First(&recording).Error
+
+	return &recording, HandleNotFound(err, ErrRecordingNotFound)
+}
+
+func GetRecordingsByRoomID(roomID string) ([]*model.Recording, error) {
+	var recordings []*model.Recording
+
+	err := db.Where("room_id = ?", roomID).Order("created_at desc").Find(&recordings).Error
+
+	return recordings, err
+}
+
+func DeleteRecording(roomID, id string) error {
+	result := db.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.Recording{})
+	return HandleUpdateResult(result, ErrRecordingNotFound)
+}
+
+// GetRecordingsSize returns the bytes used by the recordings of the room,
+// or of all rooms when roomID is empty
+func GetRecordingsSize(roomID string) (int64, error) {
+	var size int64
+
+	tx := db.Model(&model.Recording{})
+	if roomID != "" {
+		tx = tx.Where("room_id = ?", roomID)
+	}
+
+	err := tx.Select("COALESCE(SUM(size), 0)").Scan(&size).Error
+
+	return size, err
+}
+
+// UpdateRecordingSize stores the size of a recording in progress, which also
+// marks it as alive
+func UpdateRecordingSize(id string, size int64) error {
+	return db.Model(&model.Recording{}).
+		Where("id = ? AND status = ?", id, model.RecordingStatusRecording).
+		Update("size", size).
+		Error
+}
+
+// GetStaleRecordings returns the recordings in progress that were not
+// updated since before
+func GetStaleRecordings(before time.Time) ([]*model.Recording, error) {
+	var recordings []*model.Recording
+
+	err := db.Where("status = ? AND updated_at < ?", model.RecordingStatusRecording, before).
+		Find(&recordings).
+		Error
+
+	return recordings, err
+}
+
+// FailStaleRecording marks the recording failed if it is still stale,
+// it reports whether it was marked
+func FailStaleRecording(id string, before time.Time, size int64) (bool, error) {
+	result := db.Model(&model.Recording{}).
+		Where(
+			"id = ? AND status = ? AND updated_at < ?",
+			id,
+			model.RecordingStatusRecording,
+			before,
+		).
+		Updates(map[string]any{
+			"status":   model.RecordingStatusFailed,
+			"size":     size,
+			"ended_at": time.Now(),
+		})
+
+	return result.RowsAffected > 0, result.Error
+}
@@ -233,3 +233,25 @@ func SetRoomCurrent(roomID string, current *model.Current) error {
 
 	return HandleUpdateResult(result, ErrRoomNotFound)
 }
+
+// ImportRoom creates the room with its settings and members, and then its movies,
+// parents must come before their children in movies.
+func ImportRoom(r *model.Room, movies []*model.Movie) error {
+	return Transactional(func(tx *gorm.DB) error {
+		if err := tx.Create(r).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("room already exists")
+			}
+			return fmt.Errorf("failed to create room: %w", err)
+		}
+
+		for _, m := range movies {
+			m.RoomID = r.ID
+			if err := tx.Create(m).Error; err != nil {
+				return fmt.Errorf("failed to create movie %s: %w", m.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
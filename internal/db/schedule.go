@@ -0,0 +1,179 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	ErrRoomScheduleNotFound = "room schedule"
+)
+
+func CreateRoomSchedule(schedule *model.RoomSchedule) error {
+	return db.Create(schedule).Error
+}
+
+func GetRoomSchedule(roomID, id string) (*model.RoomSchedule, error) {
+	var schedule model.RoomSchedule
+
+	err := db.Where("room_id = ? AND id = ?", roomID, id).First(&schedule).Error
+
+	return &schedule, HandleNotFound(err, ErrRoomScheduleNotFound)
+}
+
+// GetUpcomingRoomSchedules returns the schedules of the room that have not started yet
+func GetUpcomingRoomSchedules(roomID string) ([]*model.RoomSchedule, error) {
+	var schedules []*model.RoomSchedule
+
+	err := db.Where("room_id = ? AND started = ?", roomID, false).
+		Order("start_at asc").
+		Find(&schedules).
+		Error
+
+	return schedules, err
+}
+
+func DeleteRoomSchedule(roomID, id string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("room_id = ? AND id = ?", roomID, id).Delete(&model.RoomSchedule{})
+		if err := HandleUpdateResult(result, ErrRoomScheduleNotFound); err != nil {
+			return err
+		}
+
+		return tx.Where("schedule_id = ?", id).Delete(&model.RoomScheduleSubscriber{}).Error
+	})
+}
+
+// GetDueRoomSchedules returns the schedules that should have started by now
+func GetDueRoomSchedules(now time.Time) ([]*model.RoomSchedule, error) {
+	var schedules []*model.RoomSchedule
+
+	err := db.Where("started = ? AND start_at <= ?", false, now).Find(&schedules).Error
+
+	return schedules, err
+}
+
+// GetDueRoomScheduleReminders returns the schedules whose reminder should have been sent by now
+func GetDueRoomScheduleReminders(now time.Time) ([]*model.RoomSchedule, error) {
+	var schedules []*model.RoomSchedule
+
+	err := db.Where("reminded = ? AND started = ? AND remind_at <= ?", false, false, now).
+		Find(&schedules).
+		Error
+
+	return schedules, err
+}
+
+// GetNextRoomScheduleTime returns the earliest pending start or reminder,
+// the zero time means nothing is pending.
+func GetNextRoomScheduleTime() (time.Time, error) {
+	var next time.Time
+
+	var s model.RoomSchedule
+
+	err := db.Select("start_at").
+		Where("started = ?", false).
+		Order("start_at asc").
+		First(&s).
+		Error
+	switch {
+	case err == nil:
+		next = s.StartAt
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return time.Time{}, err
+	}
+
+	s = model.RoomSchedule{}
+
+	err = db.Select("remind_at").
+		Where("reminded = ? AND started = ? AND remind_at IS NOT NULL", false, false).
+		Order("remind_at asc").
+		First(&s).
+		Error
+	switch {
+	case err == nil:
+		if s.RemindAt != nil && (next.IsZero() || s.RemindAt.Before(next)) {
+			next = *s.RemindAt
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return time.Time{}, err
+	}
+
+	return next, nil
+}
+
+// MarkRoomScheduleStarted reports whether this call claimed the start,
+// so that only one node starts a schedule.
+func MarkRoomScheduleStarted(id string) (bool, error) {
+	result := db.Model(&model.RoomSchedule{}).
+		Where("id = ? AND started = ?", id, false).
+		Update("started", true)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// MarkRoomScheduleReminded reports whether this call claimed the reminder.
+func MarkRoomScheduleReminded(id string) (bool, error) {
+	result := db.Model(&model.RoomSchedule{}).
+		Where("id = ? AND reminded = ?", id, false).
+		Update("reminded", true)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func SubscribeRoomSchedule(id, userID string) error {
+	err := db.Create(&model.RoomScheduleSubscriber{
+		ScheduleID: id,
+		UserID:     userID,
+	}).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil
+	}
+
+	return err
+}
+
+func UnsubscribeRoomSchedule(id, userID string) error {
+	return db.Where("schedule_id = ? AND user_id = ?", id, userID).
+		Delete(&model.RoomScheduleSubscriber{}).
+		Error
+}
+
+// GetSubscribedRoomScheduleIDs returns which of the schedules the user subscribed to
+func GetSubscribedRoomScheduleIDs(userID string, ids []string) (map[string]struct{}, error) {
+	var subscribed []string
+
+	err := db.Model(&model.RoomScheduleSubscriber{}).
+		Where("user_id = ? AND schedule_id IN ?", userID, ids).
+		Pluck("schedule_id", &subscribed).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]struct{}, len(subscribed))
+	for _, id := range subscribed {
+		m[id] = struct{}{}
+	}
+
+	return m, nil
+}
+
+// GetRoomScheduleSubscriberEmails returns the bound emails of the subscribers
+// that are still active members of the room and not banned
+func GetRoomScheduleSubscriberEmails(roomID, id string) ([]string, error) {
+	var emails []string
+
+	err := db.Model(&model.User{}).
+		Joins("JOIN room_schedule_subscribers ON room_schedule_subscribers.user_id = users.id").
+		Joins("JOIN room_members ON room_members.user_id = users.id AND room_members.room_id = ?", roomID).
+		Where("room_schedule_subscribers.schedule_id = ? AND users.email IS NOT NULL", id).
+		Where("room_members.status = ? AND users.role >= ?", model.RoomMemberStatusActive, model.RoleUser).
+		Pluck("users.email", &emails).
+		Error
+
+	return emails, err
+}
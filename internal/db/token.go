@@ -0,0 +1,49 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+const (
+	ErrAPITokenNotFound = "api token"
+)
+
+func CreateAPIToken(t *model.APIToken) error {
+	return db.Create(t).Error
+}
+
+func GetAPITokenByHash(hashed string) (*model.APIToken, error) {
+	var t model.APIToken
+
+	err := db.Where("hashed_token = ?", hashed).First(&t).Error
+
+	return &t, HandleNotFound(err, ErrAPITokenNotFound)
+}
+
+func GetAPITokensByUserID(userID string) ([]*model.APIToken, error) {
+	var tokens []*model.APIToken
+
+	err := db.Where("user_id = ?", userID).Order("created_at asc").Find(&tokens).Error
+
+	return tokens, err
+}
+
+func CountAPITokensByUserID(userID string) (int64, error) {
+	var count int64
+
+	err := db.Model(&model.APIToken{}).Where("user_id = ?", userID).Count(&count).Error
+
+	return count, err
+}
+
+func UpdateAPITokenLastUsed(id string) error {
+	result := db.Model(&model.APIToken{}).Where("id = ?", id).Update("last_used_at", time.Now())
+	return HandleUpdateResult(result, ErrAPITokenNotFound)
+}
+
+func DeleteAPIToken(userID, id string) error {
+	result := db.Where("user_id = ? AND id = ?", userID, id).Delete(&model.APIToken{})
+	return HandleUpdateResult(result, ErrAPITokenNotFound)
+}
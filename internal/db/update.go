@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"fmt"
 
 	log "github.com/sirupsen/logrus"
@@ -15,8 +16,9 @@ type dbVersion struct {
 	NextVersion string
 }
 
-const CurrentVersion = "0.0.13"
+const CurrentVersion = "0.0.27"
 
+// models are listed parents first, a restore fills the tables in this order
 var models = []any{
 	new(model.Setting),
 	new(model.User),
@@ -29,6 +31,16 @@ var models = []any{
 	new(model.AlistVendor),
 	new(model.EmbyVendor),
 	new(model.VendorBackend),
+	new(model.ChatMessage),
+	new(model.MovieRequest),
+	new(model.MovieRequestVote),
+	new(model.RoomSchedule),
+	new(model.RoomScheduleSubscriber),
+	new(model.Recording),
+	new(model.AuditLog),
+	new(model.WebAuthnCredential),
+	new(model.APIToken),
+	new(model.AuthSession),
 }
 
 var dbVersions = map[string]dbVersion{
@@ -88,6 +100,48 @@ var dbVersions = map[string]dbVersion{
 		NextVersion: "0.0.13",
 	},
 	"0.0.13": {
+		NextVersion: "0.0.14",
+	},
+	"0.0.14": {
+		NextVersion: "0.0.15",
+	},
+	"0.0.15": {
+		NextVersion: "0.0.16",
+	},
+	"0.0.16": {
+		NextVersion: "0.0.17",
+	},
+	"0.0.17": {
+		NextVersion: "0.0.18",
+	},
+	"0.0.18": {
+		NextVersion: "0.0.19",
+	},
+	"0.0.19": {
+		NextVersion: "0.0.20",
+	},
+	"0.0.20": {
+		NextVersion: "0.0.21",
+	},
+	"0.0.21": {
+		NextVersion: "0.0.22",
+	},
+	"0.0.22": {
+		NextVersion: "0.0.23",
+	},
+	"0.0.23": {
+		NextVersion: "0.0.24",
+	},
+	"0.0.24": {
+		NextVersion: "0.0.25",
+	},
+	"0.0.25": {
+		NextVersion: "0.0.26",
+	},
+	"0.0.26": {
+		NextVersion: "0.0.27",
+	},
+	"0.0.27": {
 		NextVersion: "",
 	},
 }
@@ -150,44 +204,43 @@ func UpgradeDatabase() error {
 func autoMigrate(dst ...any) error {
 	log.Info("migrating database...")
 
-	switch conf.Conf.Database.Type {
-	case conf.DatabaseTypeMysql:
-		if err := db.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
-			return err
+	return withoutForeignKeyChecks(func(tx *gorm.DB) error {
+		if conf.Conf.Database.Type == conf.DatabaseTypeMysql {
+			tx = tx.Set("gorm:table_options", "ENGINE=InnoDB CHARSET=utf8mb4")
 		}
-		defer func() {
-			err := db.Exec("SET FOREIGN_KEY_CHECKS = 1").Error
-			if err != nil {
-				log.Fatalf("failed to set foreign key checks: %s", err.Error())
-			}
-		}()
 
-		return db.Set("gorm:table_options", "ENGINE=InnoDB CHARSET=utf8mb4").AutoMigrate(dst...)
-	case conf.DatabaseTypeSqlite3:
-		if err := db.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
-			return err
+		return tx.AutoMigrate(dst...)
+	})
+}
+
+// withoutForeignKeyChecks runs fn on a single connection, because the
+// foreign key switches only apply to the session that sets them.
+func withoutForeignKeyChecks(fn func(tx *gorm.DB) error) error {
+	return db.Connection(func(tx *gorm.DB) (err error) {
+		var enable, disable string
+
+		switch conf.Conf.Database.Type {
+		case conf.DatabaseTypeMysql:
+			disable, enable = "SET FOREIGN_KEY_CHECKS = 0", "SET FOREIGN_KEY_CHECKS = 1"
+		case conf.DatabaseTypeSqlite3:
+			disable, enable = "PRAGMA foreign_keys = OFF", "PRAGMA foreign_keys = ON"
+		case conf.DatabaseTypePostgres:
+			// foreign keys cannot be switched off without a superuser, models
+			// are listed parents first so that tables are filled in order
+			return fn(tx)
+		default:
+			return fmt.Errorf("unknown database type: %s", conf.Conf.Database.Type)
 		}
-		defer func() {
-			err := db.Exec("PRAGMA foreign_keys = ON").Error
-			if err != nil {
-				log.Fatalf("failed to set foreign key checks: %s", err.Error())
-			}
-		}()
 
-		return db.AutoMigrate(dst...)
-	case conf.DatabaseTypePostgres:
-		if err := db.Exec("SET CONSTRAINTS ALL DEFERRED").Error; err != nil {
+		if err := tx.Exec(disable).Error; err != nil {
 			return err
 		}
 		defer func() {
-			err := db.Exec("SET CONSTRAINTS ALL IMMEDIATE").Error
-			if err != nil {
-				log.Fatalf("failed to set foreign key checks: %s", err.Error())
+			if e := tx.Exec(enable).Error; e != nil {
+				err = errors.Join(err, fmt.Errorf("failed to enable foreign key checks: %w", e))
 			}
 		}()
 
-		return db.AutoMigrate(dst...)
-	default:
-		return fmt.Errorf("unknown database type: %s", conf.Conf.Database.Type)
-	}
+		return fn(tx)
+	})
 }
@@ -272,6 +272,19 @@ func GetBindProviders(uid string) ([]*model.UserProvider, error) {
 	return providers, nil
 }
 
+func HasBindProvider(uid, p string) (bool, error) {
+	var count int64
+
+	err := db.Model(&model.UserProvider{}).
+		Where("user_id = ? AND provider = ?", uid, p).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to get bind provider: %w", err)
+	}
+
+	return count > 0, nil
+}
+
 func GetUserByUsername(username string) (*model.User, error) {
 	var user model.User
 
@@ -533,3 +546,96 @@ func SetUserHashedPassword(id string, hashedPassword []byte) error {
 	result := db.Model(&model.User{}).Where("id = ?", id).Update("hashed_password", hashedPassword)
 	return HandleUpdateResult(result, ErrUserNotFound)
 }
+
+func SetUserTotp(id string, secret []byte, recoveryCodes string, lastStep int64) error {
+	result := db.Model(&model.User{}).Where("id = ?", id).Updates(map[string]any{
+		"totp_secret":         secret,
+		"totp_recovery_codes": recoveryCodes,
+		"totp_last_step":      lastStep,
+		"totp_failures":       0,
+		"totp_locked_until":   0,
+	})
+
+	return HandleUpdateResult(result, ErrUserNotFound)
+}
+
+// GetUserTotp only loads the 2fa columns
+func GetUserTotp(id string) (*model.User, error) {
+	var user model.User
+
+	err := db.Select(
+		"totp_secret",
+		"totp_recovery_codes",
+		"totp_last_step",
+		"totp_failures",
+		"totp_locked_until",
+	).Where("id = ?", id).First(&user).Error
+
+	return &user, HandleNotFound(err, ErrUserNotFound)
+}
+
+func ResetUserTotp(id string) error {
+	return SetUserTotp(id, nil, "", 0)
+}
+
+// SetUserTotpLastStep fails when the step was already used, so concurrent
+// logins cannot use the same code
+func SetUserTotpLastStep(id string, step int64) error {
+	result := db.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+
+	return HandleUpdateResult(result, ErrUserNotFound)
+}
+
+// SetUserTotpRecoveryCodes fails when the codes were changed since old was
+// read, so a recovery code cannot be used twice
+func SetUserTotpRecoveryCodes(id, old, recoveryCodes string) error {
+	result := db.Model(&model.User{}).
+		Where("id = ? AND totp_recovery_codes = ?", id, old).
+		Update("totp_recovery_codes", recoveryCodes)
+
+	return HandleUpdateResult(result, ErrUserNotFound)
+}
+
+// AddUserTotpFailure counts an invalid code, once maxFailures are reached 2fa
+// is locked until lockedUntil and the count starts again
+func AddUserTotpFailure(id string, maxFailures int, lockedUntil int64) (locked bool, err error) {
+	err = Transactional(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ?", id).
+			Update("totp_failures", gorm.Expr("totp_failures + 1"))
+		if err := HandleUpdateResult(result, ErrUserNotFound); err != nil {
+			return err
+		}
+
+		var failures int
+
+		err := tx.Model(&model.User{}).
+			Where("id = ?", id).
+			Select("totp_failures").
+			First(&failures).Error
+		if err != nil {
+			return err
+		}
+
+		if failures < maxFailures {
+			return nil
+		}
+
+		locked = true
+
+		return tx.Model(&model.User{}).Where("id = ?", id).Updates(map[string]any{
+			"totp_failures":     0,
+			"totp_locked_until": lockedUntil,
+		}).Error
+	})
+
+	return locked, err
+}
+
+func ResetUserTotpFailures(id string) error {
+	return db.Model(&model.User{}).
+		Where("id = ? AND totp_failures <> 0", id).
+		Update("totp_failures", 0).Error
+}
@@ -0,0 +1,27 @@
+package db
+
+import "testing"
+
+func TestHasBindProvider(t *testing.T) {
+	initTestDB(t)
+
+	u, err := CreateUser("ldap-user", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := BindProvider(u.ID, "ldap", "uid=ldap-user"); err != nil {
+		t.Fatal(err)
+	}
+
+	for p, want := range map[string]bool{"ldap": true, "github": false} {
+		ok, err := HasBindProvider(u.ID, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok != want {
+			t.Errorf("provider %s bound: %v, want %v", p, ok, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+const (
+	ErrWebAuthnCredentialNotFound = "webauthn credential"
+)
+
+func CreateWebAuthnCredential(c *model.WebAuthnCredential) error {
+	return db.Create(c).Error
+}
+
+func GetWebAuthnCredentialsByUserID(userID string) ([]*model.WebAuthnCredential, error) {
+	var credentials []*model.WebAuthnCredential
+
+	err := db.Where("user_id = ?", userID).Order("created_at asc").Find(&credentials).Error
+
+	return credentials, err
+}
+
+func CountWebAuthnCredentialsByUserID(userID string) (int64, error) {
+	var count int64
+
+	err := db.Model(&model.WebAuthnCredential{}).Where("user_id = ?", userID).Count(&count).Error
+
+	return count, err
+}
+
+// UpdateWebAuthnCredentialUsed stores the counter and flags of an assertion
+func UpdateWebAuthnCredentialUsed(id string, signCount uint32, flags uint8) error {
+	result := db.Model(&model.WebAuthnCredential{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"sign_count":   signCount,
+			"flags":        flags,
+			"last_used_at": time.Now(),
+		})
+
+	return HandleUpdateResult(result, ErrWebAuthnCredentialNotFound)
+}
+
+func RenameWebAuthnCredential(userID, id, name string) error {
+	result := db.Model(&model.WebAuthnCredential{}).
+		Where("user_id = ? AND id = ?", userID, id).
+		Update("name", name)
+
+	return HandleUpdateResult(result, ErrWebAuthnCredentialNotFound)
+}
+
+func DeleteWebAuthnCredential(userID, id string) error {
+	result := db.Where("user_id = ? AND id = ?", userID, id).Delete(&model.WebAuthnCredential{})
+	return HandleUpdateResult(result, ErrWebAuthnCredentialNotFound)
+}
+
+// DeleteWebAuthnCredentialsByUserID returns the number of deleted passkeys
+func DeleteWebAuthnCredentialsByUserID(userID string) (int64, error) {
+	result := db.Where("user_id = ?", userID).Delete(&model.WebAuthnCredential{})
+	return result.RowsAffected, result.Error
+}
@@ -5,9 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html/template"
 	"net/url"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/Boostport/mjml-go"
@@ -64,6 +64,7 @@ var (
 	testTemplate             *template.Template
 	captchaTemplate          *template.Template
 	retrievePasswordTemplate *template.Template
+	scheduleReminderTemplate *template.Template
 )
 
 func init() {
@@ -114,6 +115,22 @@ func init() {
 	}
 
 	retrievePasswordTemplate = t
+
+	body, err = mjml.ToHTML(
+		context.Background(),
+		stream.BytesToString(emailtemplate.ScheduleReminderMjml),
+		mjml.WithMinify(true),
+	)
+	if err != nil {
+		log.Fatalf("mjml schedule reminder template error: %v", err)
+	}
+
+	t, err = template.New("").Parse(body)
+	if err != nil {
+		log.Fatalf("parse schedule reminder template error: %v", err)
+	}
+
+	scheduleReminderTemplate = t
 }
 
 type testPayload struct {
@@ -135,6 +152,15 @@ type retrievePasswordPayload struct {
 	Year int
 }
 
+type scheduleReminderPayload struct {
+	Title    string
+	RoomName string
+	StartAt  string
+	URL      string
+
+	Year int
+}
+
 func SendBindCaptchaEmail(userID, userEmail string) error {
 	if !EnableEmail.Get() {
 		return ErrEmailNotEnabled
@@ -391,3 +417,62 @@ func VerifyRetrievePasswordCaptchaEmail(userID, email, captcha string) (bool, er
 
 	return false, nil
 }
+
+// SendScheduleReminderEmail sends one email per address so that
+// subscribers do not see each other's addresses.
+// host may be empty, then the email has no link to the room.
+func SendScheduleReminderEmail(
+	emails []string,
+	title, roomName, roomID, host string,
+	startAt time.Time,
+) error {
+	if !EnableEmail.Get() {
+		return ErrEmailNotEnabled
+	}
+
+	if len(emails) == 0 {
+		return nil
+	}
+
+	var link string
+	if host != "" {
+		u, err := url.Parse(host)
+		if err != nil {
+			return err
+		}
+
+		u.Path = "web/cinema/" + roomID
+		link = u.String()
+	}
+
+	pool, err := getSMTPPool()
+	if err != nil {
+		return err
+	}
+
+	out := bytes.NewBuffer(nil)
+
+	err = scheduleReminderTemplate.Execute(out, scheduleReminderPayload{
+		Title:    title,
+		RoomName: roomName,
+		StartAt:  startAt.Format(time.RFC1123Z),
+		URL:      link,
+		Year:     time.Now().Year(),
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, email := range emails {
+		if err := pool.SendEmail(
+			[]string{email},
+			"SyncTV Watch Party Reminder: "+title,
+			out.String(),
+		); err != nil {
+			errs = append(errs, fmt.Errorf("send to %s: %w", email, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+package email
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestScheduleReminderTemplateEscapes(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+
+	err := scheduleReminderTemplate.Execute(out, scheduleReminderPayload{
+		Title:    `<script>alert(1)</script>`,
+		RoomName: `<a href="https://evil.example.com">room</a>`,
+		StartAt:  "Mon, 02 Jan 2006 15:04:05 +0000",
+		URL:      "https://synctv.example.com/web/cinema/room",
+		Year:     2006,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := out.String()
+
+	for _, raw := range []string{"<script>", `<a href="https://evil.example.com">`} {
+		if strings.Contains(body, raw) {
+			t.Fatalf("%s is not escaped", raw)
+		}
+	}
+
+	if !strings.Contains(body, `href="https://synctv.example.com/web/cinema/room"`) {
+		t.Fatal("room link is missing")
+	}
+}
+
+// the templates are escaped on their first execution, which fails on any
+// action html/template cannot place
+func TestTemplatesRender(t *testing.T) {
+	for name, tc := range map[string]struct {
+		tmpl    *template.Template
+		payload any
+	}{
+		"test":    {testTemplate, testPayload{Username: "user", Year: 2006}},
+		"captcha": {captchaTemplate, captchaPayload{Captcha: "123456", Year: 2006}},
+		"retrieve password": {retrievePasswordTemplate, retrievePasswordPayload{
+			Captcha: "123456",
+			Host:    "https://synctv.example.com",
+			URL:     "https://synctv.example.com/web/auth/reset",
+			Year:    2006,
+		}},
+	} {
+		if err := tc.tmpl.Execute(bytes.NewBuffer(nil), tc.payload); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
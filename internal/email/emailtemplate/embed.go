@@ -11,4 +11,7 @@ var (
 
 	//go:embed retrieve_password.mjml
 	RetrievePasswordMjml []byte
+
+	//go:embed schedule_reminder.mjml
+	ScheduleReminderMjml []byte
 )
@@ -0,0 +1,113 @@
+// Package flvtag builds flv tag bodies from raw h264 and aac elementary streams.
+package flvtag
+
+import (
+	"encoding/binary"
+
+	"github.com/zijiren233/livelib/av"
+	"github.com/zijiren233/livelib/container/flv"
+)
+
+const (
+	NaluTypeIDR = 5
+	NaluTypeSPS = 7
+	NaluTypePPS = 8
+	NaluTypeAUD = 9
+)
+
+// Packet wraps a tag body into a packet with its header demuxed
+func Packet(isVideo bool, ts uint32, tag []byte) (*av.Packet, error) {
+	p := &av.Packet{
+		IsVideo:   isVideo,
+		IsAudio:   !isVideo,
+		TimeStamp: ts,
+		Data:      tag,
+	}
+
+	return p, flv.NewDemuxer().DemuxH(p)
+}
+
+// AVCConfig is the sequence header carrying an AVCDecoderConfigurationRecord
+func AVCConfig(sps, pps []byte) []byte {
+	b := make([]byte, 0, 16+len(sps)+len(pps))
+	b = append(b, av.FRAME_KEY<<4|av.CODEC_AVC, av.AVC_SEQHDR, 0, 0, 0)
+	b = append(b, 1, sps[1], sps[2], sps[3], 0xff, 0xe1)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(sps)))
+	b = append(b, sps...)
+	b = append(b, 1)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(pps)))
+
+	return append(b, pps...)
+}
+
+// AVCFrame packs nal units as length prefixed avc data, cts is the
+// composition time offset in milliseconds
+func AVCFrame(nalus [][]byte, key bool, cts int32) []byte {
+	frameType := byte(av.FRAME_INTER)
+	if key {
+		frameType = av.FRAME_KEY
+	}
+
+	size := 5
+	for _, nalu := range nalus {
+		size += 4 + len(nalu)
+	}
+
+	b := make([]byte, 0, size)
+	b = append(b, frameType<<4|av.CODEC_AVC, av.AVC_NALU, byte(cts>>16), byte(cts>>8), byte(cts))
+
+	for _, nalu := range nalus {
+		b = binary.BigEndian.AppendUint32(b, uint32(len(nalu)))
+		b = append(b, nalu...)
+	}
+
+	return b
+}
+
+// SplitAnnexB returns the nal units of an access unit separated by start codes
+func SplitAnnexB(b []byte) [][]byte {
+	var (
+		nalus [][]byte
+		start = -1
+	)
+
+	for i := 0; i+2 < len(b); {
+		if b[i] != 0 || b[i+1] != 0 || b[i+2] != 1 {
+			i++
+			continue
+		}
+
+		if start >= 0 {
+			end := i
+			// the zero of a four byte start code belongs to it
+			if end > start && b[end-1] == 0 {
+				end--
+			}
+
+			if end > start {
+				nalus = append(nalus, b[start:end])
+			}
+		}
+
+		i += 3
+		start = i
+	}
+
+	if start >= 0 && start < len(b) {
+		nalus = append(nalus, b[start:])
+	}
+
+	return nalus
+}
+
+const aacTagHeader = av.SOUND_AAC<<4 | av.SOUND_44Khz<<2 | av.SOUND_16BIT<<1 | av.SOUND_STEREO
+
+// AACConfig is the sequence header carrying an AudioSpecificConfig
+func AACConfig(asc []byte) []byte {
+	return append([]byte{aacTagHeader, av.AAC_SEQHDR}, asc...)
+}
+
+// AACFrame wraps a raw aac frame without its adts header
+func AACFrame(frame []byte) []byte {
+	return append([]byte{aacTagHeader, av.AAC_RAW}, frame...)
+}
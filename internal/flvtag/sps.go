@@ -0,0 +1,210 @@
+package flvtag
+
+import (
+	"errors"
+
+	"github.com/zijiren233/livelib/av"
+)
+
+var ErrInvalidSPS = errors.New("invalid sps")
+
+// AVCConfigSPS returns the first sps of an avc sequence header tag body
+func AVCConfigSPS(tag []byte) ([]byte, error) {
+	// tag header, then the AVCDecoderConfigurationRecord
+	if len(tag) < 13 || tag[0]&0x0f != av.CODEC_AVC || tag[1] != av.AVC_SEQHDR ||
+		tag[10]&0x1f == 0 {
+		return nil, ErrInvalidSPS
+	}
+
+	n := int(tag[11])<<8 | int(tag[12])
+	if n == 0 || len(tag) < 13+n {
+		return nil, ErrInvalidSPS
+	}
+
+	return tag[13 : 13+n], nil
+}
+
+// SPSResolution returns the cropped picture size of an h264 sps
+func SPSResolution(sps []byte) (width, height int, err error) {
+	if len(sps) < 4 || sps[0]&0x1f != NaluTypeSPS {
+		return 0, 0, ErrInvalidSPS
+	}
+
+	r := &bitReader{b: unescapeRBSP(sps[1:])}
+
+	profile := r.bits(8)
+	r.skip(16) // constraint flags, level
+	r.ue()     // seq_parameter_set_id
+
+	chromaFormat := uint(1)
+
+	switch profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chromaFormat = r.ue()
+		if chromaFormat == 3 {
+			r.skip(1) // separate_colour_plane_flag
+		}
+
+		r.ue()    // bit_depth_luma_minus8
+		r.ue()    // bit_depth_chroma_minus8
+		r.skip(1) // qpprime_y_zero_transform_bypass_flag
+		if r.bits(1) == 1 {
+			lists := 8
+			if chromaFormat == 3 {
+				lists = 12
+			}
+
+			for i := range lists {
+				if r.bits(1) == 0 {
+					continue
+				}
+
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+
+				skipScalingList(r, size)
+			}
+		}
+	}
+
+	r.ue() // log2_max_frame_num_minus4
+
+	switch r.ue() { // pic_order_cnt_type
+	case 0:
+		r.ue() // log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		r.skip(1) // delta_pic_order_always_zero_flag
+		r.se()    // offset_for_non_ref_pic
+		r.se()    // offset_for_top_to_bottom_field
+
+		for range r.ue() {
+			r.se()
+		}
+	}
+
+	r.ue()    // max_num_ref_frames
+	r.skip(1) // gaps_in_frame_num_value_allowed_flag
+
+	widthMbs := r.ue() + 1
+	heightMapUnits := r.ue() + 1
+
+	frameMbsOnly := r.bits(1)
+	if frameMbsOnly == 0 {
+		r.skip(1) // mb_adaptive_frame_field_flag
+	}
+
+	r.skip(1) // direct_8x8_inference_flag
+
+	var cropLeft, cropRight, cropTop, cropBottom uint
+	if r.bits(1) == 1 {
+		cropLeft, cropRight, cropTop, cropBottom = r.ue(), r.ue(), r.ue(), r.ue()
+	}
+
+	if r.err {
+		return 0, 0, ErrInvalidSPS
+	}
+
+	cropX, cropY := uint(1), 2-frameMbsOnly
+	switch chromaFormat {
+	case 1:
+		cropX, cropY = 2, 2*(2-frameMbsOnly)
+	case 2:
+		cropX = 2
+	}
+
+	width = int(widthMbs*16 - cropX*(cropLeft+cropRight))
+	height = int((2-frameMbsOnly)*heightMapUnits*16 - cropY*(cropTop+cropBottom))
+
+	if width <= 0 || height <= 0 {
+		return 0, 0, ErrInvalidSPS
+	}
+
+	return width, height, nil
+}
+
+func skipScalingList(r *bitReader, size int) {
+	last, next := 8, 8
+	for range size {
+		if next != 0 {
+			next = (last + r.se() + 256) % 256
+		}
+
+		if next != 0 {
+			last = next
+		}
+	}
+}
+
+// unescapeRBSP drops the emulation prevention bytes
+func unescapeRBSP(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	zeros := 0
+
+	for _, c := range b {
+		if zeros >= 2 && c == 3 {
+			zeros = 0
+			continue
+		}
+
+		if c == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// bitReader reads exp-golomb coded fields, reading past the end sets err
+type bitReader struct {
+	b   []byte
+	pos int
+	err bool
+}
+
+func (r *bitReader) bits(n int) uint {
+	var v uint
+	for range n {
+		if r.pos >= len(r.b)*8 {
+			r.err = true
+			return 0
+		}
+
+		v = v<<1 | uint(r.b[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+
+	return v
+}
+
+func (r *bitReader) skip(n int) {
+	r.bits(n)
+}
+
+func (r *bitReader) ue() uint {
+	zeros := 0
+	for r.bits(1) == 0 {
+		if r.err || zeros >= 32 {
+			r.err = true
+			return 0
+		}
+
+		zeros++
+	}
+
+	return 1<<zeros - 1 + r.bits(zeros)
+}
+
+func (r *bitReader) se() int {
+	v := r.ue()
+	if v&1 == 1 {
+		return int(v+1) / 2
+	}
+
+	return -int(v / 2)
+}
@@ -0,0 +1,109 @@
+package flvtag
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// sps of x264 encodes, both high profile ones contain emulation prevention
+// bytes and the 1080p one is cropped from 1088 lines
+var (
+	sps720p = []byte{
+		0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50, 0x05, 0xbb, 0x01, 0x10, 0x00,
+		0x00, 0x03, 0x00, 0x10, 0x00, 0x00, 0x03, 0x03, 0xc0, 0xf1, 0x83, 0x19, 0x60,
+	}
+	sps1080p = []byte{
+		0x67, 0x64, 0x00, 0x28, 0xac, 0xd9, 0x40, 0x78, 0x02, 0x27, 0xe5, 0x84, 0x00,
+		0x00, 0x03, 0x00, 0x04, 0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc6, 0x58,
+	}
+	sps360pBaseline = []byte{
+		0x67, 0x42, 0xc0, 0x1e, 0xda, 0x02, 0x80, 0xbf, 0xe5, 0x84, 0x00, 0x00, 0x03,
+		0x00, 0x04, 0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x58, 0xb9, 0x20,
+	}
+)
+
+func TestSPSResolution(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		sps           []byte
+		width, height int
+	}{
+		{"720p high", sps720p, 1280, 720},
+		{"1080p high", sps1080p, 1920, 1080},
+		{"360p baseline", sps360pBaseline, 640, 360},
+	} {
+		w, h, err := SPSResolution(tc.sps)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+
+		if w != tc.width || h != tc.height {
+			t.Errorf("%s: %dx%d, want %dx%d", tc.name, w, h, tc.width, tc.height)
+		}
+	}
+}
+
+func TestSPSResolutionInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		sps  []byte
+	}{
+		{"empty", nil},
+		{"pps", append([]byte{0x68}, sps720p[1:]...)},
+		{"truncated", sps720p[:8]},
+	} {
+		if _, _, err := SPSResolution(tc.sps); !errors.Is(err, ErrInvalidSPS) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, ErrInvalidSPS)
+		}
+	}
+}
+
+func TestUnescapeRBSP(t *testing.T) {
+	got := unescapeRBSP([]byte{1, 0, 0, 3, 1, 0, 0, 3, 0, 0, 3, 3})
+	if want := []byte{1, 0, 0, 1, 0, 0, 0, 0, 3}; !bytes.Equal(got, want) {
+		t.Fatalf("unescaped %x, want %x", got, want)
+	}
+}
+
+func TestExpGolomb(t *testing.T) {
+	// 1, 010, 011, 00100, 00101 are ue 0 to 4, read as se 0, 1, -1, 2, -2
+	r := &bitReader{b: []byte{0b10100110, 0b01000010, 0b10000000}}
+
+	for i, want := range []int{0, 1, -1, 2, -2} {
+		if v := r.se(); v != want {
+			t.Fatalf("value %d is %d, want %d", i, v, want)
+		}
+	}
+
+	if r.err {
+		t.Fatal("reading within the data failed")
+	}
+
+	r.bits(8)
+
+	if !r.err {
+		t.Fatal("reading past the end did not fail")
+	}
+}
+
+func TestAVCConfigSPS(t *testing.T) {
+	pps := []byte{0x68, 0xeb, 0xe3, 0xcb}
+
+	sps, err := AVCConfigSPS(AVCConfig(sps1080p, pps))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(sps, sps1080p) {
+		t.Fatalf("sps %x, want %x", sps, sps1080p)
+	}
+
+	if _, err := AVCConfigSPS(AVCFrame([][]byte{{0x65, 1}}, true, 0)); !errors.Is(
+		err,
+		ErrInvalidSPS,
+	) {
+		t.Fatalf("error %v for a frame, want %v", err, ErrInvalidSPS)
+	}
+}
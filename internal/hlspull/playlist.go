@@ -0,0 +1,127 @@
+package hlspull
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEncrypted = errors.New("encrypted hls segments are not supported")
+	ErrFMP4      = errors.New("fmp4 hls segments are not supported")
+)
+
+type segment struct {
+	url string
+	seq int64
+}
+
+type playlist struct {
+	// highest bandwidth variant stream of a master playlist
+	variant        string
+	segments       []segment
+	targetDuration time.Duration
+	endList        bool
+}
+
+func parsePlaylist(data, baseURL string) (*playlist, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url error: %w", err)
+	}
+
+	var (
+		pl        = &playlist{targetDuration: defaultTargetDuration}
+		seq       int64
+		bandwidth = -1
+		best      = -1
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			bandwidth = 0
+			if v, ok := attribute(line, "BANDWIDTH"); ok {
+				bandwidth, _ = strconv.Atoi(v)
+			}
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			d, err := strconv.ParseFloat(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"), 64)
+			if err == nil && d > 0 {
+				pl.targetDuration = time.Duration(d * float64(time.Second))
+			}
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			seq, _ = strconv.ParseInt(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"), 10, 64)
+		case strings.HasPrefix(line, "#EXT-X-KEY:"):
+			if v, _ := attribute(line, "METHOD"); v != "NONE" {
+				return nil, ErrEncrypted
+			}
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			return nil, ErrFMP4
+		case line == "#EXT-X-ENDLIST":
+			pl.endList = true
+		case strings.HasPrefix(line, "#"):
+		default:
+			u, err := base.Parse(line)
+			if err != nil {
+				return nil, fmt.Errorf("parse segment url error: %w", err)
+			}
+
+			if bandwidth < 0 {
+				pl.segments = append(pl.segments, segment{url: u.String(), seq: seq})
+				seq++
+
+				continue
+			}
+
+			if bandwidth > best {
+				best = bandwidth
+				pl.variant = u.String()
+			}
+
+			bandwidth = -1
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan m3u8 error: %w", err)
+	}
+
+	return pl, nil
+}
+
+// attribute returns the value of a tag attribute, quotes removed
+func attribute(line, name string) (string, bool) {
+	_, attrs, _ := strings.Cut(line, ":")
+	for attrs != "" {
+		var (
+			key, value string
+			ok         bool
+		)
+
+		key, attrs, ok = strings.Cut(attrs, "=")
+		if !ok {
+			return "", false
+		}
+
+		if strings.HasPrefix(attrs, `"`) {
+			value, attrs, _ = strings.Cut(attrs[1:], `"`)
+			attrs = strings.TrimPrefix(attrs, ",")
+		} else {
+			value, attrs, _ = strings.Cut(attrs, ",")
+		}
+
+		if strings.TrimSpace(key) == name {
+			return value, true
+		}
+	}
+
+	return "", false
+}
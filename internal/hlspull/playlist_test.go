@@ -0,0 +1,140 @@
+package hlspull
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseMasterPlaylist(t *testing.T) {
+	pl, err := parsePlaylist(`#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d401e,mp4a.40.2"
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2"
+high/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=1400000
+https://cdn.example.com/mid/index.m3u8
+`, "https://example.com/live/master.m3u8?token=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "https://example.com/live/high/index.m3u8"; pl.variant != want {
+		t.Fatalf("variant %q, want %q", pl.variant, want)
+	}
+
+	if len(pl.segments) != 0 {
+		t.Fatalf("master playlist has %d segments", len(pl.segments))
+	}
+}
+
+func TestParseMediaPlaylist(t *testing.T) {
+	pl, err := parsePlaylist(`#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:120
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:4.000,
+seg120.ts
+#EXTINF:4.000,
+
+/abs/seg121.ts
+#EXTINF:3.500,
+https://cdn.example.com/seg122.ts
+#EXT-X-ENDLIST
+`, "https://example.com/live/index.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pl.variant != "" {
+		t.Fatalf("media playlist has variant %q", pl.variant)
+	}
+
+	if pl.targetDuration != 4*time.Second || !pl.endList {
+		t.Fatalf("target duration %v, end list %v", pl.targetDuration, pl.endList)
+	}
+
+	want := []segment{
+		{url: "https://example.com/live/seg120.ts", seq: 120},
+		{url: "https://example.com/abs/seg121.ts", seq: 121},
+		{url: "https://cdn.example.com/seg122.ts", seq: 122},
+	}
+	if len(pl.segments) != len(want) {
+		t.Fatalf("%d segments, want %d", len(pl.segments), len(want))
+	}
+
+	for i, s := range pl.segments {
+		if s != want[i] {
+			t.Errorf("segment %d is %+v, want %+v", i, s, want[i])
+		}
+	}
+}
+
+func TestParsePlaylistDefaultTargetDuration(t *testing.T) {
+	pl, err := parsePlaylist("#EXTM3U\n#EXTINF:2,\na.ts\n", "http://example.com/index.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pl.targetDuration != defaultTargetDuration || pl.endList {
+		t.Fatalf("target duration %v, end list %v", pl.targetDuration, pl.endList)
+	}
+}
+
+func TestParsePlaylistUnsupported(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		data string
+	}{
+		{
+			ErrEncrypted,
+			`#EXTM3U
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin",IV=0x1
+#EXTINF:4,
+seg.ts
+`,
+		},
+		{
+			ErrEncrypted,
+			`#EXTM3U
+#EXT-X-KEY:URI="skd://key",METHOD="SAMPLE-AES"
+#EXTINF:4,
+seg.ts
+`,
+		},
+		{
+			ErrFMP4,
+			`#EXTM3U
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:4,
+seg.m4s
+`,
+		},
+	} {
+		if _, err := parsePlaylist(tc.data, "http://example.com/index.m3u8"); !errors.Is(
+			err,
+			tc.err,
+		) {
+			t.Errorf("error %v, want %v", err, tc.err)
+		}
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	line := `#EXT-X-STREAM-INF:CODECS="avc1.4d401f,mp4a.40.2",BANDWIDTH=2800000,NAME="a=b"`
+
+	for name, want := range map[string]string{
+		"CODECS":    "avc1.4d401f,mp4a.40.2",
+		"BANDWIDTH": "2800000",
+		"NAME":      "a=b",
+	} {
+		if v, ok := attribute(line, name); !ok || v != want {
+			t.Errorf("%s is %q, want %q", name, v, want)
+		}
+	}
+
+	if _, ok := attribute(line, "RESOLUTION"); ok {
+		t.Error("missing attribute found")
+	}
+}
@@ -0,0 +1,269 @@
+// Package hlspull follows a live hls media playlist and reads its mpeg-ts
+// segments as flv packets, so it can feed a livelib channel like a publisher.
+package hlspull
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/go-uhc"
+	"github.com/zijiren233/livelib/av"
+)
+
+const (
+	defaultTargetDuration = 6 * time.Second
+
+	// segments behind the live edge to start from, a small buffer so the
+	// next segment is published before the current one runs out
+	liveStartSegments = 2
+
+	// consecutive failed requests before giving up
+	maxFailures = 3
+
+	// the playlist must grow within this many target durations
+	stallTargetDurations = 3
+
+	// packets may run ahead of the wall clock by this much, a reader that
+	// falls further behind resyncs instead of bursting
+	maxLead = 500 * time.Millisecond
+	maxLag  = time.Second
+
+	maxPlaylistSize = 3 * 1024 * 1024
+	maxSegmentSize  = 64 * 1024 * 1024
+)
+
+var ErrPlaylistStalled = errors.New("hls playlist stopped updating")
+
+// Reader is an av.ReadCloser over a live hls stream
+type Reader struct {
+	url     string
+	headers map[string]string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	ch     chan *av.Packet
+	err    error
+
+	// pacing state, only touched by Read
+	startedAt time.Time
+	startTS   uint32
+}
+
+func NewReader(url string, headers map[string]string) *Reader {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Reader{
+		url:     url,
+		headers: headers,
+		ctx:     ctx,
+		cancel:  cancel,
+		ch:      make(chan *av.Packet, 1024),
+	}
+
+	go r.run()
+
+	return r
+}
+
+func (r *Reader) Read() (*av.Packet, error) {
+	p, ok := <-r.ch
+	if !ok {
+		return nil, r.err
+	}
+
+	// segments arrive whole, release their packets in real time
+	now := time.Now()
+	ahead := time.Duration(
+		int64(p.TimeStamp)-int64(r.startTS),
+	)*time.Millisecond - now.Sub(
+		r.startedAt,
+	)
+
+	switch {
+	case r.startedAt.IsZero(), ahead < -maxLag:
+		r.startedAt = now
+		r.startTS = p.TimeStamp
+	case ahead > maxLead:
+		select {
+		case <-time.After(ahead - maxLead):
+		case <-r.ctx.Done():
+			return nil, io.EOF
+		}
+	}
+
+	return p, nil
+}
+
+func (r *Reader) Close() error {
+	r.cancel()
+	return nil
+}
+
+func (r *Reader) run() {
+	defer close(r.ch)
+
+	r.err = r.follow()
+	if r.err == nil || r.ctx.Err() != nil {
+		r.err = io.EOF
+	}
+}
+
+func (r *Reader) follow() error {
+	pl, err := r.playlist(r.url)
+	if err != nil {
+		return err
+	}
+
+	playlistURL := r.url
+	if pl.variant != "" {
+		playlistURL = pl.variant
+
+		pl, err = r.playlist(playlistURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	var (
+		dmx      = newTSDemuxer()
+		lastSeq  = int64(-1)
+		lastNew  = time.Now()
+		failures int
+	)
+
+	// a finished playlist is played from the start
+	if !pl.endList && len(pl.segments) > liveStartSegments {
+		lastSeq = pl.segments[len(pl.segments)-liveStartSegments-1].seq
+	}
+
+	for {
+		added := false
+
+		for _, seg := range pl.segments {
+			if seg.seq <= lastSeq {
+				continue
+			}
+
+			lastSeq = seg.seq
+			added = true
+
+			if err := r.segment(seg.url, dmx); err != nil {
+				if r.ctx.Err() != nil {
+					return nil
+				}
+
+				failures++
+				if failures >= maxFailures {
+					return err
+				}
+
+				continue
+			}
+
+			failures = 0
+		}
+
+		if pl.endList {
+			return nil
+		}
+
+		wait := pl.targetDuration
+		if added {
+			lastNew = time.Now()
+		} else {
+			if time.Since(lastNew) > stallTargetDurations*pl.targetDuration {
+				return ErrPlaylistStalled
+			}
+
+			// poll faster until the next segment shows up
+			wait /= 2
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-r.ctx.Done():
+			return nil
+		}
+
+		next, err := r.playlist(playlistURL)
+		if err != nil {
+			if r.ctx.Err() != nil {
+				return nil
+			}
+
+			failures++
+			if failures >= maxFailures {
+				return err
+			}
+
+			continue
+		}
+
+		pl = next
+	}
+}
+
+func (r *Reader) playlist(u string) (*playlist, error) {
+	b, err := r.get(u, maxPlaylistSize)
+	if err != nil {
+		return nil, fmt.Errorf("get hls playlist error: %w", err)
+	}
+
+	return parsePlaylist(string(b), u)
+}
+
+func (r *Reader) segment(u string, dmx *tsDemuxer) error {
+	b, err := r.get(u, maxSegmentSize)
+	if err != nil {
+		return fmt.Errorf("get hls segment error: %w", err)
+	}
+
+	return dmx.demux(r.ctx, b, func(p *av.Packet) error {
+		select {
+		case r.ch <- p:
+			return nil
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		}
+	})
+}
+
+func (r *Reader) get(u string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", utils.UA)
+	}
+
+	resp, err := uhc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("response is larger than %d bytes", limit)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,249 @@
+package hlspull
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/asticode/go-astits"
+	"github.com/synctv-org/synctv/internal/flvtag"
+	"github.com/zijiren233/livelib/av"
+)
+
+const (
+	clockRate = 90000
+
+	// a larger timestamp jump is a discontinuity, the timeline continues
+	// from the previous packet
+	maxTimestampJump = 10 * clockRate
+
+	adtsHeaderLen = 7
+)
+
+var aacSampleRates = [...]int64{
+	96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350,
+}
+
+// clock maps 33 bit mpeg-ts timestamps onto a continuous millisecond timeline
+type clock struct {
+	started bool
+	last    int64
+	pos     int64
+}
+
+func (c *clock) ms(ts int64) uint32 {
+	if !c.started {
+		c.started = true
+		c.last = ts
+	}
+
+	delta := ts - c.last
+	switch {
+	case delta > 1<<32:
+		delta -= 1 << 33
+	case delta < -(1 << 32):
+		delta += 1 << 33
+	}
+
+	if delta > maxTimestampJump || delta < -maxTimestampJump {
+		delta = 0
+	}
+
+	c.last = ts
+	c.pos += delta
+
+	if c.pos < 0 {
+		return 0
+	}
+
+	return uint32(c.pos * 1000 / clockRate)
+}
+
+// tsDemuxer turns mpeg-ts segments with h264 and aac into flv packets,
+// codec state is kept across segments
+type tsDemuxer struct {
+	clock      clock
+	sps, pps   []byte
+	sentAVC    bool
+	asc        []byte
+	sentAAC    bool
+	streamType map[uint16]astits.StreamType
+}
+
+func newTSDemuxer() *tsDemuxer {
+	return &tsDemuxer{streamType: make(map[uint16]astits.StreamType)}
+}
+
+func (d *tsDemuxer) demux(ctx context.Context, segment []byte, emit func(*av.Packet) error) error {
+	dmx := astits.NewDemuxer(ctx, bytes.NewReader(segment))
+
+	for {
+		data, err := dmx.NextData()
+		if err != nil {
+			if errors.Is(err, astits.ErrNoMorePackets) || errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("demux ts error: %w", err)
+		}
+
+		switch {
+		case data.PMT != nil:
+			for _, es := range data.PMT.ElementaryStreams {
+				d.streamType[es.ElementaryPID] = es.StreamType
+			}
+		case data.PES != nil:
+			packets, err := d.pes(data.PID, data.PES)
+			if err != nil {
+				return err
+			}
+
+			for _, p := range packets {
+				if err := emit(p); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (d *tsDemuxer) pes(pid uint16, pes *astits.PESData) ([]*av.Packet, error) {
+	h := pes.Header.OptionalHeader
+	if h == nil || h.PTS == nil {
+		return nil, nil
+	}
+
+	pts := h.PTS.Base
+
+	dts := pts
+	if h.DTS != nil {
+		dts = h.DTS.Base
+	}
+
+	switch d.streamType[pid] {
+	case astits.StreamTypeH264Video:
+		return d.h264(pes.Data, pts, dts)
+	case astits.StreamTypeAACAudio:
+		return d.aac(pes.Data, pts)
+	default:
+		return nil, nil
+	}
+}
+
+func (d *tsDemuxer) h264(accessUnit []byte, pts, dts int64) ([]*av.Packet, error) {
+	var (
+		nalus [][]byte
+		key   bool
+	)
+
+	for _, nalu := range flvtag.SplitAnnexB(accessUnit) {
+		switch nalu[0] & 0x1f {
+		case flvtag.NaluTypeSPS:
+			if !bytes.Equal(d.sps, nalu) {
+				d.sps = bytes.Clone(nalu)
+				d.sentAVC = false
+			}
+		case flvtag.NaluTypePPS:
+			if !bytes.Equal(d.pps, nalu) {
+				d.pps = bytes.Clone(nalu)
+				d.sentAVC = false
+			}
+		case flvtag.NaluTypeAUD:
+		case flvtag.NaluTypeIDR:
+			key = true
+			nalus = append(nalus, nalu)
+		default:
+			nalus = append(nalus, nalu)
+		}
+	}
+
+	ts := d.clock.ms(dts)
+
+	var packets []*av.Packet
+
+	if !d.sentAVC {
+		// players need the decoder config before the first keyframe
+		if !key || len(d.sps) < 4 || len(d.pps) == 0 {
+			return nil, nil
+		}
+
+		p, err := flvtag.Packet(true, ts, flvtag.AVCConfig(d.sps, d.pps))
+		if err != nil {
+			return nil, err
+		}
+
+		packets = append(packets, p)
+		d.sentAVC = true
+	}
+
+	if len(nalus) == 0 {
+		return packets, nil
+	}
+
+	cts := (pts - dts) * 1000 / clockRate
+
+	p, err := flvtag.Packet(true, ts, flvtag.AVCFrame(nalus, key, int32(cts)))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(packets, p), nil
+}
+
+// aac splits a pes payload into its adts frames
+func (d *tsDemuxer) aac(data []byte, pts int64) ([]*av.Packet, error) {
+	var packets []*av.Packet
+
+	for i := 0; len(data) >= adtsHeaderLen; i++ {
+		if data[0] != 0xff || data[1]&0xf0 != 0xf0 {
+			return packets, errors.New("invalid adts header")
+		}
+
+		var (
+			protectionAbsent = data[1] & 0x01
+			profile          = data[2] >> 6
+			rateIndex        = (data[2] >> 2) & 0x0f
+			channels         = (data[2]&0x01)<<2 | data[3]>>6
+			frameLen         = int(data[3]&0x03)<<11 | int(data[4])<<3 | int(data[5])>>5
+		)
+
+		headerLen := adtsHeaderLen
+		if protectionAbsent == 0 {
+			headerLen += 2
+		}
+
+		if int(rateIndex) >= len(aacSampleRates) || frameLen < headerLen || frameLen > len(data) {
+			return packets, errors.New("invalid adts frame")
+		}
+
+		asc := []byte{(profile+1)<<3 | rateIndex>>1, rateIndex<<7 | channels<<3}
+		if !bytes.Equal(d.asc, asc) {
+			d.asc = asc
+			d.sentAAC = false
+		}
+
+		ts := d.clock.ms(pts + int64(i)*1024*clockRate/aacSampleRates[rateIndex])
+
+		if !d.sentAAC {
+			p, err := flvtag.Packet(false, ts, flvtag.AACConfig(d.asc))
+			if err != nil {
+				return nil, err
+			}
+
+			packets = append(packets, p)
+			d.sentAAC = true
+		}
+
+		p, err := flvtag.Packet(false, ts, flvtag.AACFrame(data[headerLen:frameLen]))
+		if err != nil {
+			return nil, err
+		}
+
+		packets = append(packets, p)
+		data = data[frameLen:]
+	}
+
+	return packets, nil
+}
@@ -0,0 +1,242 @@
+package hlspull
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/asticode/go-astits"
+	"github.com/zijiren233/livelib/av"
+)
+
+const (
+	videoPID = 256
+	audioPID = 257
+)
+
+var (
+	testSPS = []byte{0x67, 0x64, 0x00, 0x1f, 0xac, 0xd9, 0x40, 0x50}
+	testPPS = []byte{0x68, 0xeb, 0xe3, 0xcb}
+)
+
+func annexB(nalus ...[]byte) []byte {
+	var b []byte
+	for _, nalu := range nalus {
+		b = append(b, 0, 0, 0, 1)
+		b = append(b, nalu...)
+	}
+
+	return b
+}
+
+// adts builds an aac-lc frame at 44.1kHz in stereo
+func adts(payload []byte) []byte {
+	frameLen := adtsHeaderLen + len(payload)
+
+	return append([]byte{
+		0xff,
+		0xf1,
+		1<<6 | 4<<2,
+		2<<6 | byte(frameLen>>11),
+		byte(frameLen >> 3),
+		byte(frameLen<<5) | 0x1f,
+		0xfc,
+	}, payload...)
+}
+
+type muxer struct {
+	t *testing.T
+	m *astits.Muxer
+}
+
+func newMuxer(t *testing.T, w *bytes.Buffer) *muxer {
+	t.Helper()
+
+	m := astits.NewMuxer(context.Background(), w)
+
+	for _, es := range []astits.PMTElementaryStream{
+		{ElementaryPID: videoPID, StreamType: astits.StreamTypeH264Video},
+		{ElementaryPID: audioPID, StreamType: astits.StreamTypeAACAudio},
+	} {
+		if err := m.AddElementaryStream(es); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.SetPCRPID(videoPID)
+
+	return &muxer{t: t, m: m}
+}
+
+func (m *muxer) write(pid uint16, streamID uint8, pts, dts int64, data []byte) {
+	m.t.Helper()
+
+	h := &astits.PESOptionalHeader{
+		MarkerBits:      2,
+		PTSDTSIndicator: astits.PTSDTSIndicatorOnlyPTS,
+		PTS:             &astits.ClockReference{Base: pts},
+	}
+	if dts != pts {
+		h.PTSDTSIndicator = astits.PTSDTSIndicatorBothPresent
+		h.DTS = &astits.ClockReference{Base: dts}
+	}
+
+	_, err := m.m.WriteData(&astits.MuxerData{
+		PID: pid,
+		AdaptationField: &astits.PacketAdaptationField{
+			RandomAccessIndicator: true,
+		},
+		PES: &astits.PESData{
+			Header: &astits.PESHeader{StreamID: streamID, OptionalHeader: h},
+			Data:   data,
+		},
+	})
+	if err != nil {
+		m.t.Fatal(err)
+	}
+}
+
+func demux(t *testing.T, d *tsDemuxer, b []byte) []*av.Packet {
+	t.Helper()
+
+	var packets []*av.Packet
+
+	err := d.demux(context.Background(), b, func(p *av.Packet) error {
+		packets = append(packets, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return packets
+}
+
+func TestDemux(t *testing.T) {
+	var buf bytes.Buffer
+
+	m := newMuxer(t, &buf)
+	// frames before the first keyframe cannot be decoded and are dropped
+	m.write(videoPID, 0xe0, 90000, 90000, annexB([]byte{0x09, 0xf0}, []byte{0x41, 1}))
+	m.write(
+		videoPID,
+		0xe0,
+		93000,
+		93000,
+		annexB([]byte{0x09, 0xf0}, testSPS, testPPS, []byte{0x65, 2}),
+	)
+	// a b-frame shown 2 frames later
+	m.write(videoPID, 0xe0, 96000+6000, 96000, annexB([]byte{0x41, 3}))
+	m.write(audioPID, 0xc0, 93000, 93000, append(adts([]byte{4}), adts([]byte{5})...))
+	// flushes the pes packets before it
+	m.write(videoPID, 0xe0, 99000, 99000, annexB([]byte{0x41, 6}))
+
+	packets := demux(t, newTSDemuxer(), buf.Bytes())
+
+	var video, audio []*av.Packet
+
+	for _, p := range packets {
+		if p.IsVideo {
+			video = append(video, p)
+		} else {
+			audio = append(audio, p)
+		}
+	}
+
+	if len(video) < 3 {
+		t.Fatalf("%d video packets", len(video))
+	}
+
+	config := video[0].Header.(av.VideoPacketHeader)
+	if !config.IsSeq() || !config.IsKeyFrame() || video[0].TimeStamp != 33 {
+		t.Fatalf("first video packet is not the decoder config at 33ms: %+v", video[0])
+	}
+
+	if !bytes.Contains(video[0].Data, testSPS) || !bytes.Contains(video[0].Data, testPPS) {
+		t.Fatal("decoder config does not hold the sps and pps")
+	}
+
+	key := video[1].Header.(av.VideoPacketHeader)
+	if key.IsSeq() || !key.IsKeyFrame() || video[1].TimeStamp != 33 {
+		t.Fatalf("second video packet is not the keyframe: %+v", video[1])
+	}
+
+	if bytes.Contains(video[1].Data, testSPS) {
+		t.Fatal("keyframe still holds the sps")
+	}
+
+	inter := video[2].Header.(av.VideoPacketHeader)
+	if inter.IsKeyFrame() || inter.CompositionTime() != 66 || video[2].TimeStamp != 66 {
+		t.Fatalf("b-frame has cts %d at %dms", inter.CompositionTime(), video[2].TimeStamp)
+	}
+
+	if len(audio) != 3 {
+		t.Fatalf("%d audio packets, want the config and 2 frames", len(audio))
+	}
+
+	if h := audio[0].Header.(av.AudioPacketHeader); h.AACPacketType() != av.AAC_SEQHDR {
+		t.Fatal("first audio packet is not the aac config")
+	}
+
+	// aac-lc, 44.1kHz, 2 channels
+	if !bytes.Equal(audio[0].Data[2:], []byte{0x12, 0x10}) {
+		t.Fatalf("audio specific config %x", audio[0].Data[2:])
+	}
+
+	// the second frame follows 1024 samples later
+	if audio[1].TimeStamp != 33 || audio[2].TimeStamp != 33+23 {
+		t.Fatalf("aac frames at %dms and %dms", audio[1].TimeStamp, audio[2].TimeStamp)
+	}
+
+	if !bytes.Equal(audio[1].Data[2:], []byte{4}) || !bytes.Equal(audio[2].Data[2:], []byte{5}) {
+		t.Fatal("aac frames are not stripped of their adts header")
+	}
+}
+
+func TestDemuxerKeepsStateAcrossSegments(t *testing.T) {
+	d := newTSDemuxer()
+
+	segment := func(dts int64, nalus ...[]byte) []byte {
+		var buf bytes.Buffer
+
+		m := newMuxer(t, &buf)
+		m.write(videoPID, 0xe0, dts, dts, annexB(nalus...))
+		// an empty access unit that flushes the one before it
+		m.write(videoPID, 0xe0, dts+3000, dts+3000, annexB([]byte{0x09, 0xf0}))
+
+		return buf.Bytes()
+	}
+
+	first := demux(t, d, segment(1<<33-6000, testSPS, testPPS, []byte{0x65, 1}))
+	if len(first) != 2 {
+		t.Fatalf("%d packets in the first segment", len(first))
+	}
+
+	// the 33 bit timestamps wrap around between the segments
+	second := demux(t, d, segment(1000, []byte{0x41, 2}))
+	if len(second) != 1 {
+		t.Fatalf("%d packets in the second segment, the config is sent again", len(second))
+	}
+
+	// 7000 ticks after the keyframe
+	if second[0].TimeStamp != first[1].TimeStamp+77 {
+		t.Fatalf("timeline went from %dms to %dms", first[1].TimeStamp, second[0].TimeStamp)
+	}
+}
+
+func TestClockDiscontinuity(t *testing.T) {
+	var c clock
+
+	if ts := c.ms(1000); ts != 0 {
+		t.Fatalf("first timestamp is %dms", ts)
+	}
+
+	if ts := c.ms(1000 + clockRate); ts != 1000 {
+		t.Fatalf("timestamp %dms after a second", ts)
+	}
+
+	// a jump of an hour continues the timeline
+	if ts := c.ms(1000 + clockRate + 3600*clockRate); ts != 1000 {
+		t.Fatalf("timestamp %dms after a discontinuity", ts)
+	}
+}
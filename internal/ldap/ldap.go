@@ -0,0 +1,300 @@
+// Package ldap authenticates users with a bind against an ldap or active
+// directory server
+package ldap
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+// Provider is the provider of the user bindings of ldap users
+const Provider = "ldap"
+
+var (
+	ErrUserNotFound       = errors.New("ldap: user not found")
+	ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+	ErrNotInGroup         = errors.New("ldap: user is not in the required group")
+)
+
+// Conn is the part of a ldap connection used to authenticate, *goldap.Conn
+// implements it
+type Conn interface {
+	Bind(username, password string) error
+	Search(req *goldap.SearchRequest) (*goldap.SearchResult, error)
+	StartTLS(config *tls.Config) error
+	Close() error
+}
+
+type Dialer func(addr string, tlsConfig *tls.Config) (Conn, error)
+
+const timeout = 10 * time.Second
+
+func DialURL(addr string, tlsConfig *tls.Config) (Conn, error) {
+	c, err := goldap.DialURL(
+		addr,
+		goldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		goldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetTimeout(timeout)
+
+	return c, nil
+}
+
+// Entry is an authenticated user, Role is zero when the admin group is not
+// set and the role is managed in synctv
+type Entry struct {
+	DN       string
+	ID       string
+	Username string
+	Role     model.Role
+}
+
+type Authenticator struct {
+	dial Dialer
+	conf conf.LdapConfig
+}
+
+func New(c conf.LdapConfig, dial Dialer) (*Authenticator, error) {
+	switch {
+	case c.URL == "":
+		return nil, errors.New("ldap: url is empty")
+	case c.BaseDN == "":
+		return nil, errors.New("ldap: base dn is empty")
+	case !strings.Contains(c.UserFilter, "%s"):
+		return nil, errors.New("ldap: user filter has no %s")
+	case c.IDAttribute == "" || c.UsernameAttribute == "":
+		return nil, errors.New("ldap: id and username attributes cannot be empty")
+	case (c.RequiredGroupDN != "" || c.AdminGroupDN != "") && c.GroupBaseDN == "":
+		return nil, errors.New("ldap: group base dn is empty")
+	case c.GroupBaseDN != "" && !strings.Contains(c.GroupFilter, "%s"):
+		return nil, errors.New("ldap: group filter has no %s")
+	}
+
+	if dial == nil {
+		dial = DialURL
+	}
+
+	return &Authenticator{
+		conf: c,
+		dial: dial,
+	}, nil
+}
+
+func (a *Authenticator) AutoProvision() bool {
+	return a.conf.AutoProvision
+}
+
+var authenticator *Authenticator
+
+func Init(a *Authenticator) {
+	authenticator = a
+}
+
+// Default returns nil when ldap is disabled
+func Default() *Authenticator {
+	return authenticator
+}
+
+func (a *Authenticator) connect() (Conn, error) {
+	tlsConfig := &tls.Config{
+		//nolint:gosec
+		InsecureSkipVerify: a.conf.InsecureSkipVerify,
+	}
+	if u, err := url.Parse(a.conf.URL); err == nil {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	c, err := a.dial(a.conf.URL, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: dial failed: %w", err)
+	}
+
+	if a.conf.StartTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("ldap: start tls failed: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+func (a *Authenticator) bindService(c Conn) error {
+	if a.conf.BindDN == "" {
+		return nil
+	}
+
+	if err := c.Bind(a.conf.BindDN, a.conf.BindPassword); err != nil {
+		return fmt.Errorf("ldap: service bind failed: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate searches the user with the service account and binds as it
+// with password
+func (a *Authenticator) Authenticate(username, password string) (*Entry, error) {
+	// an empty password is an unauthenticated bind, which most servers accept
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	c, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	if err := a.bindService(c); err != nil {
+		return nil, err
+	}
+
+	res, err := c.Search(goldap.NewSearchRequest(
+		a.conf.BaseDN,
+		goldap.ScopeWholeSubtree,
+		goldap.NeverDerefAliases,
+		2,
+		0,
+		false,
+		strings.ReplaceAll(a.conf.UserFilter, "%s", goldap.EscapeFilter(username)),
+		[]string{a.conf.IDAttribute, a.conf.UsernameAttribute},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ldap: search user failed: %w", err)
+	}
+
+	switch len(res.Entries) {
+	case 0:
+		return nil, ErrUserNotFound
+	case 1:
+	default:
+		return nil, fmt.Errorf("ldap: username %s matches more than one user", username)
+	}
+
+	e := res.Entries[0]
+
+	if err := c.Bind(e.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+
+		return nil, fmt.Errorf("ldap: user bind failed: %w", err)
+	}
+
+	entry := &Entry{
+		DN:       e.DN,
+		ID:       attributeID(e.GetRawAttributeValue(a.conf.IDAttribute)),
+		Username: e.GetAttributeValue(a.conf.UsernameAttribute),
+	}
+	if entry.ID == "" {
+		return nil, fmt.Errorf("ldap: attribute %s of %s is empty", a.conf.IDAttribute, e.DN)
+	}
+
+	if entry.Username == "" {
+		entry.Username = username
+	}
+
+	if a.conf.RequiredGroupDN == "" && a.conf.AdminGroupDN == "" {
+		return entry, nil
+	}
+
+	// the user may not be allowed to read the groups
+	if err := a.bindService(c); err != nil {
+		return nil, err
+	}
+
+	groups, err := a.groups(c, e.DN)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.conf.RequiredGroupDN != "" && !containsDN(groups, a.conf.RequiredGroupDN) {
+		return nil, ErrNotInGroup
+	}
+
+	if a.conf.AdminGroupDN != "" {
+		entry.Role = model.RoleUser
+		if containsDN(groups, a.conf.AdminGroupDN) {
+			entry.Role = model.RoleAdmin
+		}
+	}
+
+	return entry, nil
+}
+
+func (a *Authenticator) groups(c Conn, userDN string) ([]string, error) {
+	res, err := c.Search(goldap.NewSearchRequest(
+		a.conf.GroupBaseDN,
+		goldap.ScopeWholeSubtree,
+		goldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		strings.ReplaceAll(a.conf.GroupFilter, "%s", goldap.EscapeFilter(userDN)),
+		[]string{"dn"},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ldap: search groups failed: %w", err)
+	}
+
+	groups := make([]string, len(res.Entries))
+	for i, e := range res.Entries {
+		groups[i] = e.DN
+	}
+
+	return groups, nil
+}
+
+// attributeID hex encodes binary ids such as the objectGUID of active
+// directory
+func attributeID(v []byte) string {
+	if !utf8.Valid(v) {
+		return hex.EncodeToString(v)
+	}
+
+	for _, r := range string(v) {
+		if !unicode.IsPrint(r) {
+			return hex.EncodeToString(v)
+		}
+	}
+
+	return string(v)
+}
+
+func containsDN(dns []string, dn string) bool {
+	want, err := goldap.ParseDN(dn)
+
+	for _, v := range dns {
+		if err != nil {
+			if strings.EqualFold(v, dn) {
+				return true
+			}
+
+			continue
+		}
+
+		if got, err := goldap.ParseDN(v); err == nil && got.EqualFold(want) {
+			return true
+		}
+	}
+
+	return false
+}
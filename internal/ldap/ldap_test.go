@@ -0,0 +1,232 @@
+package ldap_test
+
+import (
+	"crypto/tls"
+	"errors"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/ldap"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+const (
+	serviceDN = "cn=synctv,ou=services,dc=example,dc=org"
+	usersDN   = "ou=users,dc=example,dc=org"
+	groupsDN  = "ou=groups,dc=example,dc=org"
+	adminsDN  = "cn=admins,ou=groups,dc=example,dc=org"
+	membersDN = "cn=members,ou=groups,dc=example,dc=org"
+)
+
+type entry struct {
+	password string
+	attrs    map[string][]string
+}
+
+// directory is an in-process stand-in for a ldap server
+type directory struct {
+	entries  map[string]*entry
+	binds    []string
+	startTLS bool
+}
+
+func newDirectory() *directory {
+	d := &directory{entries: make(map[string]*entry)}
+	d.entries[serviceDN] = &entry{password: "service"}
+	d.addUser("alice", "alice-pw")
+	d.addUser("bob", "bob-pw")
+	d.addUser("carol", "carol-pw")
+	d.entries[adminsDN] = &entry{attrs: map[string][]string{
+		"member": {"uid=alice," + usersDN},
+	}}
+	d.entries[membersDN] = &entry{attrs: map[string][]string{
+		"member": {"uid=alice," + usersDN, "uid=bob," + usersDN},
+	}}
+
+	return d
+}
+
+func (d *directory) addUser(uid, password string) {
+	d.entries["uid="+uid+","+usersDN] = &entry{
+		password: password,
+		attrs: map[string][]string{
+			"uid":       {uid},
+			"entryUUID": {"uuid-" + uid},
+		},
+	}
+}
+
+func (d *directory) dial(_ string, _ *tls.Config) (ldap.Conn, error) {
+	return &conn{d: d}, nil
+}
+
+type conn struct {
+	d *directory
+}
+
+func (c *conn) Bind(username, password string) error {
+	e, ok := c.d.entries[username]
+	if !ok || e.password == "" || e.password != password {
+		return goldap.NewError(
+			goldap.LDAPResultInvalidCredentials,
+			errors.New("invalid credentials"),
+		)
+	}
+
+	c.d.binds = append(c.d.binds, username)
+
+	return nil
+}
+
+func (c *conn) Search(req *goldap.SearchRequest) (*goldap.SearchResult, error) {
+	f, err := goldap.CompileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &goldap.SearchResult{}
+
+	for dn, e := range c.d.entries {
+		if !strings.HasSuffix(dn, ","+req.BaseDN) || !match(f, e) {
+			continue
+		}
+
+		ge := goldap.NewEntry(dn, nil)
+		for _, a := range req.Attributes {
+			if v, ok := e.attrs[a]; ok {
+				ge.Attributes = append(ge.Attributes, goldap.NewEntryAttribute(a, v))
+			}
+		}
+
+		res.Entries = append(res.Entries, ge)
+	}
+
+	return res, nil
+}
+
+func match(f *ber.Packet, e *entry) bool {
+	switch f.Tag {
+	case goldap.FilterAnd:
+		for _, c := range f.Children {
+			if !match(c, e) {
+				return false
+			}
+		}
+
+		return true
+	case goldap.FilterOr:
+		for _, c := range f.Children {
+			if match(c, e) {
+				return true
+			}
+		}
+
+		return false
+	case goldap.FilterEqualityMatch:
+		attr := f.Children[0].Data.String()
+		value := f.Children[1].Data.String()
+
+		for _, v := range e.attrs[attr] {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return false
+	}
+}
+
+func (c *conn) StartTLS(_ *tls.Config) error {
+	c.d.startTLS = true
+	return nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func newConfig() conf.LdapConfig {
+	c := conf.DefaultLdapConfig()
+	c.URL = "ldap://ldap.example.org"
+	c.StartTLS = true
+	c.BindDN = serviceDN
+	c.BindPassword = "service"
+	c.BaseDN = usersDN
+	c.IDAttribute = "entryUUID"
+	c.GroupBaseDN = groupsDN
+	c.RequiredGroupDN = membersDN
+	c.AdminGroupDN = adminsDN
+
+	return c
+}
+
+func TestAuthenticate(t *testing.T) {
+	d := newDirectory()
+
+	a, err := ldap.New(newConfig(), d.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := a.Authenticate("alice", "alice-pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.ID != "uuid-alice" || e.Username != "alice" || e.Role != model.RoleAdmin {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+
+	if !d.startTLS {
+		t.Fatal("start tls was not used")
+	}
+
+	if d.binds[0] != serviceDN || d.binds[1] != "uid=alice,"+usersDN {
+		t.Fatalf("unexpected binds: %v", d.binds)
+	}
+
+	e, err = a.Authenticate("bob", "bob-pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Role != model.RoleUser {
+		t.Fatalf("bob is not in the admin group, got role %v", e.Role)
+	}
+}
+
+func TestAuthenticateErrors(t *testing.T) {
+	a, err := ldap.New(newConfig(), newDirectory().dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		username, password string
+		want               error
+	}{
+		{"alice", "wrong", ldap.ErrInvalidCredentials},
+		{"alice", "", ldap.ErrInvalidCredentials},
+		{"dave", "dave-pw", ldap.ErrUserNotFound},
+		{"*)(uid=*", "alice-pw", ldap.ErrUserNotFound},
+		{"carol", "carol-pw", ldap.ErrNotInGroup},
+	} {
+		if _, err := a.Authenticate(tc.username, tc.password); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.username, err, tc.want)
+		}
+	}
+}
+
+func TestNewValidatesGroups(t *testing.T) {
+	c := newConfig()
+	c.GroupBaseDN = ""
+
+	if _, err := ldap.New(c, nil); err == nil {
+		t.Fatal("group dns without a group base dn must be rejected")
+	}
+}
@@ -0,0 +1,94 @@
+// Package metrics holds the prometheus registry served on /metrics
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "synctv"
+
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	proxyCacheRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "proxy_cache",
+		Name:      "requests_total",
+		Help:      "Proxy cache slice lookups by result.",
+	}, []string{"result"})
+
+	proxyCacheBytes = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "proxy_cache",
+		Name:      "bytes_total",
+		Help:      "Bytes of proxy cache slices served from the cache or fetched from the source.",
+	}, []string{"result"})
+
+	vendorRequestDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "vendor",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of vendor backend calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "operation"})
+
+	vendorRequestErrors = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "vendor",
+		Name:      "request_errors_total",
+		Help:      "Failed vendor backend calls.",
+	}, []string{"backend", "operation"})
+)
+
+// ProxyCache records a proxy cache slice lookup of size bytes
+func ProxyCache(hit bool, size int) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	proxyCacheRequests.WithLabelValues(result).Inc()
+	proxyCacheBytes.WithLabelValues(result).Add(float64(size))
+}
+
+// VendorRequest records a finished vendor backend call
+func VendorRequest(backend, operation string, took time.Duration, err error) {
+	vendorRequestDuration.WithLabelValues(backend, operation).Observe(took.Seconds())
+
+	if err != nil {
+		vendorRequestErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
+
+// Handler serves the registry, a bearer token is required when one is given
+func Handler(token string) http.Handler {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	if token == "" {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+)
+
+type AuditAction string
+
+// admin and root actions
+const (
+	AuditUserAdd          AuditAction = "user.add"
+	AuditUserDelete       AuditAction = "user.delete"
+	AuditUserBan          AuditAction = "user.ban"
+	AuditUserUnban        AuditAction = "user.unban"
+	AuditUserApprove      AuditAction = "user.approve"
+	AuditUserPassword     AuditAction = "user.password"
+	AuditUserUsername     AuditAction = "user.username"
+	AuditUserAddAdmin     AuditAction = "user.admin.add"
+	AuditUserDeleteAdmin  AuditAction = "user.admin.delete"
+	AuditRoomBan          AuditAction = "room.ban"
+	AuditRoomUnban        AuditAction = "room.unban"
+	AuditRoomApprove      AuditAction = "room.approve"
+	AuditRoomImport       AuditAction = "room.import"
+	AuditSettingsUpdate   AuditAction = "settings.update"
+	AuditVendorAdd        AuditAction = "vendor.add"
+	AuditVendorUpdate     AuditAction = "vendor.update"
+	AuditVendorDelete     AuditAction = "vendor.delete"
+	AuditVendorEnable     AuditAction = "vendor.enable"
+	AuditVendorDisable    AuditAction = "vendor.disable"
+	AuditVendorReconnect  AuditAction = "vendor.reconnect"
+	AuditRoomDelete       AuditAction = "room.delete"
+	AuditRoomPassword     AuditAction = "room.password"
+	AuditRoomSettings     AuditAction = "room.settings"
+	AuditRoomLeader       AuditAction = "room.leader"
+	AuditMemberBan        AuditAction = "member.ban"
+	AuditMemberUnban      AuditAction = "member.unban"
+	AuditMemberMute       AuditAction = "member.mute"
+	AuditMemberUnmute     AuditAction = "member.unmute"
+	AuditMemberDelete     AuditAction = "member.delete"
+	AuditMemberApprove    AuditAction = "member.approve"
+	AuditMemberPermission AuditAction = "member.permissions"
+	AuditMemberAdmin      AuditAction = "member.admin"
+	AuditChatDelete       AuditAction = "chat.delete"
+	AuditChatPin          AuditAction = "chat.pin"
+	AuditChatUnpin        AuditAction = "chat.unpin"
+)
+
+// AuditLog is an append only record of a privileged change, Before and
+// After hold the changed values as json
+type AuditLog struct {
+	ID        uint64      `gorm:"primaryKey;autoIncrement"        json:"id"`
+	CreatedAt time.Time   `gorm:"not null;index"                  json:"createdAt"`
+	ActorID   string      `gorm:"not null;index;type:char(32)"    json:"actorId"`
+	ActorName string      `gorm:"not null;type:varchar(32)"       json:"actorName"`
+	Action    AuditAction `gorm:"not null;index;type:varchar(32)" json:"action"`
+	RoomID    string      `gorm:"index;type:char(32)"             json:"roomId,omitempty"`
+	TargetID  string      `gorm:"index;type:varchar(256)"         json:"targetId,omitempty"`
+	Before    string      `gorm:"type:text"                       json:"before,omitempty"`
+	After     string      `gorm:"type:text"                       json:"after,omitempty"`
+}
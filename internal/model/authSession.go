@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type AuthSessionKind string
+
+const (
+	// a totp secret waiting for its first code, keyed by the user id
+	AuthSessionTotpSetup AuthSessionKind = "totpSetup"
+	// a login waiting for its second factor
+	AuthSessionTwoFactor AuthSessionKind = "twoFactor"
+	// the passkey answer of a two factor challenge, keyed by the challenge
+	AuthSessionTwoFactorWebAuthn AuthSessionKind = "twoFactorWebAuthn"
+	AuthSessionWebAuthn          AuthSessionKind = "webauthn"
+)
+
+// AuthSession is a short lived step of a login or of adding a second factor,
+// it is kept in the database so that any node can finish it
+type AuthSession struct {
+	ID        string          `gorm:"primaryKey;type:varchar(64)"`
+	Kind      AuthSessionKind `gorm:"primaryKey;type:varchar(32)"`
+	UserID    string          `gorm:"type:char(32)"`
+	Data      []byte
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
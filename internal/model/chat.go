@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+type ChatMessage struct {
+	ID        string    `gorm:"primaryKey;type:char(32)"                                         json:"id"`
+	CreatedAt time.Time `gorm:"index:idx_chat_room_created_at,priority:2"                        json:"createdAt"`
+	RoomID    string    `gorm:"not null;type:char(32);index:idx_chat_room_created_at,priority:1" json:"roomId"`
+	UserID    string    `gorm:"not null;type:char(32)"                                           json:"userId"`
+	Username  string    `gorm:"not null;type:varchar(32)"                                        json:"username"`
+	Content   string    `gorm:"not null;type:text"                                               json:"content"`
+	Pinned    bool      `gorm:"not null;default:false"                                           json:"pinned"`
+}
+
+func (c *ChatMessage) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = utils.SortUUID()
+	}
+	return nil
+}
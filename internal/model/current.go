@@ -5,12 +5,22 @@ import "time"
 type Current struct {
 	Movie  CurrentMovie `json:"movie"`
 	Status Status       `json:"status"`
+	// the user leading playback when the room uses LeaderModeSetter
+	Leader string `json:"leader,omitempty"`
 }
 
 type CurrentMovie struct {
-	ID      string `json:"id,omitempty"`
-	IsLive  bool   `json:"isLive,omitempty"`
-	SubPath string `json:"subPath,omitempty"`
+	ID     string `json:"id,omitempty"`
+	IsLive bool   `json:"isLive,omitempty"`
+	// the live movie keeps a time-shift window, Status.CurrentTime is then
+	// the number of seconds the room is behind live
+	TimeShift bool `json:"timeShift,omitempty"`
+	// the live movie supports a time-shift window, TimeShift follows the
+	// setting while it is playing
+	CanTimeShift bool   `json:"canTimeShift,omitempty"`
+	SubPath      string `json:"subPath,omitempty"`
+	// seconds, reported by clients and used to advance the playlist
+	Duration float64 `json:"duration,omitempty"`
 }
 
 type Status struct {
@@ -28,12 +38,29 @@ func NewStatus() Status {
 	}
 }
 
+// isLiveEdge reports whether the status is pinned to live
+func (c *Current) isLiveEdge() bool {
+	return c.Movie.IsLive && !c.Movie.TimeShift
+}
+
 func (c *Current) UpdateStatus() Status {
-	if c.Movie.IsLive {
+	if c.isLiveEdge() {
 		c.Status.LastUpdate = time.Now()
 		return c.Status
 	}
 
+	if c.Movie.TimeShift {
+		c.Status.CurrentTime = behindLive(
+			c.Status.CurrentTime,
+			time.Since(c.Status.LastUpdate).Seconds(),
+			c.Status.PlaybackRate,
+			c.Status.IsPlaying,
+		)
+		c.Status.LastUpdate = time.Now()
+
+		return c.Status
+	}
+
 	if c.Status.IsPlaying {
 		c.Status.CurrentTime += time.Since(c.Status.LastUpdate).Seconds() * c.Status.PlaybackRate
 	}
@@ -43,6 +70,18 @@ func (c *Current) UpdateStatus() Status {
 	return c.Status
 }
 
+// behindLive returns the delay after elapsed seconds, paused playback falls
+// behind live in real time and faster playback catches up until it reaches live.
+func behindLive(delay, elapsed, rate float64, playing bool) float64 {
+	if playing {
+		delay += elapsed * (1 - rate)
+	} else {
+		delay += elapsed
+	}
+
+	return max(delay, 0)
+}
+
 func (c *Current) setLiveStatus() Status {
 	c.Status.IsPlaying = true
 	c.Status.PlaybackRate = 1.0
@@ -53,10 +92,19 @@ func (c *Current) setLiveStatus() Status {
 }
 
 func (c *Current) SetStatus(playing bool, seek, rate, timeDiff float64) Status {
-	if c.Movie.IsLive {
+	if c.isLiveEdge() {
 		return c.setLiveStatus()
 	}
 
+	if c.Movie.TimeShift {
+		c.Status.IsPlaying = playing
+		c.Status.PlaybackRate = rate
+		c.Status.CurrentTime = behindLive(seek, timeDiff, rate, playing)
+		c.Status.LastUpdate = time.Now()
+
+		return c.Status
+	}
+
 	c.Status.IsPlaying = playing
 
 	c.Status.PlaybackRate = rate
@@ -72,10 +120,18 @@ func (c *Current) SetStatus(playing bool, seek, rate, timeDiff float64) Status {
 }
 
 func (c *Current) SetSeekRate(seek, rate, timeDiff float64) Status {
-	if c.Movie.IsLive {
+	if c.isLiveEdge() {
 		return c.setLiveStatus()
 	}
 
+	if c.Movie.TimeShift {
+		c.Status.PlaybackRate = rate
+		c.Status.CurrentTime = behindLive(seek, timeDiff, rate, c.Status.IsPlaying)
+		c.Status.LastUpdate = time.Now()
+
+		return c.Status
+	}
+
 	if c.Status.IsPlaying {
 		c.Status.CurrentTime = seek + (timeDiff * rate)
 	} else {
@@ -89,10 +145,22 @@ func (c *Current) SetSeekRate(seek, rate, timeDiff float64) Status {
 }
 
 func (c *Current) SetSeek(seek, timeDiff float64) Status {
-	if c.Movie.IsLive {
+	if c.isLiveEdge() {
 		return c.setLiveStatus()
 	}
 
+	if c.Movie.TimeShift {
+		c.Status.CurrentTime = behindLive(
+			seek,
+			timeDiff,
+			c.Status.PlaybackRate,
+			c.Status.IsPlaying,
+		)
+		c.Status.LastUpdate = time.Now()
+
+		return c.Status
+	}
+
 	if c.Status.IsPlaying {
 		c.Status.CurrentTime = seek + (timeDiff * c.Status.PlaybackRate)
 	} else {
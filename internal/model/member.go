@@ -118,6 +118,9 @@ const (
 	PermissionSetRoomSettings
 	PermissionSetRoomPassword
 	PermissionDeleteRoom
+	PermissionDeleteChatMessage
+	PermissionPinChatMessage
+	PermissionMuteRoomMember
 
 	AllAdminPermissions     RoomAdminPermission = math.MaxUint32
 	NoAdminPermission       RoomAdminPermission = 0
@@ -125,7 +128,10 @@ const (
 		PermissionBanRoomMember |
 		PermissionSetUserPermission |
 		PermissionSetRoomSettings |
-		PermissionSetRoomPassword
+		PermissionSetRoomPassword |
+		PermissionDeleteChatMessage |
+		PermissionPinChatMessage |
+		PermissionMuteRoomMember
 )
 
 func (p RoomAdminPermission) Has(permission RoomAdminPermission) bool {
@@ -149,9 +155,29 @@ type RoomMember struct {
 	AdminPermissions RoomAdminPermission
 	Status           RoomMemberStatus `gorm:"not null;default:2"`
 	Role             RoomMemberRole   `gorm:"not null;default:1"`
+	MutedUntil       *time.Time
 }
 
-var ErrNoPermission = errors.New("no permission")
+// MaxMuteDuration bounds a timed mute so that the expiry cannot overflow
+const MaxMuteDuration = time.Hour * 24 * 365
+
+var (
+	ErrNoPermission = errors.New("no permission")
+	ErrMuted        = errors.New("muted")
+	ErrNotLeader    = errors.New("only the playback leader can control playback")
+)
+
+func (r *RoomMember) IsMuted() bool {
+	return r.MutedUntil != nil && r.MutedUntil.After(time.Now())
+}
+
+// MutedUntilUnixMilli returns 0 if the member is not muted
+func (r *RoomMember) MutedUntilUnixMilli() int64 {
+	if !r.IsMuted() {
+		return 0
+	}
+	return r.MutedUntil.UnixMilli()
+}
 
 func (r *RoomMember) HasPermission(permission RoomMemberPermission) bool {
 	if r.Role.IsAdmin() {
@@ -20,18 +20,21 @@ type Movie struct {
 	Childrens []*Movie  `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
 	MovieBase `gorm:"embedded;embeddedPrefix:base_" json:"base"`
 	Position  uint `gorm:"not null"                                                         json:"-"`
+	// publish keys signed with an older version are rejected
+	PublishKeyVersion uint32 `gorm:"not null;default:0"                                               json:"-"`
 }
 
 func (m *Movie) Clone() *Movie {
 	return &Movie{
-		ID:        m.ID,
-		CreatedAt: m.CreatedAt,
-		UpdatedAt: m.UpdatedAt,
-		Position:  m.Position,
-		RoomID:    m.RoomID,
-		CreatorID: m.CreatorID,
-		MovieBase: *m.MovieBase.Clone(),
-		Childrens: m.Childrens,
+		ID:                m.ID,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+		Position:          m.Position,
+		RoomID:            m.RoomID,
+		PublishKeyVersion: m.PublishKeyVersion,
+		CreatorID:         m.CreatorID,
+		MovieBase:         *m.MovieBase.Clone(),
+		Childrens:         m.Childrens,
 	}
 }
 
@@ -84,6 +87,10 @@ type MovieBase struct {
 	Proxy       bool                 `                                            json:"proxy"`
 	RtmpSource  bool                 `                                            json:"rtmpSource"`
 	IsFolder    bool                 `                                            json:"isFolder"`
+	// record the live stream in this format, empty disables recording
+	Record RecordFormat `gorm:"type:varchar(8)"                      json:"record,omitempty"`
+	// set on movies that play a finished recording
+	RecordingID string `gorm:"type:char(32)"                        json:"recordingId,omitempty"`
 }
 
 func (m *MovieBase) IsM3u8() bool {
@@ -126,6 +133,8 @@ func (m *MovieBase) Clone() *MovieBase {
 		VendorInfo:  m.VendorInfo,
 		IsFolder:    m.IsFolder,
 		ParentID:    m.ParentID,
+		Record:      m.Record,
+		RecordingID: m.RecordingID,
 	}
 }
 
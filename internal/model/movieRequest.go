@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// MovieRequest is a movie suggested by a member, it becomes a real movie once promoted
+type MovieRequest struct {
+	ID          string              `gorm:"primaryKey;type:char(32)"                                          json:"id"`
+	CreatedAt   time.Time           `                                                                         json:"createdAt"`
+	RoomID      string              `gorm:"not null;index;type:char(32)"                                      json:"-"`
+	CreatorID   string              `gorm:"not null;type:char(32)"                                            json:"creatorId"`
+	MovieVoters []*MovieRequestVote `gorm:"foreignKey:RequestID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+	MovieBase   `gorm:"embedded;embeddedPrefix:base_" json:"base"`
+	Votes       int64 `gorm:"not null;default:0"                                                json:"votes"`
+}
+
+func (m *MovieRequest) BeforeCreate(_ *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+type MovieRequestVote struct {
+	CreatedAt time.Time
+	RequestID string `gorm:"primaryKey;type:char(32)"`
+	UserID    string `gorm:"primaryKey;type:char(32)"`
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+type RecordFormat string
+
+const (
+	RecordFormatFLV RecordFormat = "flv"
+	// segmented hls, one ts file per segment and a vod playlist
+	RecordFormatHLS RecordFormat = "hls"
+)
+
+const (
+	RecordingFLVFile  = "recording.flv"
+	RecordingHLSIndex = "index.m3u8"
+)
+
+func (f RecordFormat) Valid() bool {
+	return f == RecordFormatFLV || f == RecordFormatHLS
+}
+
+// FileName is the file players open first
+func (f RecordFormat) FileName() string {
+	if f == RecordFormatHLS {
+		return RecordingHLSIndex
+	}
+	return RecordingFLVFile
+}
+
+// MovieType is the MovieBase.Type of the registered recording
+func (f RecordFormat) MovieType() string {
+	if f == RecordFormatHLS {
+		return "m3u8"
+	}
+	return "flv"
+}
+
+type RecordingStatus string
+
+const (
+	RecordingStatusRecording RecordingStatus = "recording"
+	RecordingStatusFinished  RecordingStatus = "finished"
+	// the node recording it stopped before it was finished
+	RecordingStatusFailed RecordingStatus = "failed"
+)
+
+// Recording is a live movie written to disk, it is added to the movie list as MovieID when finished
+type Recording struct {
+	ID            string `gorm:"primaryKey;type:char(32)"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	EndedAt       *time.Time
+	RoomID        string          `gorm:"not null;index;type:char(32)"`
+	SourceMovieID string          `gorm:"not null;type:char(32)"`
+	MovieID       string          `gorm:"type:char(32)"`
+	CreatorID     string          `gorm:"type:char(32)"`
+	Name          string          `gorm:"not null;type:text"`
+	Format        RecordFormat    `gorm:"not null;type:varchar(8)"`
+	Status        RecordingStatus `gorm:"not null;type:varchar(16)"`
+	// bytes
+	Size int64 `gorm:"not null;default:0"`
+	// seconds
+	Duration float64 `gorm:"not null;default:0"`
+}
+
+func (r *Recording) BeforeCreate(_ *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = utils.SortUUID()
+	}
+	return nil
+}
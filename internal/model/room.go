@@ -38,10 +38,14 @@ type Room struct {
 	Name           string        `gorm:"not null;uniqueIndex;type:varchar(32)"`
 	CreatorID      string        `gorm:"index;type:char(32)"`
 	HashedPassword []byte
-	RoomMembers    []*RoomMember `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Movies         []*Movie      `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Status         RoomStatus    `gorm:"not null;default:2"`
-	Current        *Current      `gorm:"serializer:fastjson"`
+	RoomMembers    []*RoomMember   `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Movies         []*Movie        `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ChatMessages   []*ChatMessage  `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	MovieRequests  []*MovieRequest `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Schedules      []*RoomSchedule `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Recordings     []*Recording    `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Status         RoomStatus      `gorm:"not null;default:2"`
+	Current        *Current        `gorm:"serializer:fastjson"`
 }
 
 func (r *Room) BeforeCreate(_ *gorm.DB) error {
@@ -72,6 +76,32 @@ func (r *Room) IsActive() bool {
 	return r.Status == RoomStatusActive
 }
 
+type LeaderMode string
+
+const (
+	// anyone with PermissionSetCurrentStatus controls playback
+	LeaderModeNone LeaderMode = "none"
+	// the room creator leads playback
+	LeaderModeCreator LeaderMode = "creator"
+	// the member in RoomSettings.LeaderID leads playback
+	LeaderModeMember LeaderMode = "member"
+	// whoever first set the current movie leads playback until the
+	// leadership is transferred
+	LeaderModeSetter LeaderMode = "setter"
+)
+
+type PlayMode string
+
+const (
+	// nothing happens when the current movie ends
+	PlayModeOff PlayMode = "off"
+	// play the next movie by position and stop after the last one
+	PlayModeSequential PlayMode = "sequential"
+	PlayModeRepeatOne  PlayMode = "repeat_one"
+	PlayModeRepeatAll  PlayMode = "repeat_all"
+	PlayModeShuffle    PlayMode = "shuffle"
+)
+
 //nolint:tagliatelle
 type RoomSettings struct {
 	UpdatedAt              time.Time            `gorm:"autoUpdateTime"           json:"-"`
@@ -89,6 +119,13 @@ type RoomSettings struct {
 	CanSetCurrentMovie     bool                 `gorm:"default:true"             json:"can_set_current_movie"`
 	CanSetCurrentStatus    bool                 `gorm:"default:true"             json:"can_set_current_status"`
 	CanSendChatMessage     bool                 `gorm:"default:true"             json:"can_send_chat_message"`
+	ChatHistoryMaxCount    int64                `gorm:"default:500"              json:"chat_history_max_count"`
+	ChatHistoryReplayCount int64                `gorm:"default:50"               json:"chat_history_replay_count"`
+	LeaderMode             LeaderMode           `gorm:"not null;default:none"    json:"leader_mode"`
+	LeaderID               string               `gorm:"type:char(32)"            json:"leader_id"`
+	PlayMode               PlayMode             `gorm:"not null;default:off"     json:"play_mode"`
+	// movie requests reaching this many votes are added to the movie list, 0 disables it
+	MovieRequestVoteThreshold int64 `gorm:"default:0"                json:"movie_request_vote_threshold"`
 }
 
 func DefaultRoomSettings() *RoomSettings {
@@ -107,5 +144,11 @@ func DefaultRoomSettings() *RoomSettings {
 		CanSetCurrentMovie:  true,
 		CanSetCurrentStatus: true,
 		CanSendChatMessage:  true,
+
+		ChatHistoryMaxCount:    500,
+		ChatHistoryReplayCount: 50,
+
+		LeaderMode: LeaderModeNone,
+		PlayMode:   PlayModeOff,
 	}
 }
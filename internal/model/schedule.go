@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// RoomSchedule starts playing a movie of the room at StartAt
+type RoomSchedule struct {
+	ID          string                    `gorm:"primaryKey;type:char(32)"`
+	CreatedAt   time.Time                 ``
+	UpdatedAt   time.Time                 ``
+	RoomID      string                    `gorm:"not null;index;type:char(32)"`
+	CreatorID   string                    `gorm:"not null;type:char(32)"`
+	MovieID     string                    `gorm:"not null;type:char(32)"`
+	SubPath     string                    `gorm:"type:text"`
+	Title       string                    `gorm:"not null;type:varchar(256)"`
+	StartAt     time.Time                 `gorm:"not null;index"`
+	RemindAt    *time.Time                `gorm:"index"`
+	Subscribers []*RoomScheduleSubscriber `gorm:"foreignKey:ScheduleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Started     bool                      `gorm:"not null;default:false"`
+	Reminded    bool                      `gorm:"not null;default:false"`
+}
+
+func (s *RoomSchedule) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+// RoomScheduleSubscriber is a member who opted in to the reminder email
+type RoomScheduleSubscriber struct {
+	CreatedAt  time.Time
+	ScheduleID string `gorm:"primaryKey;type:char(32)"`
+	UserID     string `gorm:"primaryKey;type:char(32)"`
+}
@@ -22,6 +22,7 @@ const (
 	SettingGroupServer   SettingGroup = "server"
 	SettingGroupOauth2   SettingGroup = "oauth2"
 	SettingGroupEmail    SettingGroup = "email"
+	SettingGroupRecord   SettingGroup = "record"
 )
 
 type Setting struct {
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+type APITokenScope string
+
+const (
+	APITokenScopeReadRooms       APITokenScope = "rooms:read"
+	APITokenScopePushMovies      APITokenScope = "movies:push"
+	APITokenScopeDeleteMovies    APITokenScope = "movies:delete"
+	APITokenScopeControlPlayback APITokenScope = "playback:control"
+	APITokenScopeAdmin           APITokenScope = "admin"
+)
+
+var APITokenScopes = []APITokenScope{
+	APITokenScopeReadRooms,
+	APITokenScopePushMovies,
+	APITokenScopeDeleteMovies,
+	APITokenScopeControlPlayback,
+	APITokenScopeAdmin,
+}
+
+func (s APITokenScope) Valid() bool {
+	return slices.Contains(APITokenScopes, s)
+}
+
+// APIToken is a personal access token of a user, only the sha256 of the
+// token is stored
+type APIToken struct {
+	ID          string          `gorm:"primaryKey;type:char(32)"           json:"id"`
+	CreatedAt   time.Time       `                                          json:"createdAt"`
+	LastUsedAt  time.Time       `                                          json:"lastUsedAt"`
+	ExpiresAt   *time.Time      `gorm:"index"                              json:"expiresAt,omitempty"`
+	UserID      string          `gorm:"not null;index;type:char(32)"       json:"-"`
+	Name        string          `gorm:"not null;type:varchar(64)"          json:"name"`
+	HashedToken string          `gorm:"not null;uniqueIndex;type:char(64)" json:"-"`
+	Scopes      []APITokenScope `gorm:"serializer:fastjson;type:text"      json:"scopes"`
+	// the token can only be used in this room when set
+	RoomID string `gorm:"index;type:char(32)"                json:"roomId,omitempty"`
+}
+
+func (t *APIToken) BeforeCreate(_ *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = utils.SortUUID()
+	}
+	return nil
+}
+
+func (t *APIToken) HasScope(scope APITokenScope) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
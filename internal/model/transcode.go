@@ -0,0 +1,48 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LiveRendition is one output of the live transcoding ladder
+type LiveRendition struct {
+	Height int
+	// kbps
+	Bitrate int
+}
+
+// ParseLiveRenditions parses a ladder like "720:2800,480:1400", each entry is height:kbps
+func ParseLiveRenditions(s string) ([]LiveRendition, error) {
+	var renditions []LiveRendition
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		height, bitrate, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rendition %q, want height:kbps", entry)
+		}
+
+		h, err := strconv.Atoi(height)
+		if err != nil || h <= 0 || h%2 != 0 {
+			return nil, fmt.Errorf(
+				"invalid rendition height %q, want a positive even number",
+				height,
+			)
+		}
+
+		b, err := strconv.Atoi(bitrate)
+		if err != nil || b <= 0 {
+			return nil, fmt.Errorf("invalid rendition bitrate %q", bitrate)
+		}
+
+		renditions = append(renditions, LiveRendition{Height: h, Bitrate: b})
+	}
+
+	return renditions, nil
+}
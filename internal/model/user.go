@@ -39,25 +39,41 @@ func (r Role) String() string {
 }
 
 type User struct {
-	ID                    string `gorm:"primaryKey;type:char(32)"                                           json:"id"`
-	CreatedAt             time.Time
-	UpdatedAt             time.Time
-	Username              string          `gorm:"not null;uniqueIndex;type:varchar(32)"`
-	Email                 EmptyNullString `gorm:"type:varchar(64);uniqueIndex:,where:email IS NOT NULL"`
-	HashedPassword        []byte          `gorm:"not null"`
-	BilibiliVendor        *BilibiliVendor `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Movies                []*Movie        `gorm:"foreignKey:CreatorID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
-	UserProviders         []*UserProvider `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	RoomMembers           []*RoomMember   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Rooms                 []*Room         `gorm:"foreignKey:CreatorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	AlistVendor           []*AlistVendor  `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	EmbyVendor            []*EmbyVendor   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Role                  Role            `gorm:"not null;default:2"`
-	RegisteredByProvider  bool            `gorm:"not null;default:false"`
-	RegisteredByEmail     bool            `gorm:"not null;default:false"`
+	ID                   string `gorm:"primaryKey;type:char(32)"                                           json:"id"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Username             string                `gorm:"not null;uniqueIndex;type:varchar(32)"`
+	Email                EmptyNullString       `gorm:"type:varchar(64);uniqueIndex:,where:email IS NOT NULL"`
+	HashedPassword       []byte                `gorm:"not null"`
+	BilibiliVendor       *BilibiliVendor       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Movies               []*Movie              `gorm:"foreignKey:CreatorID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	UserProviders        []*UserProvider       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	RoomMembers          []*RoomMember         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Rooms                []*Room               `gorm:"foreignKey:CreatorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	AlistVendor          []*AlistVendor        `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	EmbyVendor           []*EmbyVendor         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	WebAuthnCredentials  []*WebAuthnCredential `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	APITokens            []*APIToken           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Role                 Role                  `gorm:"not null;default:2"`
+	RegisteredByProvider bool                  `gorm:"not null;default:false"`
+	RegisteredByEmail    bool                  `gorm:"not null;default:false"`
+	// encrypted with the id of the user, empty when 2fa is disabled
+	TotpSecret []byte
+	// sha256 of the unused recovery codes, comma separated
+	TotpRecoveryCodes string `gorm:"type:text"`
+	// last accepted step, a code is only accepted once
+	TotpLastStep int64 `gorm:"not null;default:0"`
+	// invalid codes since the last lockout or accepted code
+	TotpFailures int `gorm:"not null;default:0"`
+	// unix time until which codes are refused after too many invalid ones
+	TotpLockedUntil       int64 `gorm:"not null;default:0"`
 	autoAddUsernameSuffix bool
 }
 
+func (u *User) TotpEnabled() bool {
+	return len(u.TotpSecret) != 0
+}
+
 func (u *User) EnableAutoAddUsernameSuffix() {
 	u.autoAddUsernameSuffix = true
 }
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+// WebAuthnCredential is a passkey of a user
+type WebAuthnCredential struct {
+	ID         string    `gorm:"primaryKey;type:char(32)"               json:"id"`
+	CreatedAt  time.Time `                                              json:"createdAt"`
+	LastUsedAt time.Time `                                              json:"lastUsedAt"`
+	UserID     string    `gorm:"not null;index;type:char(32)"           json:"-"`
+	Name       string    `gorm:"not null;type:varchar(64)"              json:"name"`
+	// base64url of the raw id, used to find the credential of an assertion
+	CredentialID    string `gorm:"not null;uniqueIndex;type:varchar(512)" json:"-"`
+	PublicKey       []byte `gorm:"not null"                               json:"-"`
+	AttestationType string `gorm:"type:varchar(32)"                       json:"-"`
+	// comma separated
+	Transports string `gorm:"type:varchar(128)"                      json:"-"`
+	AAGUID     []byte `                                              json:"-"`
+	SignCount  uint32 `gorm:"not null;default:0"                     json:"-"`
+	Flags      uint8  `gorm:"not null;default:0"                     json:"-"`
+}
+
+func (c *WebAuthnCredential) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = utils.SortUUID()
+	}
+	return nil
+}
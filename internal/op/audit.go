@@ -0,0 +1,91 @@
+package op
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+// RecordAudit stores a privileged change made by u, a failed write is logged
+// and does not undo the change
+func (u *User) RecordAudit(action model.AuditAction, roomID, targetID string, before, after any) {
+	err := db.CreateAuditLog(&model.AuditLog{
+		ActorID:   u.ID,
+		ActorName: u.Username,
+		Action:    action,
+		RoomID:    roomID,
+		TargetID:  targetID,
+		Before:    auditValue(before),
+		After:     auditValue(after),
+	})
+	if err != nil {
+		log.Errorf("audit: record %s by %s error: %v", action, u.ID, err)
+	}
+}
+
+func auditValue(v any) string {
+	if v == nil {
+		return ""
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
+
+// auditMember is a copy of the fields of a room member, the cached member
+// is changed in place
+type auditMember struct {
+	Role             model.RoomMemberRole       `json:"role"`
+	Status           model.RoomMemberStatus     `json:"status"`
+	Permissions      model.RoomMemberPermission `json:"permissions"`
+	AdminPermissions model.RoomAdminPermission  `json:"adminPermissions"`
+	MutedUntil       *time.Time                 `json:"mutedUntil,omitempty"`
+}
+
+// loadAuditMember returns nil when the user is not a member
+func loadAuditMember(room *Room, userID string) any {
+	m, err := room.LoadMember(userID)
+	if err != nil {
+		return nil
+	}
+
+	am := &auditMember{
+		Role:             m.Role,
+		Status:           m.Status,
+		Permissions:      m.Permissions,
+		AdminPermissions: m.AdminPermissions,
+	}
+
+	if m.MutedUntil != nil {
+		t := *m.MutedUntil
+		am.MutedUntil = &t
+	}
+
+	return am
+}
+
+// auditMemberChange records the state of a member around a successful fn
+func (u *User) auditMemberChange(
+	room *Room,
+	action model.AuditAction,
+	userID string,
+	fn func() error,
+) error {
+	before := loadAuditMember(room, userID)
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	u.RecordAudit(action, room.ID, userID, before, loadAuditMember(room, userID))
+
+	return nil
+}
@@ -23,6 +23,10 @@ type Client struct {
 	timeOut   time.Duration
 	closed    uint32
 	rtcJoined atomic.Bool
+	// smoothed round-trip time in nanoseconds, 0 until the first PONG
+	rtt atomic.Int64
+	// set when the client is authenticated with an api token
+	apiToken *model.APIToken
 }
 
 func newClient(user *User, room *Room, h *Hub, conn *websocket.Conn) *Client {
@@ -37,6 +41,15 @@ func newClient(user *User, room *Room, h *Hub, conn *websocket.Conn) *Client {
 	}
 }
 
+// SetAPIToken must be called before the messages of the client are read
+func (c *Client) SetAPIToken(t *model.APIToken) {
+	c.apiToken = t
+}
+
+func (c *Client) APIToken() *model.APIToken {
+	return c.apiToken
+}
+
 func (c *Client) ConnID() string {
 	return c.connID
 }
@@ -49,6 +62,26 @@ func (c *Client) SetRTCJoined(joined bool) {
 	c.rtcJoined.Store(joined)
 }
 
+func (c *Client) RTT() time.Duration {
+	return time.Duration(c.rtt.Load())
+}
+
+// UpdateRTT folds a round-trip sample into the smoothed RTT the way TCP does.
+func (c *Client) UpdateRTT(sample time.Duration) {
+	for {
+		pre := c.rtt.Load()
+
+		rtt := int64(sample)
+		if pre != 0 {
+			rtt = pre + (rtt-pre)/8
+		}
+
+		if c.rtt.CompareAndSwap(pre, rtt) {
+			return
+		}
+	}
+}
+
 func (c *Client) User() *User {
 	return c.u
 }
@@ -58,6 +91,7 @@ func (c *Client) Room() *Room {
 }
 
 func (c *Client) Broadcast(msg Message, conf ...BroadcastConf) error {
+	publishBroadcast(c.r.ID, msg, conf...)
 	return c.h.Broadcast(msg, conf...)
 }
 
@@ -66,9 +100,24 @@ func (c *Client) SendChatMessage(message string) error {
 		return model.ErrNoPermission
 	}
 
+	muted, err := c.r.IsMemberMuted(c.u.ID)
+	if err != nil {
+		return err
+	}
+
+	if muted {
+		return model.ErrMuted
+	}
+
+	m, err := c.r.AddChatMessage(c.u, message)
+	if err != nil {
+		return err
+	}
+
 	return c.Broadcast(&pb.Message{
 		Type:      pb.MessageType_CHAT,
-		Timestamp: time.Now().UnixMilli(),
+		Timestamp: m.CreatedAt.UnixMilli(),
+		ChatId:    m.ID,
 		Sender: &pb.Sender{
 			UserId:   c.u.ID,
 			Username: c.u.Username,
@@ -140,3 +189,24 @@ func (c *Client) SetStatus(playing bool, seek, rate, timeDiff float64) error {
 		},
 	}, WithIgnoreConnID(c.ConnID()))
 }
+
+// SuggestStatus forwards a status change from a member who is not the
+// playback leader to the leader instead of applying it.
+func (c *Client) SuggestStatus(status *pb.Status) error {
+	leader := c.r.Leader()
+	if leader == "" {
+		return ErrRoomHasNoLeader
+	}
+
+	return c.r.SendToUserWithID(leader, &pb.Message{
+		Type:      pb.MessageType_STATUS_SUGGESTION,
+		Timestamp: time.Now().UnixMilli(),
+		Sender: &pb.Sender{
+			Username: c.User().Username,
+			UserId:   c.User().ID,
+		},
+		Payload: &pb.Message_PlaybackStatus{
+			PlaybackStatus: status,
+		},
+	})
+}
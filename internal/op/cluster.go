@@ -0,0 +1,404 @@
+package op
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/pubsub"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/gencontainer/rwmap"
+	"google.golang.org/protobuf/proto"
+)
+
+// a node is considered gone once it has not reported viewers for this long
+const clusterViewerTTL = time.Second * 15
+
+var (
+	nodeID        = utils.SortUUID()
+	clusterPubSub pubsub.PubSub
+	remoteViewers rwmap.RWMap[string, *rwmap.RWMap[string, nodeViewerCount]]
+)
+
+type nodeViewerCount struct {
+	at    time.Time
+	count int64
+}
+
+type clusterEventType string
+
+const (
+	clusterEventBroadcast  clusterEventType = "broadcast"
+	clusterEventSendToUser clusterEventType = "user"
+	clusterEventSendToConn clusterEventType = "conn"
+	clusterEventKick       clusterEventType = "kick"
+	clusterEventViewers    clusterEventType = "viewers"
+	clusterEventCurrent    clusterEventType = "current"
+	clusterEventMember     clusterEventType = "member"
+	clusterEventSettings   clusterEventType = "settings"
+	// the cached room, its movies or a user changed in the database
+	clusterEventRoom       clusterEventType = "room"
+	clusterEventRoomClosed clusterEventType = "roomClosed"
+	clusterEventMovies     clusterEventType = "movies"
+	clusterEventUser       clusterEventType = "userChanged"
+	clusterEventUserClosed clusterEventType = "userClosed"
+	// the publish key of a movie was revoked
+	clusterEventKickPublishers clusterEventType = "kickPublishers"
+	// an api token was revoked, its websockets are closed on every node
+	clusterEventKickAPIToken clusterEventType = "kickAPIToken"
+)
+
+type clusterEvent struct {
+	Current      *model.Current   `json:"current,omitempty"`
+	Node         string           `json:"node"`
+	Type         clusterEventType `json:"type"`
+	RoomID       string           `json:"roomId"`
+	UserID       string           `json:"userId,omitempty"`
+	ConnID       string           `json:"connId,omitempty"`
+	Message      []byte           `json:"message,omitempty"`
+	IgnoreConnID []string         `json:"ignoreConnId,omitempty"`
+	IgnoreUserID []string         `json:"ignoreUserId,omitempty"`
+	ViewerCount  int64            `json:"viewerCount,omitempty"`
+	RTCJoined    bool             `json:"rtcJoined,omitempty"`
+	MovieIDs     []string         `json:"movieIds,omitempty"`
+	TokenID      string           `json:"tokenId,omitempty"`
+}
+
+// InitPubSub makes this node share room events with every other node
+// connected to the same backend. Without it rooms only live on this node.
+func InitPubSub(ps pubsub.PubSub) error {
+	if _, err := ps.Subscribe(handleClusterEvent); err != nil {
+		return err
+	}
+
+	clusterPubSub = ps
+
+	return nil
+}
+
+func NodeID() string {
+	return nodeID
+}
+
+func publishClusterEvent(e *clusterEvent) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	e.Node = nodeID
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("marshal cluster event failed: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := clusterPubSub.Publish(ctx, data); err != nil {
+		log.Errorf("publish cluster event failed: %v", err)
+	}
+}
+
+func marshalClusterMessage(data Message) ([]byte, bool) {
+	msg, ok := data.(*pb.Message)
+	if !ok {
+		return nil, false
+	}
+
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		log.Errorf("marshal cluster message failed: %v", err)
+		return nil, false
+	}
+
+	return b, true
+}
+
+func publishBroadcast(roomID string, data Message, conf ...BroadcastConf) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	b, ok := marshalClusterMessage(data)
+	if !ok {
+		return
+	}
+
+	bm := &broadcastMessage{}
+	for _, c := range conf {
+		c(bm)
+	}
+
+	publishClusterEvent(&clusterEvent{
+		Type:         clusterEventBroadcast,
+		RoomID:       roomID,
+		Message:      b,
+		IgnoreConnID: bm.ignoreConnID,
+		IgnoreUserID: bm.ignoreUserID,
+		RTCJoined:    bm.rtcJoined,
+	})
+}
+
+func publishSendTo(roomID, userID, connID string, data Message) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	b, ok := marshalClusterMessage(data)
+	if !ok {
+		return
+	}
+
+	e := &clusterEvent{
+		Type:    clusterEventSendToUser,
+		RoomID:  roomID,
+		UserID:  userID,
+		Message: b,
+	}
+	if connID != "" {
+		e.Type = clusterEventSendToConn
+		e.ConnID = connID
+	}
+
+	publishClusterEvent(e)
+}
+
+func publishRoomEvent(t clusterEventType, roomID, userID string) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	publishClusterEvent(&clusterEvent{
+		Type:   t,
+		RoomID: roomID,
+		UserID: userID,
+	})
+}
+
+// publishUserEvent drops the cached user on the other nodes, they load it
+// from the database on the next use.
+func publishUserEvent(t clusterEventType, userID string) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	publishClusterEvent(&clusterEvent{
+		Type:   t,
+		UserID: userID,
+	})
+}
+
+// publishMoviesEvent drops the cached movies and their children on the
+// other nodes, an empty id drops every movie of the room.
+func publishMoviesEvent(roomID string, ids ...string) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	publishClusterEvent(&clusterEvent{
+		Type:     clusterEventMovies,
+		RoomID:   roomID,
+		MovieIDs: ids,
+	})
+}
+
+func publishKickPublishersEvent(roomID, movieID string) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	publishClusterEvent(&clusterEvent{
+		Type:     clusterEventKickPublishers,
+		RoomID:   roomID,
+		MovieIDs: []string{movieID},
+	})
+}
+
+func publishKickAPITokenEvent(userID, tokenID string) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	publishClusterEvent(&clusterEvent{
+		Type:    clusterEventKickAPIToken,
+		UserID:  userID,
+		TokenID: tokenID,
+	})
+}
+
+func publishCurrent(roomID string, c model.Current) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	publishClusterEvent(&clusterEvent{
+		Type:    clusterEventCurrent,
+		RoomID:  roomID,
+		Current: &c,
+	})
+}
+
+func publishViewerCount(roomID string, count int64) {
+	if clusterPubSub == nil {
+		return
+	}
+
+	publishClusterEvent(&clusterEvent{
+		Type:        clusterEventViewers,
+		RoomID:      roomID,
+		ViewerCount: count,
+	})
+}
+
+func remoteViewerCount(roomID string) int64 {
+	nodes, ok := remoteViewers.Load(roomID)
+	if !ok {
+		return 0
+	}
+
+	var count int64
+
+	nodes.Range(func(node string, v nodeViewerCount) bool {
+		if time.Since(v.at) > clusterViewerTTL {
+			nodes.CompareAndDelete(node, v)
+		} else {
+			count += v.count
+		}
+
+		return true
+	})
+
+	return count
+}
+
+func setRemoteViewerCount(roomID, node string, count int64) {
+	nodes, _ := remoteViewers.LoadOrStore(roomID, &rwmap.RWMap[string, nodeViewerCount]{})
+	if count <= 0 {
+		nodes.Delete(node)
+		return
+	}
+
+	nodes.Store(node, nodeViewerCount{count: count, at: time.Now()})
+}
+
+func handleClusterEvent(data []byte) {
+	var e clusterEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		log.Errorf("unmarshal cluster event failed: %v", err)
+		return
+	}
+
+	if e.Node == nodeID {
+		return
+	}
+
+	switch e.Type {
+	case clusterEventViewers:
+		setRemoteViewerCount(e.RoomID, e.Node, e.ViewerCount)
+		return
+	case clusterEventUser:
+		userCache.Delete(e.UserID)
+		return
+	case clusterEventUserClosed:
+		_ = CloseUserByID(e.UserID)
+		return
+	case clusterEventRoomClosed:
+		_ = CloseRoomByID(e.RoomID)
+		return
+	case clusterEventKickAPIToken:
+		kickAPITokenLocal(e.UserID, e.TokenID)
+		return
+	default:
+	}
+
+	entry, ok := roomCache.Load(e.RoomID)
+	if !ok {
+		return
+	}
+
+	r := entry.Value()
+
+	switch e.Type {
+	case clusterEventBroadcast:
+		msg, err := unmarshalClusterMessage(e.Message)
+		if err != nil {
+			return
+		}
+
+		conf := []BroadcastConf{
+			WithIgnoreConnID(e.IgnoreConnID...),
+			WithIgnoreID(e.IgnoreUserID...),
+		}
+		if e.RTCJoined {
+			conf = append(conf, WithRTCJoined())
+		}
+
+		_ = r.broadcastLocal(msg, conf...)
+	case clusterEventSendToUser:
+		msg, err := unmarshalClusterMessage(e.Message)
+		if err != nil {
+			return
+		}
+
+		_ = r.sendToUserLocal(e.UserID, msg)
+	case clusterEventSendToConn:
+		msg, err := unmarshalClusterMessage(e.Message)
+		if err != nil {
+			return
+		}
+
+		_ = r.sendToConnIDLocal(e.UserID, e.ConnID, msg)
+	case clusterEventKick:
+		_ = r.kickUserLocal(e.UserID)
+	case clusterEventCurrent:
+		if e.Current != nil {
+			r.current.apply(*e.Current)
+			// the node that changed the status arms its own timer, one
+			// armed here as well could advance the room twice
+			r.stopAutoAdvance()
+		}
+	case clusterEventMember:
+		r.members.Delete(e.UserID)
+	case clusterEventSettings:
+		rs, err := db.CreateOrLoadRoomSettings(r.ID)
+		if err != nil {
+			log.Errorf("reload room settings failed: %v", err)
+			return
+		}
+
+		r.settingsMu.Lock()
+		r.applySettings(rs)
+		r.settingsMu.Unlock()
+	case clusterEventRoom:
+		room, err := db.GetRoomByID(r.ID)
+		if err != nil {
+			log.Errorf("reload room failed: %v", err)
+			return
+		}
+
+		r.applyRoom(room)
+	case clusterEventMovies:
+		r.movies.DeleteMovieAndChiledCache(e.MovieIDs...)
+	case clusterEventKickPublishers:
+		for _, id := range e.MovieIDs {
+			r.movies.KickPublishers(id)
+		}
+	default:
+	}
+}
+
+func unmarshalClusterMessage(b []byte) (*pb.Message, error) {
+	msg := &pb.Message{}
+	if err := proto.Unmarshal(b, msg); err != nil {
+		log.Errorf("unmarshal cluster message failed: %v", err)
+		return nil, err
+	}
+
+	return msg, nil
+}
@@ -2,16 +2,22 @@ package op
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
 )
 
 type current struct {
 	roomID  string
 	current model.Current
 	lock    sync.RWMutex
+	// the latest current waiting to be published to the other nodes
+	pending    atomic.Pointer[model.Current]
+	publishing atomic.Bool
 }
 
 func newCurrent(roomID string, c *model.Current) *current {
@@ -31,10 +37,12 @@ func newCurrent(roomID string, c *model.Current) *current {
 }
 
 func (c *current) Current() model.Current {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
+	c.syncTimeShift()
 	c.current.UpdateStatus()
+	c.clampTimeShift()
 
 	return c.current
 }
@@ -48,22 +56,21 @@ func (c *current) CurrentMovie() model.CurrentMovie {
 func (c *current) SetMovie(movie model.CurrentMovie, play bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	defer func() {
-		if err := db.SetRoomCurrent(c.roomID, &c.current); err != nil {
-			log.Errorf("set room current failed: %v", err)
-		}
-	}()
+	defer c.save()
 
 	c.current.Movie = movie
+	c.syncTimeShift()
 	c.current.SetSeek(0, 0)
 	c.current.Status.IsPlaying = play
 }
 
 func (c *current) Status() model.Status {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
+	c.syncTimeShift()
 	c.current.UpdateStatus()
+	c.clampTimeShift()
 
 	return c.current.Status
 }
@@ -71,13 +78,13 @@ func (c *current) Status() model.Status {
 func (c *current) SetStatus(playing bool, seek, rate, timeDiff float64) *model.Status {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	defer func() {
-		if err := db.SetRoomCurrent(c.roomID, &c.current); err != nil {
-			log.Errorf("set room current failed: %v", err)
-		}
-	}()
+	defer c.save()
 
-	s := c.current.SetStatus(playing, seek, rate, timeDiff)
+	c.syncTimeShift()
+	c.current.SetStatus(playing, seek, rate, timeDiff)
+	c.clampTimeShift()
+
+	s := c.current.Status
 
 	return &s
 }
@@ -85,13 +92,119 @@ func (c *current) SetStatus(playing bool, seek, rate, timeDiff float64) *model.S
 func (c *current) SetSeekRate(seek, rate, timeDiff float64) *model.Status {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	defer func() {
-		if err := db.SetRoomCurrent(c.roomID, &c.current); err != nil {
-			log.Errorf("set room current failed: %v", err)
-		}
-	}()
+	defer c.save()
 
-	s := c.current.SetSeekRate(seek, rate, timeDiff)
+	c.syncTimeShift()
+	c.current.SetSeekRate(seek, rate, timeDiff)
+	c.clampTimeShift()
+
+	s := c.current.Status
 
 	return &s
 }
+
+// syncTimeShift follows the time shift setting, which may change while the
+// movie is playing, it must be called with the lock held
+func (c *current) syncTimeShift() {
+	enabled := c.current.Movie.CanTimeShift && settings.LiveTimeShift.Get() > 0
+	if c.current.Movie.TimeShift == enabled {
+		return
+	}
+
+	// both modes start at live
+	c.current.Movie.TimeShift = enabled
+	c.current.Status.CurrentTime = 0
+	c.current.Status.LastUpdate = time.Now()
+}
+
+// clampTimeShift keeps the delay behind live inside the time-shift window,
+// older segments are already gone, it must be called with the lock held
+func (c *current) clampTimeShift() {
+	if !c.current.Movie.TimeShift {
+		return
+	}
+
+	window := float64(settings.LiveTimeShift.Get() * 60)
+	c.current.Status.CurrentTime = min(c.current.Status.CurrentTime, window)
+}
+
+// save persists the current and queues it to be published, it must be
+// called with the lock held
+func (c *current) save() {
+	if err := db.SetRoomCurrent(c.roomID, &c.current); err != nil {
+		log.Errorf("set room current failed: %v", err)
+	}
+
+	if clusterPubSub == nil {
+		return
+	}
+
+	cur := c.current
+	c.pending.Store(&cur)
+
+	if c.publishing.CompareAndSwap(false, true) {
+		go c.publishPending()
+	}
+}
+
+// publishPending publishes outside of the lock so that a slow broker does not
+// stall the room, only the latest current is published when several are
+// queued
+func (c *current) publishPending() {
+	for {
+		cur := c.pending.Swap(nil)
+		if cur == nil {
+			c.publishing.Store(false)
+
+			// a current may be queued between the swap and the store
+			if c.pending.Load() == nil || !c.publishing.CompareAndSwap(false, true) {
+				return
+			}
+
+			continue
+		}
+
+		publishCurrent(c.roomID, *cur)
+	}
+}
+
+// apply replaces the current with one set on another node,
+// it is already persisted there so it is not written again.
+func (c *current) apply(cur model.Current) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.current = cur
+}
+
+func (c *current) Leader() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.current.Leader
+}
+
+func (c *current) SetLeader(userID string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	defer c.save()
+
+	c.current.Leader = userID
+}
+
+// SetDuration reports whether the duration of movieID was set, it is only
+// set once for each movie.
+func (c *current) SetDuration(movieID string, duration float64) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.current.Movie.ID != movieID || c.current.Movie.Duration > 0 {
+		return false
+	}
+
+	c.current.Movie.Duration = duration
+
+	c.save()
+
+	return true
+}
@@ -0,0 +1,45 @@
+package op
+
+import (
+	"testing"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+func TestTimeShiftDelayClamped(t *testing.T) {
+	initTestDB(t)
+
+	err := db.FirstOrCreateSettingItemValue(&model.Setting{
+		Name:  settings.LiveTimeShift.Name(),
+		Type:  model.SettingTypeInt64,
+		Group: model.SettingGroupRtmp,
+		Value: "0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := settings.LiveTimeShift.Set(1); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = settings.LiveTimeShift.Set(0) })
+
+	c := newCurrent("room", nil)
+	c.SetMovie(model.CurrentMovie{ID: "live", IsLive: true, CanTimeShift: true}, true)
+
+	if s := c.SetStatus(true, time.Hour.Seconds(), 1, 0); s.CurrentTime != 60 {
+		t.Fatalf("seek past the window: %v seconds behind live", s.CurrentTime)
+	}
+
+	// paused playback keeps falling behind in real time
+	c.SetStatus(false, 59, 1, 0)
+	c.current.Status.LastUpdate = time.Now().Add(-time.Minute)
+
+	if s := c.Status(); s.CurrentTime != 60 {
+		t.Fatalf("paused past the window: %v seconds behind live", s.CurrentTime)
+	}
+}
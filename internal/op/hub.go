@@ -116,7 +116,10 @@ func (h *Hub) ping() {
 	for {
 		select {
 		case <-ticker.C:
-			current = h.ClientNum()
+			local := h.ClientNum()
+			publishViewerCount(h.id, local)
+
+			current = local + remoteViewerCount(h.id)
 			if current != pre {
 				if err := h.Broadcast(&pb.Message{
 					Type: pb.MessageType_VIEWER_COUNT,
@@ -133,6 +136,16 @@ func (h *Hub) ping() {
 					continue
 				}
 			}
+
+			// clients answer with a PONG so that their round-trip time is known
+			now := time.Now().UnixMilli()
+			_ = h.Broadcast(&pb.Message{
+				Type:      pb.MessageType_PING,
+				Timestamp: now,
+				Payload: &pb.Message_Ping{
+					Ping: &pb.Ping{SentAt: now},
+				},
+			})
 		case <-h.exit:
 			return
 		}
@@ -159,6 +172,7 @@ func (h *Hub) Close() error {
 	}
 
 	close(h.exit)
+	publishViewerCount(h.id, 0)
 	h.clients.Range(func(id string, clients *clients) bool {
 		h.clients.CompareAndDelete(id, clients)
 
@@ -348,3 +362,26 @@ func (h *Hub) KickUser(userID string) error {
 
 	return nil
 }
+
+// KickAPIToken closes the connections of userID opened with the api token
+func (h *Hub) KickAPIToken(userID, tokenID string) error {
+	if h.Closed() {
+		return ErrAlreadyClosed
+	}
+
+	cli, ok := h.clients.Load(userID)
+	if !ok {
+		return nil
+	}
+
+	cli.lock.RLock()
+	defer cli.lock.RUnlock()
+
+	for _, c := range cli.m {
+		if t := c.APIToken(); t != nil && t.ID == tokenID {
+			c.Close()
+		}
+	}
+
+	return nil
+}
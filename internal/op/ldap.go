@@ -0,0 +1,58 @@
+package op
+
+import (
+	"errors"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/ldap"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+// ErrLdapUser is returned for local password logins and changes of users
+// bound to ldap, their password is checked by the ldap server only
+var ErrLdapUser = errors.New("the password of ldap users is managed by ldap")
+
+// LoadOrCreateLdapUser loads the user bound to e, it is created when create
+// is true. The role follows the admin group of ldap, root, pending and
+// banned users are not changed
+func LoadOrCreateLdapUser(e *ldap.Entry, create bool) (*UserEntry, error) {
+	var (
+		userE *UserEntry
+		err   error
+	)
+
+	if create {
+		userE, err = CreateOrLoadUserWithProvider(
+			e.Username,
+			utils.RandString(16),
+			ldap.Provider,
+			e.ID,
+		)
+	} else {
+		userE, err = GetUserByProvider(ldap.Provider, e.ID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	user := userE.Value()
+
+	switch {
+	case e.Role == model.RoleAdmin && user.Role == model.RoleUser:
+		err = user.SetAdminRole()
+	case e.Role == model.RoleUser && user.Role == model.RoleAdmin:
+		err = user.SetUserRole()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return userE, nil
+}
+
+func (u *User) IsLdapUser() (bool, error) {
+	return db.HasBindProvider(u.ID, ldap.Provider)
+}
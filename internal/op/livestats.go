@@ -0,0 +1,342 @@
+package op
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/flvtag"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/zijiren233/livelib/av"
+	rtmps "github.com/zijiren233/livelib/server"
+)
+
+const (
+	// bitrate and frame rate are averaged over this much media time
+	liveStatsWindow = 5000
+
+	// an hls viewer counts until its playlist was not refreshed for this long
+	hlsViewerTimeout = 30 * time.Second
+)
+
+// LiveStats is a snapshot of a live channel
+type LiveStats struct {
+	MovieID          string
+	Publishing       bool
+	PublishedAt      time.Time
+	VideoCodec       string
+	AudioCodec       string
+	Width            int
+	Height           int
+	FrameRate        float64
+	Bitrate          uint64
+	KeyframeInterval time.Duration
+	FlvViewers       int
+	HlsViewers       int
+}
+
+type liveSample struct {
+	ts    uint32
+	size  int
+	frame bool
+}
+
+type liveStats struct {
+	mu          sync.Mutex
+	publishing  bool
+	publishedAt time.Time
+	videoCodec  string
+	audioCodec  string
+	width       int
+	height      int
+	samples     []liveSample
+	lastKey     uint32
+	hasKey      bool
+	keyInterval uint32
+	hlsViewers  map[string]time.Time
+	// expired hls viewers are dropped at most once per hlsViewerTimeout
+	hlsPrunedAt time.Time
+	flvViewers  atomic.Int64
+}
+
+func (s *liveStats) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.publishing = true
+	s.publishedAt = time.Now()
+}
+
+// stop forgets the publication, packets may be observed before start
+func (s *liveStats) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.publishing = false
+	s.videoCodec, s.audioCodec = "", ""
+	s.width, s.height = 0, 0
+	s.samples = nil
+	s.hasKey = false
+	s.keyInterval = 0
+}
+
+func (s *liveStats) isPublishing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.publishing
+}
+
+func (s *liveStats) observe(p *av.Packet) {
+	if p.IsMetadata || p.Header == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.IsVideo {
+		vh, ok := p.Header.(av.VideoPacketHeader)
+		if !ok {
+			return
+		}
+
+		s.videoCodec = videoCodecName(vh.CodecID())
+
+		if vh.IsSeq() {
+			s.parseVideoConfig(p.Data)
+			return
+		}
+
+		if vh.IsKeyFrame() {
+			if s.hasKey && p.TimeStamp > s.lastKey {
+				s.keyInterval = p.TimeStamp - s.lastKey
+			}
+
+			s.lastKey = p.TimeStamp
+			s.hasKey = true
+		}
+	} else {
+		ah, ok := p.Header.(av.AudioPacketHeader)
+		if !ok {
+			return
+		}
+
+		s.audioCodec = audioCodecName(ah.SoundFormat())
+
+		if ah.SoundFormat() == av.SOUND_AAC && ah.AACPacketType() == av.AAC_SEQHDR {
+			return
+		}
+	}
+
+	s.samples = append(s.samples, liveSample{ts: p.TimeStamp, size: len(p.Data), frame: p.IsVideo})
+
+	i := 0
+	for i < len(s.samples) && s.samples[i].ts+liveStatsWindow < p.TimeStamp {
+		i++
+	}
+
+	s.samples = s.samples[i:]
+}
+
+func (s *liveStats) parseVideoConfig(tag []byte) {
+	sps, err := flvtag.AVCConfigSPS(tag)
+	if err != nil {
+		return
+	}
+
+	s.width, s.height, err = flvtag.SPSResolution(sps)
+	if err != nil {
+		s.width, s.height = 0, 0
+	}
+}
+
+func (s *liveStats) watchHls(viewer string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hlsViewers == nil {
+		s.hlsViewers = make(map[string]time.Time)
+	}
+
+	now := time.Now()
+	s.hlsViewers[viewer] = now
+
+	if now.Sub(s.hlsPrunedAt) > hlsViewerTimeout {
+		s.pruneHlsViewers(now)
+	}
+}
+
+func (s *liveStats) pruneHlsViewers(now time.Time) {
+	for viewer, seen := range s.hlsViewers {
+		if now.Sub(seen) > hlsViewerTimeout {
+			delete(s.hlsViewers, viewer)
+		}
+	}
+
+	s.hlsPrunedAt = now
+}
+
+func (s *liveStats) snapshot(movieID string) *LiveStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := &LiveStats{
+		MovieID:          movieID,
+		Publishing:       s.publishing,
+		VideoCodec:       s.videoCodec,
+		AudioCodec:       s.audioCodec,
+		Width:            s.width,
+		Height:           s.height,
+		KeyframeInterval: time.Duration(s.keyInterval) * time.Millisecond,
+		FlvViewers:       int(s.flvViewers.Load()),
+	}
+
+	if s.publishing {
+		stats.PublishedAt = s.publishedAt
+	}
+
+	s.pruneHlsViewers(time.Now())
+	stats.HlsViewers = len(s.hlsViewers)
+
+	if len(s.samples) < 2 {
+		return stats
+	}
+
+	span := s.samples[len(s.samples)-1].ts - s.samples[0].ts
+	if span < 1000 {
+		return stats
+	}
+
+	var (
+		size   int
+		frames int
+	)
+
+	for _, sample := range s.samples {
+		size += sample.size
+		if sample.frame {
+			frames++
+		}
+	}
+
+	stats.Bitrate = uint64(size) * 8 * 1000 / uint64(span)
+	stats.FrameRate = float64(frames) * 1000 / float64(span)
+
+	return stats
+}
+
+func videoCodecName(id uint8) string {
+	switch id {
+	case av.CODEC_AVC:
+		return "h264"
+	case 12:
+		return "hevc"
+	default:
+		return fmt.Sprintf("codec %d", id)
+	}
+}
+
+func audioCodecName(format uint8) string {
+	switch format {
+	case av.SOUND_AAC:
+		return "aac"
+	case av.SOUND_MP3:
+		return "mp3"
+	case av.SOUND_SPEEX:
+		return "speex"
+	default:
+		return fmt.Sprintf("format %d", format)
+	}
+}
+
+// statsPlayer feeds the packets of one publication to liveStats
+type statsPlayer struct {
+	stats *liveStats
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (p *statsPlayer) Write(pkt *av.Packet) error {
+	p.stats.observe(pkt)
+	return nil
+}
+
+func (p *statsPlayer) Close() error {
+	p.once.Do(func() {
+		close(p.done)
+	})
+
+	return nil
+}
+
+func (m *Movie) startLiveStats(c *rtmps.Channel) {
+	go m.watchPublisher(c)
+}
+
+// watchPublisher follows the publications of the channel and tells the
+// room when a publisher connects or leaves
+func (m *Movie) watchPublisher(c *rtmps.Channel) {
+	for m.channel.Load() == c && !c.Closed() {
+		p := &statsPlayer{stats: &m.liveStats, done: make(chan struct{})}
+		if err := c.AddPlayer(p); err != nil {
+			time.Sleep(time.Second)
+			continue
+		}
+
+		m.liveStats.start()
+		m.broadcastPublisher(pb.MessageType_LIVE_PUBLISH)
+
+		<-p.done
+
+		m.liveStats.stop()
+
+		// a terminated movie is announced with the movie list instead
+		if m.channel.Load() != c {
+			return
+		}
+
+		m.broadcastPublisher(pb.MessageType_LIVE_UNPUBLISH)
+	}
+}
+
+func (m *Movie) broadcastPublisher(t pb.MessageType) {
+	err := m.room.Broadcast(&pb.Message{
+		Type:      t,
+		Timestamp: time.Now().UnixMilli(),
+		MovieId:   m.ID,
+	})
+	if err != nil && !errors.Is(err, ErrAlreadyClosed) {
+		log.Errorf("broadcast publisher of movie %s error: %v", m.ID, err)
+	}
+}
+
+// LiveStats returns nil until the channel of the movie is started
+func (m *Movie) LiveStats() *LiveStats {
+	if m.channel.Load() == nil {
+		return nil
+	}
+
+	return m.liveStats.snapshot(m.ID)
+}
+
+// WatchFlv counts an flv viewer until done is called
+func (m *Movie) WatchFlv() (done func()) {
+	m.liveStats.flvViewers.Add(1)
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			m.liveStats.flvViewers.Add(-1)
+		})
+	}
+}
+
+// WatchHls counts an hls viewer for a while after each playlist request
+func (m *Movie) WatchHls(viewer string) {
+	m.liveStats.watchHls(viewer)
+}
@@ -0,0 +1,114 @@
+package op
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	roomsDesc = prometheus.NewDesc(
+		"synctv_rooms_active",
+		"Rooms loaded in memory.",
+		nil, nil,
+	)
+	hubClientsDesc = prometheus.NewDesc(
+		"synctv_hub_clients",
+		"Websocket clients connected to the hubs of the rooms.",
+		nil, nil,
+	)
+	hubQueueDesc = prometheus.NewDesc(
+		"synctv_hub_broadcast_queue",
+		"Messages waiting in the broadcast queues of the rooms.",
+		nil, nil,
+	)
+	roomHubClientsDesc = prometheus.NewDesc(
+		"synctv_room_hub_clients",
+		"Websocket clients connected to the hub of a room.",
+		[]string{"room_id"}, nil,
+	)
+	roomHubQueueDesc = prometheus.NewDesc(
+		"synctv_room_hub_broadcast_queue",
+		"Messages waiting in the broadcast queue of a room.",
+		[]string{"room_id"}, nil,
+	)
+	channelsDesc = prometheus.NewDesc(
+		"synctv_rtmp_channels",
+		"Started live channels.",
+		nil, nil,
+	)
+	publishingDesc = prometheus.NewDesc(
+		"synctv_rtmp_channels_publishing",
+		"Live channels with a connected publisher or proxy source.",
+		nil, nil,
+	)
+)
+
+// Collector exports the state of the loaded rooms, it is read on every scrape.
+// The hubs are summed up unless perRoom is set, a series per room grows with
+// every room that is ever loaded.
+type Collector struct {
+	perRoom bool
+}
+
+func NewCollector(perRoom bool) *Collector {
+	return &Collector{perRoom: perRoom}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- roomsDesc
+	ch <- hubClientsDesc
+	ch <- hubQueueDesc
+	ch <- channelsDesc
+	ch <- publishingDesc
+
+	if c.perRoom {
+		ch <- roomHubClientsDesc
+		ch <- roomHubQueueDesc
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	var rooms, clients, queued, channels, publishing int
+
+	RangeRoomCache(func(_ string, value *RoomEntry) bool {
+		rooms++
+
+		room := value.Value()
+
+		if h := room.hub.Load(); h != nil && !h.Closed() {
+			n, q := h.ClientNum(), len(h.broadcast)
+			clients += int(n)
+			queued += q
+
+			if c.perRoom {
+				ch <- prometheus.MustNewConstMetric(
+					roomHubClientsDesc, prometheus.GaugeValue, float64(n), room.ID,
+				)
+				ch <- prometheus.MustNewConstMetric(
+					roomHubQueueDesc, prometheus.GaugeValue, float64(q), room.ID,
+				)
+			}
+		}
+
+		room.movies.cache.Range(func(_ string, m *Movie) bool {
+			if m.channel.Load() == nil {
+				return true
+			}
+
+			channels++
+
+			if m.liveStats.isPublishing() {
+				publishing++
+			}
+
+			return true
+		})
+
+		return true
+	})
+
+	ch <- prometheus.MustNewConstMetric(roomsDesc, prometheus.GaugeValue, float64(rooms))
+	ch <- prometheus.MustNewConstMetric(hubClientsDesc, prometheus.GaugeValue, float64(clients))
+	ch <- prometheus.MustNewConstMetric(hubQueueDesc, prometheus.GaugeValue, float64(queued))
+	ch <- prometheus.MustNewConstMetric(channelsDesc, prometheus.GaugeValue, float64(channels))
+	ch <- prometheus.MustNewConstMetric(publishingDesc, prometheus.GaugeValue, float64(publishing))
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"hash/crc32"
+	"io"
 	"net/http"
 	"net/url"
 	"sync/atomic"
@@ -13,9 +14,12 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/cache"
 	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/hlspull"
 	"github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/settings"
 	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/gencontainer/rwmap"
 	"github.com/zijiren233/go-uhc"
 	"github.com/zijiren233/livelib/av"
 	"github.com/zijiren233/livelib/container/flv"
@@ -29,6 +33,10 @@ type Movie struct {
 	room *Room
 	*model.Movie
 	channel       atomic.Pointer[rtmps.Channel]
+	timeShift     atomic.Pointer[TimeShift]
+	transcoder    atomic.Pointer[Transcoder]
+	liveStats     liveStats
+	publishers    rwmap.RWMap[av.ReadCloser, struct{}]
 	alistCache    atomic.Pointer[cache.AlistMovieCache]
 	bilibiliCache atomic.Pointer[cache.BilibiliMovieCache]
 	embyCache     atomic.Pointer[cache.EmbyMovieCache]
@@ -207,6 +215,10 @@ func (m *Movie) initRtmpSourceChannel() (*rtmps.Channel, error) {
 		return nil, fmt.Errorf("init rtmp hls player error: %w", err)
 	}
 
+	m.startTimeShift(c)
+	m.startRecord(c)
+	m.startLiveStats(c)
+
 	return c, nil
 }
 
@@ -223,6 +235,10 @@ func (m *Movie) initRtmpProxyChannel() (*rtmps.Channel, error) {
 
 	go m.handleRtmpProxy(c)
 
+	m.startTimeShift(c)
+	m.startRecord(c)
+	m.startLiveStats(c)
+
 	return c, nil
 }
 
@@ -248,8 +264,14 @@ func (m *Movie) handleRtmpProxy(c *rtmps.Channel) {
 	}
 }
 
+// HlsPassthrough reports whether a proxied live playlist is handed to the
+// viewers as it is instead of being pulled into a channel
+func (m *Movie) HlsPassthrough() bool {
+	return m.Live && m.Proxy && utils.IsM3u8Url(m.URL) && !settings.LiveHlsIngest.Get()
+}
+
 func (m *Movie) initHTTPProxyChannel() (*rtmps.Channel, error) {
-	if utils.IsM3u8Url(m.URL) {
+	if m.HlsPassthrough() {
 		return nil, errors.New("m3u8 url not support")
 	}
 
@@ -265,6 +287,10 @@ func (m *Movie) initHTTPProxyChannel() (*rtmps.Channel, error) {
 
 	go m.handleHTTPProxy(c)
 
+	m.startTimeShift(c)
+	m.startRecord(c)
+	m.startLiveStats(c)
+
 	return c, nil
 }
 
@@ -274,37 +300,61 @@ func (m *Movie) handleHTTPProxy(c *rtmps.Channel) {
 			return
 		}
 
-		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, m.URL, nil)
+		r, err := m.openHTTPProxy()
 		if err != nil {
 			log.Errorf("get live error: %v", err)
 			time.Sleep(time.Second)
 			continue
 		}
 
-		for k, v := range m.Headers {
-			req.Header.Set(k, v)
-		}
-
-		if req.Header.Get("User-Agent") == "" {
-			req.Header.Set("User-Agent", utils.UA)
-		}
-
-		resp, err := uhc.Do(req)
-		if err != nil {
-			log.Errorf("get live error: %v", err)
-			resp.Body.Close()
+		if err := c.PushStart(r); err != nil {
+			log.Errorf("push live error: %v", err)
+			r.Close()
 			time.Sleep(time.Second)
+
 			continue
 		}
 
-		if err := c.PushStart(flv.NewReader(resp.Body)); err != nil {
-			log.Errorf("push live error: %v", err)
-			resp.Body.Close()
-			time.Sleep(time.Second)
-		}
+		r.Close()
 	}
 }
 
+// openHTTPProxy pulls an hls playlist or an http-flv stream
+func (m *Movie) openHTTPProxy() (av.ReadCloser, error) {
+	if utils.IsM3u8Url(m.URL) {
+		return hlspull.NewReader(m.URL, m.Headers), nil
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, m.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range m.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", utils.UA)
+	}
+
+	resp, err := uhc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &flvReader{Reader: flv.NewReader(resp.Body), body: resp.Body}, nil
+}
+
+type flvReader struct {
+	*flv.Reader
+	body io.Closer
+}
+
+func (r *flvReader) Close() error {
+	return r.body.Close()
+}
+
 func (m *Movie) Validate() error {
 	// First check vendor info
 	if m.VendorInfo.Vendor != "" {
@@ -316,11 +366,20 @@ func (m *Movie) Validate() error {
 		return nil
 	}
 
+	// Recordings are served from disk
+	if m.RecordingID != "" {
+		return m.validateRecording()
+	}
+
 	// Validate RTMP source settings
 	if err := m.validateRTMPSource(); err != nil {
 		return err
 	}
 
+	if err := m.validateRecord(); err != nil {
+		return err
+	}
+
 	// Validate URL and proxy settings
 	return m.validateURLAndProxy()
 }
@@ -338,6 +397,34 @@ func (m *Movie) validateRTMPSource() error {
 	return nil
 }
 
+func (m *Movie) validateRecording() error {
+	recording, err := db.GetRecording(m.room.ID, m.RecordingID)
+	if err != nil {
+		return err
+	}
+
+	if recording.Status != model.RecordingStatusFinished {
+		return errors.New("recording is not finished")
+	}
+
+	return nil
+}
+
+func (m *Movie) validateRecord() error {
+	switch {
+	case m.Record == "":
+		return nil
+	case !settings.RecordEnable.Get():
+		return errors.New("recording is not enabled")
+	case !m.Record.Valid():
+		return fmt.Errorf("unsupported record format: %s", m.Record)
+	case !m.Live || (!m.RtmpSource && !m.Proxy):
+		return errors.New("only rtmp source and proxied live movies can be recorded")
+	}
+
+	return nil
+}
+
 func (m *Movie) validateURLAndProxy() error {
 	u, err := url.Parse(m.URL)
 	if err != nil {
@@ -0,0 +1,119 @@
+package op
+
+import (
+	"errors"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func (r *Room) AddMovieRequest(request *model.MovieRequest) error {
+	if request.IsFolder {
+		return errors.New("cannot request a folder")
+	}
+
+	if request.RtmpSource {
+		return errors.New("cannot request a rtmp source")
+	}
+
+	// validate it the same way it will be validated once promoted
+	movie := &Movie{
+		room: r,
+		Movie: &model.Movie{
+			MovieBase: request.MovieBase,
+			CreatorID: request.CreatorID,
+		},
+	}
+	if err := movie.Validate(); err != nil {
+		return err
+	}
+
+	request.RoomID = r.ID
+
+	return db.CreateMovieRequest(request)
+}
+
+func (r *Room) GetMovieRequestsWithPage(
+	page, pageSize int,
+) ([]*model.MovieRequest, int64, error) {
+	count, err := db.GetMovieRequestsCount(r.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	requests, err := db.GetMovieRequests(r.ID, db.Paginate(page, pageSize))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return requests, count, nil
+}
+
+func (r *Room) GetMovieRequest(id string) (*model.MovieRequest, error) {
+	return db.GetMovieRequest(r.ID, id)
+}
+
+func (r *Room) DeleteMovieRequest(id string) error {
+	return db.DeleteMovieRequest(r.ID, id)
+}
+
+// VoteMovieRequest returns the added movie when the vote reached the
+// promotion threshold of the room, nil otherwise.
+func (r *Room) VoteMovieRequest(id, userID string) (*model.Movie, error) {
+	request, err := db.VoteMovieRequest(r.ID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := r.Settings.MovieRequestVoteThreshold
+	if threshold <= 0 || request.Votes < threshold {
+		return nil, nil
+	}
+
+	m, err := r.PromoteMovieRequest(id)
+	if err != nil {
+		// a concurrent vote already promoted it
+		if errors.Is(err, db.NotFoundError(db.ErrMovieRequestNotFound)) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *Room) UnvoteMovieRequest(id, userID string) error {
+	_, err := db.UnvoteMovieRequest(r.ID, id, userID)
+	return err
+}
+
+// PromoteMovieRequest adds the requested movie to the movie list on
+// behalf of the member who requested it and removes the request.
+func (r *Room) PromoteMovieRequest(id string) (*model.Movie, error) {
+	request, err := db.GetMovieRequest(r.ID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	creator, err := LoadOrInitUserByID(request.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := creator.Value().NewMovie(&request.MovieBase)
+	if err != nil {
+		return nil, err
+	}
+
+	m.RoomID = r.ID
+
+	err = r.movies.addMovie(m, func(m *model.Movie) error {
+		return db.PromoteMovieRequest(r.ID, id, m)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
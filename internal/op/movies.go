@@ -1,8 +1,11 @@
 package op
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/synctv-org/synctv/internal/db"
@@ -18,8 +21,14 @@ type movies struct {
 	cache  rwmap.RWMap[string, *Movie]
 }
 
-//nolint:gosec
 func (m *movies) AddMovie(mo *model.Movie) error {
+	return m.addMovie(mo, db.CreateMovie)
+}
+
+// addMovie validates the movie and caches it once create stored it
+//
+//nolint:gosec
+func (m *movies) addMovie(mo *model.Movie, create func(*model.Movie) error) error {
 	mo.Position = uint(time.Now().UnixMilli())
 	movie := &Movie{
 		room:  m.room,
@@ -31,7 +40,7 @@ func (m *movies) AddMovie(mo *model.Movie) error {
 		return err
 	}
 
-	err = db.CreateMovie(mo)
+	err = create(mo)
 	if err != nil {
 		return err
 	}
@@ -90,6 +99,25 @@ func (m *movies) GetChannel(id string) (*rtmps.Channel, error) {
 	return movie.Channel()
 }
 
+// LiveStats returns the stats of the live movies whose channel is started
+func (m *movies) LiveStats() []*LiveStats {
+	stats := []*LiveStats{}
+
+	m.cache.Range(func(_ string, value *Movie) bool {
+		if s := value.LiveStats(); s != nil {
+			stats = append(stats, s)
+		}
+
+		return true
+	})
+
+	slices.SortFunc(stats, func(a, b *LiveStats) int {
+		return strings.Compare(a.MovieID, b.MovieID)
+	})
+
+	return stats
+}
+
 func (m *movies) Update(movieID string, movie *model.MovieBase) error {
 	mv, err := db.GetMovieByID(m.roomID, movieID)
 	if err != nil {
@@ -108,9 +136,31 @@ func (m *movies) Update(movieID string, movie *model.MovieBase) error {
 		_ = mm.Close()
 	}
 
+	publishMoviesEvent(m.roomID, mv.ID)
+
+	return nil
+}
+
+// RevokePublishKey rejects the issued publish keys of the movie and kicks
+// its publishers on every node, the viewers stay in the channel
+func (m *movies) RevokePublishKey(id string) error {
+	err := db.IncrMoviePublishKeyVersion(m.roomID, id)
+	if err != nil {
+		return err
+	}
+
+	m.KickPublishers(id)
+	publishKickPublishersEvent(m.roomID, id)
+
 	return nil
 }
 
+func (m *movies) KickPublishers(id string) {
+	if mm, ok := m.cache.Load(id); ok {
+		mm.KickPublishers()
+	}
+}
+
 func (m *movies) Clear() error {
 	return m.DeleteMovieByParentID("")
 }
@@ -135,6 +185,7 @@ func (m *movies) DeleteMovieByParentID(parentID string) error {
 	}
 
 	m.DeleteMovieAndChiledCache(parentID)
+	publishMoviesEvent(m.roomID, parentID)
 
 	return nil
 }
@@ -146,6 +197,7 @@ func (m *movies) DeleteMovieByID(id string) error {
 	}
 
 	m.DeleteMovieAndChiledCache(id)
+	publishMoviesEvent(m.roomID, id)
 
 	return nil
 }
@@ -191,6 +243,7 @@ func (m *movies) DeleteMoviesByID(ids []string) error {
 	}
 
 	m.DeleteMovieAndChiledCache(ids...)
+	publishMoviesEvent(m.roomID, ids...)
 
 	return nil
 }
@@ -223,11 +276,13 @@ func (m *movies) SwapMoviePositions(id1, id2 string) error {
 }
 
 func (m *movies) GetMoviesWithPage(
+	ctx context.Context,
 	keyword string,
 	page, pageSize int,
 	parentID string,
 ) ([]*model.Movie, int64, error) {
 	scopes := []func(*gorm.DB) *gorm.DB{
+		db.WithContext(ctx),
 		db.WithParentMovieID(parentID),
 	}
 	if keyword != "" {
@@ -236,7 +291,8 @@ func (m *movies) GetMoviesWithPage(
 
 	count, err := db.GetMoviesCountByRoomID(
 		m.roomID,
-		append(scopes, db.Paginate(page, pageSize))...)
+		append(scopes, db.Paginate(page, pageSize))...,
+	)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -249,6 +305,37 @@ func (m *movies) GetMoviesWithPage(
 	return movies, count, nil
 }
 
+// Playlist returns the movies in playing order, static folders are
+// expanded in place and left out, dynamic folders are kept as one entry.
+func (m *movies) Playlist() ([]*model.Movie, error) {
+	all, err := db.GetMoviesByRoomID(m.roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[model.EmptyNullString][]*model.Movie)
+	for _, mv := range all {
+		children[mv.ParentID] = append(children[mv.ParentID], mv)
+	}
+
+	playlist := make([]*model.Movie, 0, len(all))
+
+	var walk func(parentID model.EmptyNullString)
+	walk = func(parentID model.EmptyNullString) {
+		for _, mv := range children[parentID] {
+			if mv.IsFolder && !mv.IsDynamicFolder() {
+				walk(model.EmptyNullString(mv.ID))
+				continue
+			}
+
+			playlist = append(playlist, mv)
+		}
+	}
+	walk("")
+
+	return playlist, nil
+}
+
 // IsParentOf check if parentID is the parent of id
 func (m *movies) IsParentOf(id, parentID string) (bool, error) {
 	if parentID == "" {
@@ -0,0 +1,222 @@
+package op
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/model"
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+// the end of a movie is accepted this many seconds early,
+// clients rarely report the exact duration as the last position
+const movieEndTolerance = 5
+
+type autoAdvance struct {
+	timer *time.Timer
+	// serializes advancing so that one end only advances once
+	advanceLock sync.Mutex
+	timerLock   sync.Mutex
+}
+
+func checkPlayMode(v any) error {
+	var mode model.PlayMode
+
+	switch m := v.(type) {
+	case string:
+		mode = model.PlayMode(m)
+	case model.PlayMode:
+		mode = m
+	default:
+		return fmt.Errorf("invalid play mode: %v", v)
+	}
+
+	switch mode {
+	case model.PlayModeOff,
+		model.PlayModeSequential,
+		model.PlayModeRepeatOne,
+		model.PlayModeRepeatAll,
+		model.PlayModeShuffle:
+		return nil
+	default:
+		return fmt.Errorf("invalid play mode: %s", mode)
+	}
+}
+
+func (r *Room) autoAdvanceEnabled() bool {
+	return r.Settings.PlayMode != "" && r.Settings.PlayMode != model.PlayModeOff
+}
+
+// SetCurrentDuration records the duration of the current movie reported by a client.
+func (r *Room) SetCurrentDuration(movieID string, duration float64) {
+	if duration <= 0 || !r.current.SetDuration(movieID, duration) {
+		return
+	}
+
+	r.scheduleAutoAdvance()
+}
+
+// scheduleAutoAdvance arms a timer for the end of the current movie,
+// it must be called again whenever the current status changes.
+func (r *Room) scheduleAutoAdvance() {
+	r.autoAdvance.timerLock.Lock()
+	defer r.autoAdvance.timerLock.Unlock()
+
+	if r.autoAdvance.timer != nil {
+		r.autoAdvance.timer.Stop()
+		r.autoAdvance.timer = nil
+	}
+
+	if !r.autoAdvanceEnabled() {
+		return
+	}
+
+	c := r.current.Current()
+	if c.Movie.ID == "" || c.Movie.IsLive || c.Movie.Duration <= 0 ||
+		!c.Status.IsPlaying || c.Status.PlaybackRate <= 0 {
+		return
+	}
+
+	remaining := max((c.Movie.Duration-c.Status.CurrentTime)/c.Status.PlaybackRate, 0)
+	movieID := c.Movie.ID
+
+	r.autoAdvance.timer = time.AfterFunc(
+		time.Duration(remaining*float64(time.Second)),
+		func() {
+			if err := r.advanceCurrentMovie(movieID, true); err != nil {
+				log.Errorf("room %s auto advance failed: %v", r.ID, err)
+			}
+		},
+	)
+}
+
+func (r *Room) stopAutoAdvance() {
+	r.autoAdvance.timerLock.Lock()
+	defer r.autoAdvance.timerLock.Unlock()
+
+	if r.autoAdvance.timer != nil {
+		r.autoAdvance.timer.Stop()
+		r.autoAdvance.timer = nil
+	}
+}
+
+// EndCurrentMovie handles a client reporting that movieID finished playing.
+func (r *Room) EndCurrentMovie(movieID string) error {
+	if !r.autoAdvanceEnabled() {
+		return nil
+	}
+
+	c := r.current.Current()
+	if c.Movie.ID != movieID || c.Movie.IsLive {
+		return nil
+	}
+
+	// ignore clients that end early when the duration is known
+	if c.Movie.Duration > 0 && c.Status.CurrentTime < c.Movie.Duration-movieEndTolerance {
+		return nil
+	}
+
+	return r.AdvanceCurrentMovie(movieID)
+}
+
+// AdvanceCurrentMovie moves to the next movie following the play mode,
+// it does nothing if the current movie is no longer movieID.
+func (r *Room) AdvanceCurrentMovie(movieID string) error {
+	return r.advanceCurrentMovie(movieID, false)
+}
+
+// advanceCurrentMovie with atEnd also requires the movie to still be playing
+// at its end, the timer may fire after a pause or seek applied by another node
+func (r *Room) advanceCurrentMovie(movieID string, atEnd bool) error {
+	r.autoAdvance.advanceLock.Lock()
+	defer r.autoAdvance.advanceLock.Unlock()
+
+	c := r.current.Current()
+	if c.Movie.ID != movieID {
+		return nil
+	}
+
+	if atEnd && (!c.Status.IsPlaying ||
+		c.Status.CurrentTime < c.Movie.Duration-movieEndTolerance) {
+		return nil
+	}
+
+	next, subPath, err := r.nextMovie(c.Movie)
+	if err != nil {
+		return err
+	}
+
+	if next == nil {
+		return nil
+	}
+
+	if err := r.SetCurrentMovie(next.ID, subPath, true); err != nil {
+		return err
+	}
+
+	return r.Broadcast(&pb.Message{
+		Type:      pb.MessageType_CURRENT,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+var errEmptyPlaylist = errors.New("playlist is empty")
+
+// nextMovie returns nil when the playlist has ended.
+// The items of a dynamic folder are listed by the vendor and are not known
+// here, so a movie played from a dynamic folder advances to the entry after
+// the folder instead of the next item inside it.
+func (r *Room) nextMovie(current model.CurrentMovie) (*model.Movie, string, error) {
+	playlist, err := r.movies.Playlist()
+	if err != nil {
+		return nil, "", err
+	}
+
+	idx := slices.IndexFunc(playlist, func(m *model.Movie) bool {
+		return m.ID == current.ID
+	})
+
+	if r.Settings.PlayMode == model.PlayModeRepeatOne && idx != -1 {
+		return playlist[idx], current.SubPath, nil
+	}
+
+	// dynamic folders can only be played through a sub path picked by a member
+	candidates := make([]int, 0, len(playlist))
+	for i, m := range playlist {
+		if !m.IsDynamicFolder() {
+			candidates = append(candidates, i)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, "", errEmptyPlaylist
+	}
+
+	switch r.Settings.PlayMode {
+	case model.PlayModeShuffle:
+		if len(candidates) > 1 {
+			candidates = slices.DeleteFunc(candidates, func(i int) bool { return i == idx })
+		}
+
+		return playlist[candidates[rand.IntN(len(candidates))]], "", nil
+	case model.PlayModeSequential, model.PlayModeRepeatAll, model.PlayModeRepeatOne:
+		for _, i := range candidates {
+			if i > idx {
+				return playlist[i], "", nil
+			}
+		}
+
+		if r.Settings.PlayMode == model.PlayModeSequential {
+			return nil, "", nil
+		}
+
+		return playlist[candidates[0]], "", nil
+	default:
+		return nil, "", nil
+	}
+}
@@ -0,0 +1,103 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+// newPlaylistRoom returns a sequential room playing a, folder and b in order,
+// folder being a dynamic alist folder
+func newPlaylistRoom(t *testing.T) (r *Room, a, folder, b *model.Movie) {
+	t.Helper()
+
+	initTestDB(t)
+
+	userE, err := CreateUser("player", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roomE, err := userE.Value().CreateRoom("playlist", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r = roomE.Value()
+	r.Settings.PlayMode = model.PlayModeSequential
+
+	a = &model.Movie{MovieBase: model.MovieBase{Name: "a", URL: "http://example.com/a.mp4"}}
+	folder = &model.Movie{MovieBase: model.MovieBase{
+		Name:     "folder",
+		IsFolder: true,
+		VendorInfo: model.VendorInfo{
+			Vendor: model.VendorAlist,
+			Alist:  &model.AlistStreamingInfo{Path: "/folder"},
+		},
+	}}
+	b = &model.Movie{MovieBase: model.MovieBase{Name: "b", URL: "http://example.com/b.mp4"}}
+
+	for _, m := range []*model.Movie{a, folder, b} {
+		m.CreatorID = userE.Value().ID
+		if err := r.AddMovie(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return r, a, folder, b
+}
+
+func TestNextMovieDynamicFolder(t *testing.T) {
+	r, a, folder, b := newPlaylistRoom(t)
+
+	for _, current := range []model.CurrentMovie{
+		{ID: a.ID},
+		// the items of the folder are not known, the folder is left
+		{ID: folder.ID, SubPath: "/folder/1.mp4"},
+	} {
+		next, subPath, err := r.nextMovie(current)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if next == nil || next.ID != b.ID || subPath != "" {
+			t.Fatalf("next of %+v: %v %q, want %s", current, next, subPath, b.ID)
+		}
+	}
+}
+
+func TestAutoAdvanceRechecksStatus(t *testing.T) {
+	r, a, _, b := newPlaylistRoom(t)
+
+	r.current.SetMovie(model.CurrentMovie{ID: a.ID}, true)
+	r.current.SetDuration(a.ID, 100)
+
+	// paused or seeked back by another node before the timer fired
+	for _, status := range []struct {
+		playing bool
+		seek    float64
+	}{
+		{false, 100},
+		{true, 10},
+	} {
+		r.current.SetStatus(status.playing, status.seek, 1, 0)
+
+		if err := r.advanceCurrentMovie(a.ID, true); err != nil {
+			t.Fatal(err)
+		}
+
+		if id := r.current.CurrentMovie().ID; id != a.ID {
+			t.Fatalf("advanced to %s while playing %v at %v", id, status.playing, status.seek)
+		}
+	}
+
+	r.current.SetStatus(true, 100, 1, 0)
+
+	if err := r.advanceCurrentMovie(a.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if id := r.current.CurrentMovie().ID; id != b.ID {
+		t.Fatalf("current %s at the end, want %s", id, b.ID)
+	}
+}
@@ -0,0 +1,80 @@
+package op
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/rtmp"
+	"github.com/zijiren233/livelib/av"
+)
+
+var ErrPublishKeyRevoked = errors.New("publish key has been revoked")
+
+// PublishMovie checks a publish key against the room and returns its movie,
+// every ingest protocol authenticates publishers this way.
+func PublishMovie(roomID, publishKey string) (*Movie, error) {
+	roomE, err := LoadOrInitRoomByID(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	room := roomE.Value()
+
+	if room.IsBanned() {
+		return nil, fmt.Errorf("room %s is banned", room.ID)
+	}
+
+	if room.IsPending() {
+		return nil, fmt.Errorf("room %s is pending, need admin approval", room.ID)
+	}
+
+	claims, err := rtmp.AuthRtmpPublish(publishKey)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := room.GetMovieByID(claims.MovieID)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := m.LoadPublishKeyVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Version != version {
+		return nil, ErrPublishKeyRevoked
+	}
+
+	return m, nil
+}
+
+// LoadPublishKeyVersion reads the version from the database, the cached
+// movie is not updated when a key is revoked on another node
+func (m *Movie) LoadPublishKeyVersion() (uint32, error) {
+	return db.GetMoviePublishKeyVersion(m.RoomID, m.ID)
+}
+
+// Publish pushes r into the channel of the movie until the publisher leaves,
+// r is closed when the publisher is kicked
+func (m *Movie) Publish(r av.ReadCloser) error {
+	c, err := m.Channel()
+	if err != nil {
+		return err
+	}
+
+	m.publishers.Store(r, struct{}{})
+	defer m.publishers.Delete(r)
+
+	return c.PushStart(r)
+}
+
+// KickPublishers disconnects the current publishers of the movie
+func (m *Movie) KickPublishers() {
+	m.publishers.Range(func(r av.ReadCloser, _ struct{}) bool {
+		_ = r.Close()
+		return true
+	})
+}
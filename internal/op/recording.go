@@ -0,0 +1,605 @@
+package op
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/cmd/flags"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/zijiren233/livelib/av"
+	"github.com/zijiren233/livelib/container/flv"
+	"github.com/zijiren233/livelib/protocol/hls"
+	rtmps "github.com/zijiren233/livelib/server"
+)
+
+var (
+	ErrRecordQuotaExceeded = errors.New("recording quota exceeded")
+	ErrRecordingInProgress = errors.New("recording is in progress")
+	errRecordLimit         = errors.New("recording reached its size or duration limit")
+)
+
+const (
+	recordRetryInterval = time.Second
+	// the size of a recording is stored and its quota checked this often
+	recordHeartbeat = time.Second * 5
+	// a recording not stored for this long was left by a stopped node
+	recordStaleAfter = time.Minute
+)
+
+// RecordingDir is where the files of a recording are kept
+func RecordingDir(roomID, recordingID string) string {
+	return filepath.Join(flags.Global.DataDir, "recordings", roomID, recordingID)
+}
+
+func removeRoomRecordings(roomID string) {
+	if err := os.RemoveAll(filepath.Join(flags.Global.DataDir, "recordings", roomID)); err != nil {
+		log.Errorf("remove recordings of room %s error: %v", roomID, err)
+	}
+}
+
+// startRecord records every publication of the channel until the movie drops it
+func (m *Movie) startRecord(c *rtmps.Channel) {
+	if m.Record == "" {
+		return
+	}
+
+	go m.record(c)
+}
+
+func (m *Movie) record(c *rtmps.Channel) {
+	for m.channel.Load() == c && !c.Closed() {
+		if !settings.RecordEnable.Get() {
+			time.Sleep(recordRetryInterval)
+			continue
+		}
+
+		r := newRecorder(m)
+		if err := c.AddPlayer(r); err != nil {
+			time.Sleep(recordRetryInterval)
+			continue
+		}
+
+		<-r.done
+
+		if err := m.finishRecording(r); err != nil {
+			log.Errorf("finish recording of movie %s error: %v", m.ID, err)
+		}
+
+		// do not start another recording before this publication ends
+		if r.stopped {
+			w := newDiscardPlayer()
+			if err := c.AddPlayer(w); err == nil {
+				<-w.done
+			}
+		}
+	}
+}
+
+// remainingRecordQuota returns the bytes the room may still record, -1 is unlimited
+func remainingRecordQuota(roomID string) (int64, error) {
+	remaining := int64(-1)
+
+	if quota := settings.RecordRoomQuota.Get() << 20; quota > 0 {
+		used, err := db.GetRecordingsSize(roomID)
+		if err != nil {
+			return 0, err
+		}
+
+		remaining = max(quota-used, 0)
+	}
+
+	if quota := settings.RecordTotalQuota.Get() << 20; quota > 0 {
+		used, err := db.GetRecordingsSize("")
+		if err != nil {
+			return 0, err
+		}
+
+		if left := max(quota-used, 0); remaining < 0 || left < remaining {
+			remaining = left
+		}
+	}
+
+	return remaining, nil
+}
+
+// recorder is a channel player writing one publication to disk,
+// the recording is only created once the first packet arrives.
+type recorder struct {
+	movie     *Movie
+	recording *model.Recording
+	w         av.WriteCloser
+	file      *os.File
+	hls       *hlsRecorder
+	deadline  time.Time
+	done      chan struct{}
+	size      atomic.Int64
+	limit     atomic.Int64
+	firstTS   uint32
+	lastTS    uint32
+	mu        sync.Mutex
+	hasTS     bool
+	closed    bool
+	// stopped by a limit or an error rather than by the end of the publication
+	stopped bool
+}
+
+func newRecorder(m *Movie) *recorder {
+	return &recorder{
+		movie: m,
+		done:  make(chan struct{}),
+	}
+}
+
+func (r *recorder) Write(p *av.Packet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return av.ErrClosed
+	}
+
+	if r.recording == nil {
+		if err := r.start(); err != nil {
+			log.Errorf("start recording of movie %s error: %v", r.movie.ID, err)
+			r.stopped = true
+			return err
+		}
+	}
+
+	if limit := r.limit.Load(); (limit >= 0 && r.size.Load() >= limit) ||
+		(!r.deadline.IsZero() && time.Now().After(r.deadline)) {
+		r.stopped = true
+		return errRecordLimit
+	}
+
+	if !p.IsMetadata {
+		if !r.hasTS {
+			r.firstTS = p.TimeStamp
+			r.hasTS = true
+		}
+
+		r.lastTS = p.TimeStamp
+	}
+
+	if err := r.w.Write(p); err != nil {
+		r.stopped = true
+		return err
+	}
+
+	return nil
+}
+
+func (r *recorder) start() error {
+	limit, err := remainingRecordQuota(r.movie.RoomID)
+	if err != nil {
+		return err
+	}
+
+	if limit == 0 {
+		return ErrRecordQuotaExceeded
+	}
+
+	r.limit.Store(limit)
+
+	if d := settings.RecordMaxDuration.Get(); d > 0 {
+		r.deadline = time.Now().Add(time.Duration(d) * time.Minute)
+	}
+
+	recording := &model.Recording{
+		RoomID:        r.movie.RoomID,
+		SourceMovieID: r.movie.ID,
+		CreatorID:     r.movie.CreatorID,
+		Name:          fmt.Sprintf("%s %s", r.movie.Name, time.Now().Format("2006-01-02 15:04:05")),
+		Format:        r.movie.Record,
+		Status:        model.RecordingStatusRecording,
+	}
+	if err := db.CreateRecording(recording); err != nil {
+		return err
+	}
+
+	r.recording = recording
+
+	go r.heartbeat()
+
+	dir := RecordingDir(recording.RoomID, recording.ID)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	switch recording.Format {
+	case model.RecordFormatHLS:
+		src := hls.NewSource(hls.WithGenTsNameFunc(genTSName))
+		r.hls = newHLSRecorder(src, dir, &r.size)
+		r.w = src
+
+		go r.hls.run()
+	default:
+		f, err := os.Create(filepath.Join(dir, model.RecordingFLVFile))
+		if err != nil {
+			return err
+		}
+
+		r.file = f
+		r.w = flv.NewWriter(&countingWriter{w: f, n: &r.size})
+	}
+
+	return nil
+}
+
+// heartbeat stores the size of the recording so that the quota of the other
+// recordings counts it, and lowers the limit once they used the quota
+func (r *recorder) heartbeat() {
+	t := time.NewTicker(recordHeartbeat)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-t.C:
+		}
+
+		size := r.size.Load()
+		if err := db.UpdateRecordingSize(r.recording.ID, size); err != nil {
+			log.Errorf("update recording %s size error: %v", r.recording.ID, err)
+			continue
+		}
+
+		remaining, err := remainingRecordQuota(r.movie.RoomID)
+		if err != nil {
+			log.Errorf("get recording quota of room %s error: %v", r.movie.RoomID, err)
+			continue
+		}
+
+		if remaining >= 0 {
+			r.limit.Store(size + remaining)
+		}
+	}
+}
+
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return av.ErrClosed
+	}
+
+	r.closed = true
+	defer close(r.done)
+
+	if r.w != nil {
+		_ = r.w.Close()
+	}
+
+	if r.file != nil {
+		return r.file.Close()
+	}
+
+	return nil
+}
+
+func (r *recorder) duration() float64 {
+	if r.hls != nil {
+		return r.hls.duration()
+	}
+
+	return float64(r.lastTS-r.firstTS) / 1000
+}
+
+// finishRecording adds the recording to the movie list of the room
+func (m *Movie) finishRecording(r *recorder) error {
+	recording := r.recording
+	if recording == nil {
+		return nil
+	}
+
+	var err error
+	if r.hls != nil {
+		<-r.hls.done
+		err = r.hls.err
+	}
+
+	now := time.Now()
+	recording.EndedAt = &now
+	recording.Size = r.size.Load()
+	recording.Duration = r.duration()
+
+	if err != nil || recording.Size == 0 {
+		_ = os.RemoveAll(RecordingDir(recording.RoomID, recording.ID))
+		if dErr := db.DeleteRecording(recording.RoomID, recording.ID); dErr != nil {
+			return dErr
+		}
+
+		return err
+	}
+
+	recording.Status = model.RecordingStatusFinished
+	if err := db.SaveRecording(recording); err != nil {
+		// the room may have been deleted while recording
+		_ = os.RemoveAll(RecordingDir(recording.RoomID, recording.ID))
+		return err
+	}
+
+	roomE, err := LoadOrInitRoomByID(recording.RoomID)
+	if err != nil {
+		return err
+	}
+
+	room := roomE.Value()
+
+	movie := &model.Movie{
+		CreatorID: recording.CreatorID,
+		MovieBase: model.MovieBase{
+			Name:        recording.Name,
+			Type:        recording.Format.MovieType(),
+			RecordingID: recording.ID,
+		},
+	}
+	if err := room.AddMovie(movie); err != nil {
+		return err
+	}
+
+	recording.MovieID = movie.ID
+	if err := db.SaveRecording(recording); err != nil {
+		return err
+	}
+
+	return room.Broadcast(&pb.Message{
+		Type: pb.MessageType_MOVIES,
+	})
+}
+
+// RunRecordingReconciler marks the recordings left in progress by a stopped
+// node as failed until ctx is done, so that they can be deleted.
+func RunRecordingReconciler(ctx context.Context) {
+	t := time.NewTicker(recordStaleAfter)
+	defer t.Stop()
+
+	for {
+		reconcileRecordings(time.Now().Add(-recordStaleAfter))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func reconcileRecordings(before time.Time) {
+	recordings, err := db.GetStaleRecordings(before)
+	if err != nil {
+		log.Errorf("get stale recordings error: %v", err)
+		return
+	}
+
+	for _, recording := range recordings {
+		// the files are only found on the node that recorded it
+		size := recording.Size
+		if s, err := dirSize(RecordingDir(recording.RoomID, recording.ID)); err == nil && s > 0 {
+			size = s
+		}
+
+		failed, err := db.FailStaleRecording(recording.ID, before, size)
+		if err != nil {
+			log.Errorf("fail stale recording %s error: %v", recording.ID, err)
+			continue
+		}
+
+		if failed {
+			log.Warnf("recording %s of room %s was interrupted", recording.ID, recording.RoomID)
+		}
+	}
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
+
+func (r *Room) GetRecordings() ([]*model.Recording, error) {
+	return db.GetRecordingsByRoomID(r.ID)
+}
+
+func (r *Room) GetRecording(id string) (*model.Recording, error) {
+	return db.GetRecording(r.ID, id)
+}
+
+// DeleteRecording removes the recording, its files and its movie
+func (r *Room) DeleteRecording(id string) error {
+	recording, err := r.GetRecording(id)
+	if err != nil {
+		return err
+	}
+
+	if recording.Status == model.RecordingStatusRecording {
+		return ErrRecordingInProgress
+	}
+
+	if recording.MovieID != "" {
+		if _, err := r.GetMovieByID(recording.MovieID); err == nil {
+			if err := r.DeleteMovieByID(recording.MovieID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.RemoveAll(RecordingDir(r.ID, id)); err != nil {
+		return err
+	}
+
+	return db.DeleteRecording(r.ID, id)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// hlsRecorder persists the segments cut by an hls source and writes a vod playlist at the end,
+// the source only keeps its last segments, so they are collected every second.
+type hlsRecorder struct {
+	err      error
+	src      *hls.Source
+	size     *atomic.Int64
+	done     chan struct{}
+	written  map[string]struct{}
+	dir      string
+	segments []*hls.TSItem
+}
+
+func newHLSRecorder(src *hls.Source, dir string, size *atomic.Int64) *hlsRecorder {
+	return &hlsRecorder{
+		src:     src,
+		dir:     dir,
+		size:    size,
+		done:    make(chan struct{}),
+		written: make(map[string]struct{}),
+	}
+}
+
+func (h *hlsRecorder) run() {
+	defer close(h.done)
+
+	sent := make(chan struct{})
+
+	go func() {
+		defer close(sent)
+
+		_ = h.src.SendPacket(context.Background())
+	}()
+
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-sent:
+			if h.err = h.collect(); h.err == nil {
+				h.err = h.writeIndex()
+			}
+
+			return
+		case <-t.C:
+			if err := h.collect(); err != nil {
+				log.Errorf("write recording segment error: %v", err)
+			}
+		}
+	}
+}
+
+func (h *hlsRecorder) collect() error {
+	items, err := newSegments(h.src, h.written)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := os.WriteFile(filepath.Join(h.dir, item.TsName+".ts"), item.Data, 0o644); err != nil {
+			return err
+		}
+
+		h.written[item.TsName] = struct{}{}
+		h.size.Add(int64(len(item.Data)))
+		h.segments = append(h.segments, &hls.TSItem{
+			TsName:   item.TsName,
+			SeqNum:   item.SeqNum,
+			Duration: item.Duration,
+		})
+	}
+
+	return nil
+}
+
+func (h *hlsRecorder) duration() float64 {
+	var ms int64
+	for _, s := range h.segments {
+		ms += s.Duration
+	}
+
+	return float64(ms) / 1000
+}
+
+func (h *hlsRecorder) writeIndex() error {
+	var maxDuration int64
+	for _, s := range h.segments {
+		maxDuration = max(maxDuration, s.Duration)
+	}
+
+	b := bytes.NewBuffer(nil)
+	fmt.Fprintf(
+		b,
+		"#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:0\n",
+		maxDuration/1000+1,
+	)
+
+	for _, s := range h.segments {
+		fmt.Fprintf(b, "#EXTINF:%.3f,\n%s.ts\n", float64(s.Duration)/1000, s.TsName)
+	}
+
+	b.WriteString("#EXT-X-ENDLIST\n")
+
+	return os.WriteFile(filepath.Join(h.dir, model.RecordingHLSIndex), b.Bytes(), 0o644)
+}
+
+// discardPlayer waits for the end of a publication
+type discardPlayer struct {
+	done chan struct{}
+	once sync.Once
+}
+
+func newDiscardPlayer() *discardPlayer {
+	return &discardPlayer{done: make(chan struct{})}
+}
+
+func (d *discardPlayer) Write(_ *av.Packet) error {
+	return nil
+}
+
+func (d *discardPlayer) Close() error {
+	d.once.Do(func() {
+		close(d.done)
+	})
+
+	return nil
+}
@@ -4,24 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/settings"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/synctv-org/synctv/utils"
 	"github.com/zijiren233/gencontainer/rwmap"
 	rtmps "github.com/zijiren233/livelib/server"
 	"github.com/zijiren233/stream"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type Room struct {
-	current *current
-	hub     atomic.Pointer[Hub]
-	movies  *movies
-	members rwmap.RWMap[string, *model.RoomMember]
+	current     *current
+	hub         atomic.Pointer[Hub]
+	movies      *movies
+	members     rwmap.RWMap[string, *model.RoomMember]
+	autoAdvance autoAdvance
+	// messages stored since the chat history was last pruned
+	unprunedChatMessages atomic.Int64
+	// serializes the updates of the settings
+	settingsMu sync.Mutex
 	model.Room
 }
 
@@ -43,6 +53,10 @@ func (r *Room) HubIsNotInited() bool {
 }
 
 func (r *Room) ViewerCount() int64 {
+	return r.localViewerCount() + remoteViewerCount(r.ID)
+}
+
+func (r *Room) localViewerCount() int64 {
 	if r.HubIsNotInited() {
 		return 0
 	}
@@ -50,6 +64,11 @@ func (r *Room) ViewerCount() int64 {
 }
 
 func (r *Room) KickUser(userID string) error {
+	publishRoomEvent(clusterEventKick, r.ID, userID)
+	return r.kickUserLocal(userID)
+}
+
+func (r *Room) kickUserLocal(userID string) error {
 	if r.HubIsNotInited() {
 		return nil
 	}
@@ -57,6 +76,11 @@ func (r *Room) KickUser(userID string) error {
 }
 
 func (r *Room) Broadcast(data Message, conf ...BroadcastConf) error {
+	publishBroadcast(r.ID, data, conf...)
+	return r.broadcastLocal(data, conf...)
+}
+
+func (r *Room) broadcastLocal(data Message, conf ...BroadcastConf) error {
 	if r.HubIsNotInited() {
 		return nil
 	}
@@ -68,6 +92,11 @@ func (r *Room) SendToUser(user *User, data Message) error {
 }
 
 func (r *Room) SendToUserWithID(userID string, data Message) error {
+	publishSendTo(r.ID, userID, "", data)
+	return r.sendToUserLocal(userID, data)
+}
+
+func (r *Room) sendToUserLocal(userID string, data Message) error {
 	if r.HubIsNotInited() {
 		return nil
 	}
@@ -75,16 +104,202 @@ func (r *Room) SendToUserWithID(userID string, data Message) error {
 }
 
 func (r *Room) SendToConnID(userID, connID string, data Message) error {
+	if !r.HubIsNotInited() {
+		if _, ok := r.lazyInitHub().GetClientByConnID(userID, connID); ok {
+			return r.sendToConnIDLocal(userID, connID, data)
+		}
+	}
+
+	// the connection may live on another node
+	publishSendTo(r.ID, userID, connID, data)
+
+	return nil
+}
+
+func (r *Room) sendToConnIDLocal(userID, connID string, data Message) error {
 	if r.HubIsNotInited() {
 		return nil
 	}
 	return r.lazyInitHub().SendToConnID(userID, connID, data)
 }
 
+// invalidateMember drops the cached member on every node.
+func (r *Room) invalidateMember(userID string) {
+	r.members.Delete(userID)
+	publishRoomEvent(clusterEventMember, r.ID, userID)
+}
+
+const (
+	maxChatHistoryMaxCount    = 10000
+	maxChatHistoryReplayCount = 100
+	// the chat history is pruned once every chatPruneBatch messages, so it
+	// may exceed the max count by up to that many messages
+	chatPruneBatch = 50
+)
+
+func checkChatHistoryCount(key string, v any, maxCount int64) error {
+	var count int64
+
+	switch c := v.(type) {
+	case int:
+		count = int64(c)
+	case int64:
+		count = c
+	case float64:
+		if c != float64(int64(c)) {
+			return fmt.Errorf("invalid %s: %v", key, v)
+		}
+		count = int64(c)
+	default:
+		return fmt.Errorf("invalid %s: %v", key, v)
+	}
+
+	if count < 0 || count > maxCount {
+		return fmt.Errorf("%s must be between 0 and %d", key, maxCount)
+	}
+
+	return nil
+}
+
+func checkChatHistorySettings(settings map[string]any) error {
+	if v, ok := settings["chat_history_max_count"]; ok {
+		if err := checkChatHistoryCount(
+			"chat_history_max_count",
+			v,
+			maxChatHistoryMaxCount,
+		); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := settings["chat_history_replay_count"]; ok {
+		if err := checkChatHistoryCount(
+			"chat_history_replay_count",
+			v,
+			maxChatHistoryReplayCount,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Room) chatHistoryEnabled() bool {
+	return r.Settings.ChatHistoryMaxCount > 0
+}
+
+func (r *Room) AddChatMessage(user *User, content string) (*model.ChatMessage, error) {
+	message := &model.ChatMessage{
+		RoomID:   r.ID,
+		UserID:   user.ID,
+		Username: user.Username,
+		Content:  content,
+	}
+
+	if !r.chatHistoryEnabled() {
+		message.ID = utils.SortUUID()
+		message.CreatedAt = time.Now()
+
+		return message, nil
+	}
+
+	if err := db.CreateChatMessage(message); err != nil {
+		return nil, err
+	}
+
+	if r.unprunedChatMessages.Add(1) >= chatPruneBatch {
+		r.unprunedChatMessages.Store(0)
+
+		if err := db.PruneChatMessages(r.ID, int(r.Settings.ChatHistoryMaxCount)); err != nil {
+			logrus.Errorf("prune room %s chat messages failed: %v", r.ID, err)
+		}
+	}
+
+	return message, nil
+}
+
+func (r *Room) GetChatMessagesWithPage(
+	ctx context.Context,
+	page, pageSize int,
+	before time.Time,
+) ([]*model.ChatMessage, int64, error) {
+	if !r.chatHistoryEnabled() {
+		return []*model.ChatMessage{}, 0, nil
+	}
+
+	scopes := []func(*gorm.DB) *gorm.DB{db.WithContext(ctx)}
+	if !before.IsZero() {
+		scopes = append(scopes, db.WhereCreatedAtBefore(before))
+	}
+
+	total, err := db.GetChatMessagesCount(r.ID, scopes...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	messages, err := db.GetChatMessages(
+		r.ID,
+		append(scopes, db.OrderByCreatedAtDesc, db.Paginate(page, pageSize))...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+// LatestChatMessages returns the messages replayed to a newly connected client
+func (r *Room) LatestChatMessages() ([]*model.ChatMessage, error) {
+	if !r.chatHistoryEnabled() || r.Settings.ChatHistoryReplayCount <= 0 {
+		return nil, nil
+	}
+
+	return db.GetLatestChatMessages(
+		r.ID,
+		int(min(r.Settings.ChatHistoryReplayCount, maxChatHistoryReplayCount)),
+	)
+}
+
+var ErrChatHistoryDisabled = errors.New("chat history is disabled")
+
+func (r *Room) DeleteChatMessage(id string) error {
+	if !r.chatHistoryEnabled() {
+		// the message was never stored, clients only need to be notified
+		return nil
+	}
+
+	return db.DeleteChatMessage(r.ID, id)
+}
+
+func (r *Room) SetChatMessagePinned(id string, pinned bool) (*model.ChatMessage, error) {
+	if !r.chatHistoryEnabled() {
+		return nil, ErrChatHistoryDisabled
+	}
+
+	if err := db.SetChatMessagePinned(r.ID, id, pinned); err != nil {
+		return nil, err
+	}
+
+	return db.GetChatMessage(r.ID, id)
+}
+
+func (r *Room) PinnedChatMessages() ([]*model.ChatMessage, error) {
+	if !r.chatHistoryEnabled() {
+		return nil, nil
+	}
+
+	return db.GetPinnedChatMessages(r.ID)
+}
+
 func (r *Room) GetChannel(channelName string) (*rtmps.Channel, error) {
 	return r.movies.GetChannel(channelName)
 }
 
+func (r *Room) LiveStats() []*LiveStats {
+	return r.movies.LiveStats()
+}
+
 func (r *Room) close() {
 	if h := r.hub.Load(); h != nil {
 		if r.hub.CompareAndSwap(h, nil) {
@@ -92,6 +307,7 @@ func (r *Room) close() {
 		}
 	}
 
+	r.stopAutoAdvance()
 	r.movies.Close()
 	r.members.Clear()
 }
@@ -363,9 +579,14 @@ func (r *Room) SetPassword(password string) error {
 		}
 	}
 
+	if err := db.SetRoomHashedPassword(r.ID, hashedPassword); err != nil {
+		return err
+	}
+
 	r.HashedPassword = hashedPassword
+	publishRoomEvent(clusterEventRoom, r.ID, "")
 
-	return db.SetRoomHashedPassword(r.ID, hashedPassword)
+	return nil
 }
 
 func (r *Room) checkCanModifyMovie(id string) error {
@@ -458,6 +679,10 @@ func (r *Room) ClearMoviesByParentID(parentID string) error {
 	return r.movies.DeleteMovieByParentID(parentID)
 }
 
+func (r *Room) RevokePublishKey(id string) error {
+	return r.movies.RevokePublishKey(id)
+}
+
 func (r *Room) GetMovieByID(id string) (*Movie, error) {
 	return r.movies.GetMovieByID(id)
 }
@@ -492,6 +717,8 @@ func (r *Room) CheckCurrentExpired(ctx context.Context, expireID uint64) (bool,
 }
 
 func (r *Room) SetCurrentMovie(movieID, subPath string, play bool) error {
+	defer r.scheduleAutoAdvance()
+
 	currentMovie, err := r.LoadCurrentMovie()
 	if err != nil {
 		if !errors.Is(err, ErrNoCurrentMovie) {
@@ -524,9 +751,10 @@ func (r *Room) SetCurrentMovie(movieID, subPath string, play bool) error {
 	}
 
 	r.current.SetMovie(model.CurrentMovie{
-		ID:      m.ID,
-		IsLive:  m.Live,
-		SubPath: subPath,
+		ID:           m.ID,
+		IsLive:       m.Live,
+		CanTimeShift: m.canTimeShift(),
+		SubPath:      subPath,
 	}, play)
 
 	return m.ClearCache()
@@ -546,11 +774,12 @@ func (r *Room) SwapMoviePositions(id1, id2 string) error {
 }
 
 func (r *Room) GetMoviesWithPage(
+	ctx context.Context,
 	keyword string,
 	page, pageSize int,
 	parentID string,
 ) ([]*model.Movie, int64, error) {
-	return r.movies.GetMoviesWithPage(keyword, page, pageSize, parentID)
+	return r.movies.GetMoviesWithPage(ctx, keyword, page, pageSize, parentID)
 }
 
 func (r *Room) NewClient(user *User, conn *websocket.Conn) (*Client, error) {
@@ -582,37 +811,192 @@ func (r *Room) UserOnlineCount(userID string) int {
 }
 
 func (r *Room) SetCurrentStatus(playing bool, seek, rate, timeDiff float64) *model.Status {
+	defer r.scheduleAutoAdvance()
 	return r.current.SetStatus(playing, seek, rate, timeDiff)
 }
 
 func (r *Room) SetCurrentSeekRate(seek, rate, timeDiff float64) *model.Status {
+	defer r.scheduleAutoAdvance()
 	return r.current.SetSeekRate(seek, rate, timeDiff)
 }
 
+// Leader returns the user leading playback,
+// empty means anyone with PermissionSetCurrentStatus can control it.
+func (r *Room) Leader() string {
+	switch r.Settings.LeaderMode {
+	case model.LeaderModeCreator:
+		return r.CreatorID
+	case model.LeaderModeMember:
+		return r.Settings.LeaderID
+	case model.LeaderModeSetter:
+		return r.current.Leader()
+	default:
+		return ""
+	}
+}
+
+func (r *Room) IsLeader(userID string) bool {
+	leader := r.Leader()
+	return leader == "" || leader == userID
+}
+
+var ErrRoomHasNoLeader = errors.New("room has no playback leader")
+
+func (r *Room) TransferLeader(userID string) error {
+	if r.IsGuest(userID) {
+		return errors.New("cannot transfer leader to guest")
+	}
+
+	status, err := r.LoadMemberStatus(userID)
+	if err != nil {
+		return err
+	}
+
+	if !status.IsActive() {
+		return errors.New("leader must be an active member")
+	}
+
+	switch r.Settings.LeaderMode {
+	case model.LeaderModeSetter:
+		return r.setCurrentLeader(userID)
+	case model.LeaderModeCreator, model.LeaderModeMember:
+		// the creator hands over leadership by naming a member
+		return r.UpdateSettings(map[string]any{
+			"leader_mode": model.LeaderModeMember,
+			"leader_id":   userID,
+		})
+	default:
+		return ErrRoomHasNoLeader
+	}
+}
+
+func (r *Room) setCurrentLeader(userID string) error {
+	pre := r.Leader()
+	r.current.SetLeader(userID)
+
+	return r.broadcastLeaderChanged(pre)
+}
+
+func (r *Room) broadcastLeaderChanged(pre string) error {
+	leader := r.Leader()
+	if leader == pre {
+		return nil
+	}
+
+	return r.Broadcast(&pb.Message{
+		Type:      pb.MessageType_LEADER,
+		Timestamp: time.Now().UnixMilli(),
+		LeaderId:  leader,
+	})
+}
+
 func (r *Room) SetSettings(settings *model.RoomSettings) error {
+	_, err := r.setSettings(settings)
+	return err
+}
+
+// setSettings returns the settings it replaced
+func (r *Room) setSettings(settings *model.RoomSettings) (model.RoomSettings, error) {
+	if err := checkChatHistorySettings(map[string]any{
+		"chat_history_max_count":    settings.ChatHistoryMaxCount,
+		"chat_history_replay_count": settings.ChatHistoryReplayCount,
+	}); err != nil {
+		return model.RoomSettings{}, err
+	}
+
+	r.settingsMu.Lock()
+	defer r.settingsMu.Unlock()
+
+	before := *r.Settings
+
 	err := db.SaveRoomSettings(r.ID, settings)
 	if err != nil {
-		return err
+		return before, err
 	}
 
-	return r.afterUpdateSettings(settings)
+	return before, r.afterUpdateSettings(settings)
 }
 
 func (r *Room) UpdateSettings(settings map[string]any) error {
+	_, err := r.updateSettings(settings)
+	return err
+}
+
+// updateSettings returns the settings it replaced
+func (r *Room) updateSettings(settings map[string]any) (model.RoomSettings, error) {
+	if v, ok := settings["leader_mode"]; ok {
+		if err := checkLeaderMode(v); err != nil {
+			return model.RoomSettings{}, err
+		}
+	}
+
+	if v, ok := settings["play_mode"]; ok {
+		if err := checkPlayMode(v); err != nil {
+			return model.RoomSettings{}, err
+		}
+	}
+
+	if err := checkChatHistorySettings(settings); err != nil {
+		return model.RoomSettings{}, err
+	}
+
+	r.settingsMu.Lock()
+	defer r.settingsMu.Unlock()
+
+	before := *r.Settings
+
 	rs, err := db.UpdateRoomSettings(r.ID, settings)
 	if err != nil {
-		return err
+		return before, err
 	}
 
-	return r.afterUpdateSettings(rs)
+	return before, r.afterUpdateSettings(rs)
+}
+
+func checkLeaderMode(v any) error {
+	var mode model.LeaderMode
+
+	switch m := v.(type) {
+	case string:
+		mode = model.LeaderMode(m)
+	case model.LeaderMode:
+		mode = m
+	default:
+		return fmt.Errorf("invalid leader mode: %v", v)
+	}
+
+	switch mode {
+	case model.LeaderModeNone,
+		model.LeaderModeCreator,
+		model.LeaderModeMember,
+		model.LeaderModeSetter:
+		return nil
+	default:
+		return fmt.Errorf("invalid leader mode: %s", mode)
+	}
 }
 
 func (r *Room) afterUpdateSettings(rs *model.RoomSettings) error {
-	if r.Settings.GuestPermissions != rs.GuestPermissions {
-		r.members.Delete(db.GuestUserID)
+	preLeader := r.Leader()
+	prePlayMode := r.Settings.PlayMode
+
+	if r.Settings.ChatHistoryMaxCount != rs.ChatHistoryMaxCount {
+		if err := db.PruneChatMessages(r.ID, int(rs.ChatHistoryMaxCount)); err != nil {
+			logrus.Errorf("prune room %s chat messages failed: %v", r.ID, err)
+		}
+	}
+
+	r.applySettings(rs)
+	publishRoomEvent(clusterEventSettings, r.ID, "")
+
+	if err := r.broadcastLeaderChanged(preLeader); err != nil {
+		logrus.Errorf("broadcast room %s leader failed: %v", r.ID, err)
+	}
+
+	if r.Settings.PlayMode != prePlayMode {
+		r.scheduleAutoAdvance()
 	}
 
-	r.Settings = rs
 	if rs.DisableGuest {
 		return r.KickUser(db.GuestUserID)
 	}
@@ -620,6 +1004,14 @@ func (r *Room) afterUpdateSettings(rs *model.RoomSettings) error {
 	return nil
 }
 
+func (r *Room) applySettings(rs *model.RoomSettings) {
+	if r.Settings.GuestPermissions != rs.GuestPermissions {
+		r.members.Delete(db.GuestUserID)
+	}
+
+	r.Settings = rs
+}
+
 func (r *Room) ResetMemberPermissions(userID string) error {
 	return r.SetMemberPermissions(userID, r.Settings.UserDefaultPermissions)
 }
@@ -638,7 +1030,7 @@ func (r *Room) SetMemberPermissions(userID string, permissions model.RoomMemberP
 	if r.IsGuest(userID) {
 		return r.SetGuestPermissions(permissions)
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.SetMemberPermissions(r.ID, userID, permissions)
 }
@@ -651,7 +1043,7 @@ func (r *Room) AddMemberPermissions(userID string, permissions model.RoomMemberP
 	if r.IsAdmin(userID) {
 		return errors.New("cannot add permissions to admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.AddMemberPermissions(r.ID, userID, permissions)
 }
@@ -667,7 +1059,7 @@ func (r *Room) RemoveMemberPermissions(
 	if r.IsAdmin(userID) {
 		return errors.New("cannot remove permissions from admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.RemoveMemberPermissions(r.ID, userID, permissions)
 }
@@ -676,7 +1068,7 @@ func (r *Room) ApprovePendingMember(userID string) error {
 	if r.IsCreator(userID) {
 		return errors.New("creator cannot be approved as a pending member")
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.RoomApprovePendingMember(r.ID, userID)
 }
@@ -690,7 +1082,7 @@ func (r *Room) BanMember(userID string) error {
 		return errors.New("please set whether to disable guest users in the room settings")
 	}
 	defer func() {
-		r.members.Delete(userID)
+		r.invalidateMember(userID)
 		_ = r.KickUser(userID)
 	}()
 
@@ -705,17 +1097,62 @@ func (r *Room) UnbanMember(userID string) error {
 	if r.IsGuest(userID) {
 		return errors.New("please set whether to enable guest users in the room settings")
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.RoomUnbanMember(r.ID, userID)
 }
 
+func (r *Room) MuteMember(userID string, until time.Time) error {
+	if r.IsCreator(userID) {
+		return errors.New("creator cannot be muted")
+	}
+
+	if r.IsGuest(userID) {
+		return errors.New(
+			"please set whether guest users can send chat messages in the room settings",
+		)
+	}
+	defer r.invalidateMember(userID)
+
+	return db.RoomMuteMember(r.ID, userID, until)
+}
+
+func (r *Room) UnmuteMember(userID string) error {
+	if r.IsCreator(userID) {
+		return errors.New("creator cannot be unmuted")
+	}
+
+	if r.IsGuest(userID) {
+		return errors.New(
+			"please set whether guest users can send chat messages in the room settings",
+		)
+	}
+	defer r.invalidateMember(userID)
+
+	return db.RoomUnmuteMember(r.ID, userID)
+}
+
+// IsMemberMuted reports an error when the member cannot be loaded, so that
+// the caller refuses the message instead of letting a muted member speak
+func (r *Room) IsMemberMuted(userID string) (bool, error) {
+	if r.IsCreator(userID) {
+		return false, nil
+	}
+
+	member, err := r.LoadMember(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return member.IsMuted(), nil
+}
+
 func (r *Room) DeleteMember(userID string) error {
 	if r.IsCreator(userID) {
 		return errors.New("creator cannot be deleted")
 	}
 	defer func() {
-		r.members.Delete(userID)
+		r.invalidateMember(userID)
 		_ = r.KickUser(userID)
 	}()
 
@@ -740,7 +1177,7 @@ func (r *Room) SetAdminPermissions(userID string, permissions model.RoomAdminPer
 	} else if !member.Role.IsAdmin() {
 		return errors.New("not admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.RoomSetAdminPermissions(r.ID, userID, permissions)
 }
@@ -759,7 +1196,7 @@ func (r *Room) AddAdminPermissions(userID string, permissions model.RoomAdminPer
 	} else if !member.Role.IsAdmin() {
 		return errors.New("not admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.RoomAddAdminPermissions(r.ID, userID, permissions)
 }
@@ -778,7 +1215,7 @@ func (r *Room) RemoveAdminPermissions(userID string, permissions model.RoomAdmin
 	} else if !member.Role.IsAdmin() {
 		return errors.New("not admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.RoomRemoveAdminPermissions(r.ID, userID, permissions)
 }
@@ -791,7 +1228,7 @@ func (r *Room) SetAdmin(userID string, permissions model.RoomAdminPermission) er
 	if r.IsGuest(userID) {
 		return errors.New("cannot set guest as admin")
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.RoomSetAdmin(r.ID, userID, permissions)
 }
@@ -800,7 +1237,7 @@ func (r *Room) SetMember(userID string, permissions model.RoomMemberPermission)
 	if r.IsCreator(userID) {
 		return errors.New("creator cannot set member")
 	}
-	defer r.members.Delete(userID)
+	defer r.invalidateMember(userID)
 
 	return db.RoomSetMember(r.ID, userID, permissions)
 }
@@ -814,10 +1251,21 @@ func (r *Room) SetStatus(status model.RoomStatus) error {
 		return err
 	}
 
+	publishRoomEvent(clusterEventRoom, r.ID, "")
+	r.applyStatus(status)
+
+	return nil
+}
+
+func (r *Room) applyStatus(status model.RoomStatus) {
 	r.Status = status
 	if status == model.RoomStatusBanned || status == model.RoomStatusPending {
 		r.close()
 	}
+}
 
-	return nil
+// applyRoom applies the fields of a room changed on another node
+func (r *Room) applyRoom(room *model.Room) {
+	r.HashedPassword = room.HashedPassword
+	r.applyStatus(room.Status)
 }
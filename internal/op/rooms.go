@@ -79,6 +79,9 @@ func DeleteRoomByID(roomID string) error {
 	if err := db.DeleteRoomByID(roomID); err != nil {
 		return err
 	}
+	removeRoomRecordings(roomID)
+	publishRoomEvent(clusterEventRoomClosed, roomID, "")
+
 	return CloseRoomByID(roomID)
 }
 
@@ -86,6 +89,9 @@ func DeleteRoom(room *Room) error {
 	if err := db.DeleteRoomByID(room.ID); err != nil {
 		return err
 	}
+	removeRoomRecordings(room.ID)
+	publishRoomEvent(clusterEventRoomClosed, room.ID, "")
+
 	return CloseRoom(room)
 }
 
@@ -93,6 +99,9 @@ func DeleteRoomWithRoomEntry(roomE *RoomEntry) error {
 	if err := db.DeleteRoomByID(roomE.Value().ID); err != nil {
 		return err
 	}
+	removeRoomRecordings(roomE.Value().ID)
+	publishRoomEvent(clusterEventRoomClosed, roomE.Value().ID, "")
+
 	return CloseRoomWithRoomEntry(roomE)
 }
 
@@ -100,6 +109,8 @@ func CompareAndDeleteRoom(room *RoomEntry) error {
 	if err := db.DeleteRoomByID(room.Value().ID); err != nil {
 		return err
 	}
+	removeRoomRecordings(room.Value().ID)
+	publishRoomEvent(clusterEventRoomClosed, room.Value().ID, "")
 
 	CompareAndCloseRoom(room)
 
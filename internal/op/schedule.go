@@ -0,0 +1,226 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/email"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+const (
+	// other nodes only learn about new schedules by polling
+	maxScheduleWait = time.Minute
+	// schedules missed by more than this, e.g. while the server was down, are dropped
+	scheduleStartGrace = 10 * time.Minute
+)
+
+var (
+	ErrScheduleInPast     = errors.New("schedule start time must be in the future")
+	ErrScheduleRemindLate = errors.New("reminder must be before the start time")
+)
+
+var scheduleWakeup = make(chan struct{}, 1)
+
+// wakeScheduler makes the scheduler re-read the next due time
+func wakeScheduler() {
+	select {
+	case scheduleWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// RunScheduler starts due watch parties and sends their reminders until ctx is done.
+func RunScheduler(ctx context.Context) {
+	for {
+		wait := maxScheduleWait
+
+		next := runDueSchedules(time.Now())
+		if !next.IsZero() {
+			wait = min(max(time.Until(next), 0), maxScheduleWait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-scheduleWakeup:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// runDueSchedules returns the next time it needs to run
+func runDueSchedules(now time.Time) time.Time {
+	reminders, err := db.GetDueRoomScheduleReminders(now)
+	if err != nil {
+		log.Errorf("get due schedule reminders error: %v", err)
+	}
+
+	for _, s := range reminders {
+		claimed, err := db.MarkRoomScheduleReminded(s.ID)
+		if err != nil {
+			log.Errorf("mark schedule %s reminded error: %v", s.ID, err)
+			continue
+		}
+
+		if claimed {
+			go sendScheduleReminder(s)
+		}
+	}
+
+	schedules, err := db.GetDueRoomSchedules(now)
+	if err != nil {
+		log.Errorf("get due schedules error: %v", err)
+	}
+
+	for _, s := range schedules {
+		claimed, err := db.MarkRoomScheduleStarted(s.ID)
+		if err != nil {
+			log.Errorf("mark schedule %s started error: %v", s.ID, err)
+			continue
+		}
+
+		if !claimed {
+			continue
+		}
+
+		if now.Sub(s.StartAt) > scheduleStartGrace {
+			log.Warnf("schedule %s missed its start time %s, skip it", s.ID, s.StartAt)
+			continue
+		}
+
+		if err := startSchedule(s); err != nil {
+			log.Errorf("start schedule %s error: %v", s.ID, err)
+		}
+	}
+
+	next, err := db.GetNextRoomScheduleTime()
+	if err != nil {
+		log.Errorf("get next schedule time error: %v", err)
+	}
+
+	return next
+}
+
+func startSchedule(s *model.RoomSchedule) error {
+	entry, err := LoadOrInitRoomByID(s.RoomID)
+	if err != nil {
+		return err
+	}
+
+	room := entry.Value()
+
+	// the creator may have lost the permission or the lead since scheduling
+	creatorE, err := LoadOrInitUserByID(s.CreatorID)
+	if err != nil {
+		return err
+	}
+
+	creator := creatorE.Value()
+	if !creator.HasRoomPermission(room, model.PermissionSetCurrentMovie) {
+		return model.ErrNoPermission
+	}
+
+	if !room.IsLeader(creator.ID) {
+		return model.ErrNotLeader
+	}
+
+	if err := room.SetCurrentMovie(s.MovieID, s.SubPath, true); err != nil {
+		return err
+	}
+
+	return room.Broadcast(&pb.Message{
+		Type:      pb.MessageType_CURRENT,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+func sendScheduleReminder(s *model.RoomSchedule) {
+	if !email.EnableEmail.Get() {
+		return
+	}
+
+	emails, err := db.GetRoomScheduleSubscriberEmails(s.RoomID, s.ID)
+	if err != nil {
+		log.Errorf("get schedule %s subscribers error: %v", s.ID, err)
+		return
+	}
+
+	if len(emails) == 0 {
+		return
+	}
+
+	entry, err := LoadOrInitRoomByID(s.RoomID)
+	if err != nil {
+		log.Errorf("load room %s for schedule reminder error: %v", s.RoomID, err)
+		return
+	}
+
+	err = email.SendScheduleReminderEmail(
+		emails,
+		s.Title,
+		entry.Value().Name,
+		s.RoomID,
+		settings.HOST.Get(),
+		s.StartAt,
+	)
+	if err != nil {
+		log.Errorf("send schedule %s reminder error: %v", s.ID, err)
+	}
+}
+
+func (r *Room) AddSchedule(schedule *model.RoomSchedule) error {
+	if !schedule.StartAt.After(time.Now()) {
+		return ErrScheduleInPast
+	}
+
+	if schedule.RemindAt != nil && !schedule.RemindAt.Before(schedule.StartAt) {
+		return ErrScheduleRemindLate
+	}
+
+	if _, err := r.GetMovieByID(schedule.MovieID); err != nil {
+		return err
+	}
+
+	schedule.RoomID = r.ID
+
+	if err := db.CreateRoomSchedule(schedule); err != nil {
+		return err
+	}
+
+	wakeScheduler()
+
+	return nil
+}
+
+func (r *Room) GetUpcomingSchedules() ([]*model.RoomSchedule, error) {
+	return db.GetUpcomingRoomSchedules(r.ID)
+}
+
+func (r *Room) GetSchedule(id string) (*model.RoomSchedule, error) {
+	return db.GetRoomSchedule(r.ID, id)
+}
+
+func (r *Room) DeleteSchedule(id string) error {
+	return db.DeleteRoomSchedule(r.ID, id)
+}
+
+func (r *Room) SubscribeSchedule(id, userID string) error {
+	if _, err := r.GetSchedule(id); err != nil {
+		return err
+	}
+
+	return db.SubscribeRoomSchedule(id, userID)
+}
+
+func (r *Room) UnsubscribeSchedule(id, userID string) error {
+	return db.UnsubscribeRoomSchedule(id, userID)
+}
@@ -0,0 +1,141 @@
+package op
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+// newScheduleRoom returns a room of host with a movie and a joined member
+func newScheduleRoom(t *testing.T) (r *Room, host, member *User, movie *model.Movie) {
+	t.Helper()
+
+	initTestDB(t)
+
+	hostE, err := CreateUser("host", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memberE, err := CreateUser("member", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roomE, err := hostE.Value().CreateRoom("schedule", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, host, member = roomE.Value(), hostE.Value(), memberE.Value()
+
+	if _, err := r.LoadOrCreateMember(member.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	movie = &model.Movie{
+		MovieBase: model.MovieBase{Name: "movie", URL: "http://example.com/movie.mp4"},
+		CreatorID: host.ID,
+	}
+	if err := r.AddMovie(movie); err != nil {
+		t.Fatal(err)
+	}
+
+	return r, host, member, movie
+}
+
+func TestCreateRoomScheduleNotLeader(t *testing.T) {
+	r, host, member, movie := newScheduleRoom(t)
+
+	r.Settings.LeaderMode = model.LeaderModeMember
+	r.Settings.LeaderID = member.ID
+
+	err := host.CreateRoomSchedule(r, &model.RoomSchedule{
+		MovieID: movie.ID,
+		Title:   "party",
+		StartAt: time.Now().Add(time.Hour),
+	})
+	if !errors.Is(err, model.ErrNotLeader) {
+		t.Fatalf("schedule of a follower: %v", err)
+	}
+}
+
+func TestScheduleStartRechecksCreator(t *testing.T) {
+	r, host, member, movie := newScheduleRoom(t)
+
+	due := func() {
+		t.Helper()
+
+		if err := db.CreateRoomSchedule(&model.RoomSchedule{
+			RoomID:    r.ID,
+			CreatorID: host.ID,
+			MovieID:   movie.ID,
+			Title:     "party",
+			StartAt:   time.Now().Add(-time.Second),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		runDueSchedules(time.Now())
+	}
+
+	// the lead moved to another member after the schedule was created
+	r.Settings.LeaderMode = model.LeaderModeMember
+	r.Settings.LeaderID = member.ID
+
+	due()
+
+	if id := r.current.CurrentMovie().ID; id != "" {
+		t.Fatalf("schedule of a follower started %s", id)
+	}
+
+	r.Settings.LeaderMode = model.LeaderModeNone
+
+	due()
+
+	if id := r.current.CurrentMovie().ID; id != movie.ID {
+		t.Fatalf("current %q, want %s", id, movie.ID)
+	}
+}
+
+func TestScheduleReminderSkipsBannedSubscribers(t *testing.T) {
+	r, host, member, movie := newScheduleRoom(t)
+
+	s := &model.RoomSchedule{
+		RoomID:    r.ID,
+		CreatorID: host.ID,
+		MovieID:   movie.ID,
+		Title:     "party",
+		StartAt:   time.Now().Add(time.Hour),
+	}
+	if err := db.CreateRoomSchedule(s); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*User{host, member} {
+		if err := db.BindEmail(u.ID, u.Username+"@example.com"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := r.SubscribeSchedule(s.ID, u.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.BanMember(member.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	emails, err := db.GetRoomScheduleSubscriberEmails(r.ID, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(emails, []string{"host@example.com"}) {
+		t.Fatalf("reminder sent to %v", emails)
+	}
+}
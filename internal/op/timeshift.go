@@ -0,0 +1,301 @@
+package op
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/cmd/flags"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/zijiren233/livelib/protocol/hls"
+	rtmps "github.com/zijiren233/livelib/server"
+)
+
+var (
+	ErrTimeShiftNotEnabled = errors.New("live time shift is not enabled")
+	ErrTimeShiftEmpty      = errors.New("live time shift has no segments yet")
+	ErrSegmentNotFound     = errors.New("segment not found")
+)
+
+const timeShiftCollectInterval = time.Second
+
+// TimeShift keeps the hls segments of a channel on disk for the
+// LiveTimeShift window, so viewers can play behind live.
+type TimeShift struct {
+	names    map[string]struct{}
+	dir      string
+	segments []*timeShiftSegment
+	// bytes of the stored segments
+	size int64
+	// media sequence of segments[0]
+	seq int64
+	// discontinuities dropped from the window
+	discontinuitySeq int64
+	mu               sync.RWMutex
+}
+
+type timeShiftSegment struct {
+	createdAt time.Time
+	name      string
+	size      int64
+	// milliseconds
+	duration int64
+	// first segment of a new publication
+	discontinuity bool
+}
+
+func newTimeShift(dir string) *TimeShift {
+	return &TimeShift{
+		dir:   dir,
+		names: make(map[string]struct{}),
+	}
+}
+
+// TimeShiftEnabled reports whether the live movie can be played behind live
+func (m *Movie) TimeShiftEnabled() bool {
+	return settings.LiveTimeShift.Get() > 0 && m.canTimeShift()
+}
+
+func (m *Movie) canTimeShift() bool {
+	return m.Live && (m.RtmpSource || (m.Proxy && !m.HlsPassthrough()))
+}
+
+func (m *Movie) TimeShift() (*TimeShift, error) {
+	if !m.TimeShiftEnabled() {
+		return nil, ErrTimeShiftNotEnabled
+	}
+
+	c, err := m.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	if ts := m.timeShift.Load(); ts != nil {
+		return ts, nil
+	}
+
+	// the time shift was enabled after the channel started
+	ts := newTimeShift(m.timeShiftDir())
+	if !m.timeShift.CompareAndSwap(nil, ts) {
+		return m.timeShift.Load(), nil
+	}
+
+	go m.collectTimeShift(c, ts)
+
+	return ts, nil
+}
+
+func (m *Movie) timeShiftDir() string {
+	return filepath.Join(flags.Global.DataDir, "timeshift", m.ID)
+}
+
+// startTimeShift replaces the time shift of the previous channel, no time
+// shift is kept while it is disabled.
+func (m *Movie) startTimeShift(c *rtmps.Channel) {
+	if settings.LiveTimeShift.Get() <= 0 {
+		m.timeShift.Store(nil)
+		return
+	}
+
+	ts := newTimeShift(m.timeShiftDir())
+	m.timeShift.Store(ts)
+
+	go m.collectTimeShift(c, ts)
+}
+
+func (m *Movie) collectTimeShift(c *rtmps.Channel, ts *TimeShift) {
+	defer func() {
+		// the directory belongs to the time shift that replaced this one
+		if !m.timeShift.CompareAndSwap(ts, nil) {
+			return
+		}
+
+		if err := os.RemoveAll(ts.dir); err != nil {
+			log.Errorf("remove time shift of movie %s error: %v", m.ID, err)
+		}
+	}()
+
+	// segments of a previous run are never listed again
+	_ = os.RemoveAll(ts.dir)
+
+	t := time.NewTicker(timeShiftCollectInterval)
+	defer t.Stop()
+
+	var last *hls.Source
+
+	for m.channel.Load() == c && !c.Closed() {
+		<-t.C
+
+		window := time.Duration(settings.LiveTimeShift.Get()) * time.Minute
+		if window <= 0 {
+			return
+		}
+
+		if src := c.HlsPlayer(); src != nil {
+			if err := ts.collect(src, last != nil && src != last); err != nil {
+				log.Errorf("collect time shift of movie %s error: %v", m.ID, err)
+			} else {
+				last = src
+			}
+		}
+
+		ts.prune(window, settings.LiveTimeShiftMaxSize.Get()<<20)
+	}
+}
+
+// collect stores the segments of src that are not stored yet
+func (t *TimeShift) collect(src *hls.Source, discontinuity bool) error {
+	t.mu.RLock()
+	items, err := newSegments(src, t.names)
+	t.mu.RUnlock()
+
+	if err != nil || len(items) == 0 {
+		return err
+	}
+
+	if err := os.MkdirAll(t.dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := os.WriteFile(filepath.Join(t.dir, item.TsName+".ts"), item.Data, 0o644); err != nil {
+			return err
+		}
+
+		t.mu.Lock()
+		t.names[item.TsName] = struct{}{}
+		t.segments = append(t.segments, &timeShiftSegment{
+			createdAt:     time.Now(),
+			name:          item.TsName,
+			size:          int64(len(item.Data)),
+			duration:      item.Duration,
+			discontinuity: discontinuity,
+		})
+		t.size += int64(len(item.Data))
+		t.mu.Unlock()
+
+		discontinuity = false
+	}
+
+	return nil
+}
+
+// prune drops the segments older than the window and the oldest segments
+// beyond maxSize bytes
+func (t *TimeShift) prune(window time.Duration, maxSize int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	deadline := time.Now().Add(-window)
+
+	n := 0
+	for n < len(t.segments) &&
+		(t.segments[n].createdAt.Before(deadline) || t.size > maxSize) {
+		s := t.segments[n]
+		delete(t.names, s.name)
+		t.size -= s.size
+
+		if s.discontinuity {
+			t.discontinuitySeq++
+		}
+
+		if err := os.Remove(filepath.Join(t.dir, s.name+".ts")); err != nil &&
+			!os.IsNotExist(err) {
+			log.Errorf("remove time shift segment error: %v", err)
+		}
+
+		n++
+	}
+
+	t.segments = t.segments[n:]
+	t.seq += int64(n)
+}
+
+// GenM3U8File returns an event playlist of the whole window
+func (t *TimeShift) GenM3U8File(tsPath func(tsName string) (tsPath string)) ([]byte, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if len(t.segments) == 0 {
+		return nil, ErrTimeShiftEmpty
+	}
+
+	var maxDuration int64
+	for _, s := range t.segments {
+		maxDuration = max(maxDuration, s.duration)
+	}
+
+	b := bytes.NewBuffer(nil)
+	fmt.Fprintf(
+		b,
+		"#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-PLAYLIST-TYPE:EVENT\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:%d\n#EXT-X-DISCONTINUITY-SEQUENCE:%d\n",
+		maxDuration/1000+1,
+		t.seq,
+		t.discontinuitySeq,
+	)
+
+	for _, s := range t.segments {
+		if s.discontinuity {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+
+		fmt.Fprintf(
+			b,
+			"#EXT-X-PROGRAM-DATE-TIME:%s\n#EXTINF:%.3f,\n%s\n",
+			s.createdAt.Add(-time.Duration(s.duration)*time.Millisecond).
+				UTC().
+				Format("2006-01-02T15:04:05.000Z"),
+			float64(s.duration)/1000,
+			tsPath(s.name),
+		)
+	}
+
+	return b.Bytes(), nil
+}
+
+func (t *TimeShift) GetSegment(tsName string) ([]byte, error) {
+	t.mu.RLock()
+	_, ok := t.names[tsName]
+	t.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrSegmentNotFound
+	}
+
+	return os.ReadFile(filepath.Join(t.dir, tsName+".ts"))
+}
+
+// newSegments returns the cached segments of src missing from seen, the
+// source only keeps its last few segments so callers poll it regularly.
+func newSegments(src *hls.Source, seen map[string]struct{}) ([]*hls.TSItem, error) {
+	var names []string
+
+	_, err := src.GetCacheInc().GenM3U8File(func(tsName string) string {
+		names = append(names, tsName)
+		return tsName
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*hls.TSItem, 0, len(names))
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+
+		item, err := src.GetCacheInc().GetItem(name)
+		if err != nil {
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
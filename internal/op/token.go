@@ -0,0 +1,157 @@
+package op
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/zijiren233/gencontainer/synccache"
+)
+
+// APITokenPrefix tells personal access tokens from the jwt of a login
+const APITokenPrefix = "stv_"
+
+const maxAPITokens = 50
+
+var (
+	ErrAPITokenInvalid = errors.New("invalid api token")
+	ErrAPITokenExpired = errors.New("api token expired")
+)
+
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken returns the token, it cannot be shown again
+func (u *User) CreateAPIToken(
+	name string,
+	scopes []model.APITokenScope,
+	roomID string,
+	expiresAt *time.Time,
+) (string, *model.APIToken, error) {
+	if u.IsGuest() {
+		return "", nil, errors.New("guest cannot create api tokens")
+	}
+
+	if len(scopes) == 0 {
+		return "", nil, errors.New("api token needs at least one scope")
+	}
+
+	for _, s := range scopes {
+		switch {
+		case !s.Valid():
+			return "", nil, fmt.Errorf("invalid api token scope: %s", s)
+		case s == model.APITokenScopeAdmin && !u.IsAdmin():
+			return "", nil, errors.New("only admins can create admin api tokens")
+		case s == model.APITokenScopeAdmin && roomID != "":
+			return "", nil, errors.New("admin api tokens cannot be restricted to a room")
+		}
+	}
+
+	if roomID != "" {
+		if _, err := db.GetRoomByID(roomID); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return "", nil, errors.New("api token expiration is in the past")
+	}
+
+	count, err := db.CountAPITokensByUserID(u.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if count >= maxAPITokens {
+		return "", nil, fmt.Errorf("a user can have at most %d api tokens", maxAPITokens)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	token := APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	t := &model.APIToken{
+		UserID:      u.ID,
+		Name:        name,
+		HashedToken: hashAPIToken(token),
+		Scopes:      scopes,
+		RoomID:      roomID,
+		ExpiresAt:   expiresAt,
+		LastUsedAt:  time.Now(),
+	}
+	if err := db.CreateAPIToken(t); err != nil {
+		return "", nil, err
+	}
+
+	return token, t, nil
+}
+
+func (u *User) ListAPITokens() ([]*model.APIToken, error) {
+	return db.GetAPITokensByUserID(u.ID)
+}
+
+// RevokeAPIToken also closes the websockets opened with the token, they are
+// only authenticated when they connect
+func (u *User) RevokeAPIToken(id string) error {
+	if err := db.DeleteAPIToken(u.ID, id); err != nil {
+		return err
+	}
+
+	publishKickAPITokenEvent(u.ID, id)
+	kickAPITokenLocal(u.ID, id)
+
+	return nil
+}
+
+func kickAPITokenLocal(userID, tokenID string) {
+	roomCache.Range(func(_ string, value *synccache.Entry[*Room]) bool {
+		if r := value.Value(); !r.HubIsNotInited() {
+			_ = r.lazyInitHub().KickAPIToken(userID, tokenID)
+		}
+		return true
+	})
+}
+
+// AuthAPIToken returns the token and its user, the user is not checked
+func AuthAPIToken(token string) (*model.APIToken, *UserEntry, error) {
+	t, err := db.GetAPITokenByHash(hashAPIToken(token))
+	if err != nil {
+		if errors.Is(err, db.NotFoundError(db.ErrAPITokenNotFound)) {
+			return nil, nil, ErrAPITokenInvalid
+		}
+
+		return nil, nil, err
+	}
+
+	if t.IsExpired() {
+		return nil, nil, ErrAPITokenExpired
+	}
+
+	userE, err := LoadOrInitUserByID(t.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// bots may call the api many times a second
+	if time.Since(t.LastUsedAt) > time.Minute {
+		_ = db.UpdateAPITokenLastUsed(t.ID)
+	}
+
+	return t, userE, nil
+}
@@ -0,0 +1,59 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func TestRevokeAPITokenKicksWebsockets(t *testing.T) {
+	initTestDB(t)
+
+	userE, err := CreateUser("bot", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := userE.Value()
+
+	roomE, err := u.CreateRoom("token", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := roomE.Value()
+
+	_, token, err := u.CreateAPIToken(
+		"bot",
+		[]model.APITokenScope{model.APITokenScopeReadRooms},
+		r.ID,
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withToken, err := r.NewClient(u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withToken.SetAPIToken(token)
+
+	withLogin, err := r.NewClient(u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.RevokeAPIToken(token.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if !withToken.Closed() {
+		t.Fatal("websocket of the revoked token is still open")
+	}
+
+	if withLogin.Closed() {
+		t.Fatal("websocket of the login was closed")
+	}
+}
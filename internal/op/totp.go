@@ -0,0 +1,481 @@
+package op
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/internal/totp"
+	"github.com/synctv-org/synctv/utils"
+)
+
+var (
+	ErrTotpNotEnabled            = errors.New("2fa is not enabled")
+	ErrTotpEnabled               = errors.New("2fa is already enabled")
+	ErrTotpInvalidCode           = errors.New("invalid 2fa code")
+	ErrTotpSetupExpired          = errors.New("2fa setup expired")
+	ErrTotpRequired              = errors.New("2fa is required for admins")
+	ErrTwoFactorChallengeExpired = errors.New("2fa challenge expired, login again")
+	ErrTotpLocked                = errors.New("too many invalid 2fa codes, try again later")
+)
+
+const (
+	appName              = "SyncTV"
+	recoveryCodeCount    = 10
+	maxChallengeAttempts = 5
+	// invalid codes of a user, over any number of challenges, before 2fa is
+	// locked for totpLockout
+	maxTotpFailures = 10
+	totpLockout     = time.Minute * 15
+)
+
+const (
+	totpSetupTimeout          = time.Minute * 10
+	twoFactorChallengeTimeout = time.Minute * 5
+)
+
+// isAuthSessionNotFound reports an auth session that expired, was used up or
+// never existed
+func isAuthSessionNotFound(err error) bool {
+	return errors.Is(err, db.NotFoundError(db.ErrAuthSessionNotFound))
+}
+
+// TotpRequired reports whether u needs 2fa to use the admin api, either totp
+// or a passkey
+func (u *User) TotpRequired() bool {
+	return u.IsAdmin() && settings.RequireAdmin2FA.Get()
+}
+
+// CheckTotpRequired returns ErrTotpRequired when u needs 2fa but has neither
+// totp nor a passkey
+func (u *User) CheckTotpRequired() error {
+	if !u.TotpRequired() || u.TotpEnabled() {
+		return nil
+	}
+
+	passkey, err := u.HasWebAuthnCredentials()
+	if err != nil {
+		return err
+	}
+
+	if !passkey {
+		return ErrTotpRequired
+	}
+
+	return nil
+}
+
+// LoadTotp reads the 2fa state of the cached user again, it may have been
+// changed by another node or by the reset-2fa command
+func (u *User) LoadTotp() error {
+	t, err := db.GetUserTotp(u.ID)
+	if err != nil {
+		return err
+	}
+
+	u.TotpSecret = t.TotpSecret
+	u.TotpRecoveryCodes = t.TotpRecoveryCodes
+	u.TotpLastStep = t.TotpLastStep
+	u.TotpFailures = t.TotpFailures
+	u.TotpLockedUntil = t.TotpLockedUntil
+
+	return nil
+}
+
+// TwoFactorEnabled reports from the database whether a login of u needs a
+// second factor. Totp turns it on and a passkey may then answer it instead of
+// a code. Passkeys alone only turn it on for admins who must have 2fa and
+// chose a passkey as it.
+func (u *User) TwoFactorEnabled() (bool, error) {
+	if err := u.LoadTotp(); err != nil {
+		return false, err
+	}
+
+	if u.TotpEnabled() {
+		return true, nil
+	}
+
+	if !u.TotpRequired() {
+		return false, nil
+	}
+
+	return u.HasWebAuthnCredentials()
+}
+
+// NewTotpSetup returns a new secret and its otpauth url, it is enabled by
+// EnableTotp with a code of it
+func (u *User) NewTotpSetup() (secret, url string, err error) {
+	if u.IsGuest() {
+		return "", "", errors.New("guest cannot enable 2fa")
+	}
+
+	if u.TotpEnabled() {
+		return "", "", ErrTotpEnabled
+	}
+
+	s, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	// stored like the secret of an enabled totp
+	encrypted, err := utils.Crypto(s, utils.GenCryptoKey(u.ID))
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := db.SaveAuthSession(&model.AuthSession{
+		ID:        u.ID,
+		Kind:      model.AuthSessionTotpSetup,
+		UserID:    u.ID,
+		Data:      encrypted,
+		ExpiresAt: time.Now().Add(totpSetupTimeout),
+	}); err != nil {
+		return "", "", err
+	}
+
+	return totp.EncodeSecret(s), totp.URL(appName, u.Username, s), nil
+}
+
+// EnableTotp returns the recovery codes, they are only stored hashed
+func (u *User) EnableTotp(code string) ([]string, error) {
+	if u.TotpEnabled() {
+		return nil, ErrTotpEnabled
+	}
+
+	setup, err := db.GetAuthSession(model.AuthSessionTotpSetup, u.ID)
+	if err != nil {
+		if isAuthSessionNotFound(err) {
+			return nil, ErrTotpSetupExpired
+		}
+
+		return nil, err
+	}
+
+	plain, err := utils.Decrypto(setup.Data, utils.GenCryptoKey(u.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(plain, code, time.Now(), 0)
+	if !ok {
+		return nil, ErrTotpInvalidCode
+	}
+
+	secret := setup.Data
+
+	codes, hashed, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.SetUserTotp(u.ID, secret, hashed, step); err != nil {
+		return nil, err
+	}
+
+	_ = db.DeleteAuthSession(model.AuthSessionTotpSetup, u.ID)
+
+	u.TotpSecret = secret
+	u.TotpRecoveryCodes = hashed
+	u.TotpLastStep = step
+	u.TotpFailures = 0
+	u.TotpLockedUntil = 0
+	publishUserEvent(clusterEventUser, u.ID)
+
+	return codes, nil
+}
+
+func (u *User) DisableTotp(code string) error {
+	if !u.TotpEnabled() {
+		return ErrTotpNotEnabled
+	}
+
+	if err := u.VerifyTotp(code); err != nil {
+		return err
+	}
+
+	// a passkey is left as the second factor
+	if u.TotpRequired() {
+		passkey, err := u.HasWebAuthnCredentials()
+		if err != nil {
+			return err
+		}
+
+		if !passkey {
+			return ErrTotpRequired
+		}
+	}
+
+	if err := db.ResetUserTotp(u.ID); err != nil {
+		return err
+	}
+
+	u.TotpSecret = nil
+	u.TotpRecoveryCodes = ""
+	u.TotpLastStep = 0
+	u.TotpFailures = 0
+	u.TotpLockedUntil = 0
+	publishUserEvent(clusterEventUser, u.ID)
+
+	return nil
+}
+
+func (u *User) RegenerateTotpRecoveryCodes(code string) ([]string, error) {
+	if err := u.VerifyTotp(code); err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.SetUserTotpRecoveryCodes(u.ID, u.TotpRecoveryCodes, hashed); err != nil {
+		return nil, err
+	}
+
+	u.TotpRecoveryCodes = hashed
+	publishUserEvent(clusterEventUser, u.ID)
+
+	return codes, nil
+}
+
+func (u *User) TotpRecoveryCodesLeft() int {
+	if u.TotpRecoveryCodes == "" {
+		return 0
+	}
+
+	return strings.Count(u.TotpRecoveryCodes, ",") + 1
+}
+
+// VerifyTotp accepts a code of the authenticator app or an unused recovery
+// code, both can only be used once. 2fa is locked after maxTotpFailures
+// invalid codes.
+func (u *User) VerifyTotp(code string) error {
+	if err := u.LoadTotp(); err != nil {
+		return err
+	}
+
+	if !u.TotpEnabled() {
+		return ErrTotpNotEnabled
+	}
+
+	if time.Now().Unix() < u.TotpLockedUntil {
+		return ErrTotpLocked
+	}
+
+	err := u.verifyTotp(code)
+	if errors.Is(err, ErrTotpInvalidCode) {
+		return u.addTotpFailure()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if u.TotpFailures != 0 {
+		if err := db.ResetUserTotpFailures(u.ID); err != nil {
+			return err
+		}
+
+		u.TotpFailures = 0
+	}
+
+	return nil
+}
+
+func (u *User) verifyTotp(code string) error {
+	code = normalizeRecoveryCode(code)
+	if len(code) != totp.Digits {
+		return u.useRecoveryCode(code)
+	}
+
+	secret, err := utils.Decrypto(u.TotpSecret, utils.GenCryptoKey(u.ID))
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), u.TotpLastStep)
+	if !ok {
+		return ErrTotpInvalidCode
+	}
+
+	if err := db.SetUserTotpLastStep(u.ID, step); err != nil {
+		if errors.Is(err, db.NotFoundError(db.ErrUserNotFound)) {
+			return ErrTotpInvalidCode
+		}
+
+		return err
+	}
+
+	u.TotpLastStep = step
+
+	return nil
+}
+
+// addTotpFailure returns the error of the invalid code
+func (u *User) addTotpFailure() error {
+	lockedUntil := time.Now().Add(totpLockout).Unix()
+
+	locked, err := db.AddUserTotpFailure(u.ID, maxTotpFailures, lockedUntil)
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		u.TotpFailures = 0
+		u.TotpLockedUntil = lockedUntil
+
+		return ErrTotpLocked
+	}
+
+	u.TotpFailures++
+
+	return ErrTotpInvalidCode
+}
+
+func (u *User) useRecoveryCode(code string) error {
+	old := u.TotpRecoveryCodes
+	if old == "" || code == "" {
+		return ErrTotpInvalidCode
+	}
+
+	codes := strings.Split(old, ",")
+
+	i := slices.Index(codes, hashRecoveryCode(code))
+	if i == -1 {
+		return ErrTotpInvalidCode
+	}
+
+	remaining := strings.Join(slices.Delete(codes, i, i+1), ",")
+
+	if err := db.SetUserTotpRecoveryCodes(u.ID, old, remaining); err != nil {
+		if errors.Is(err, db.NotFoundError(db.ErrUserNotFound)) {
+			return ErrTotpInvalidCode
+		}
+
+		return err
+	}
+
+	u.TotpRecoveryCodes = remaining
+
+	return nil
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns codes like abcd-efgh and their comma separated
+// hashes
+func newRecoveryCodes() (codes []string, hashed string, err error) {
+	codes = make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	b := make([]byte, 5)
+	for i := range codes {
+		if _, err := rand.Read(b); err != nil {
+			return nil, "", err
+		}
+
+		c := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes[i] = c[:4] + "-" + c[4:]
+		hashes[i] = hashRecoveryCode(c)
+	}
+
+	return codes, strings.Join(hashes, ","), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewTwoFactorChallenge is returned by a login of a user with 2fa instead of
+// a token, the token is issued by VerifyTwoFactorChallenge
+func NewTwoFactorChallenge(userID string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	challenge := hex.EncodeToString(b)
+
+	if err := db.SaveAuthSession(&model.AuthSession{
+		ID:        challenge,
+		Kind:      model.AuthSessionTwoFactor,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(twoFactorChallengeTimeout),
+	}); err != nil {
+		return "", err
+	}
+
+	return challenge, nil
+}
+
+// attemptTwoFactorChallenge counts an answer to the challenge and returns
+// its user id
+func attemptTwoFactorChallenge(challenge string) (string, error) {
+	s, err := db.GetAuthSession(model.AuthSessionTwoFactor, challenge)
+	if err == nil {
+		err = db.AddAuthSessionAttempt(
+			model.AuthSessionTwoFactor,
+			challenge,
+			maxChallengeAttempts,
+		)
+	}
+
+	if err != nil {
+		if isAuthSessionNotFound(err) {
+			_ = db.DeleteAuthSession(model.AuthSessionTwoFactor, challenge)
+			return "", ErrTwoFactorChallengeExpired
+		}
+
+		return "", err
+	}
+
+	return s.UserID, nil
+}
+
+func VerifyTwoFactorChallenge(challenge, code string) (*UserEntry, error) {
+	userID, err := attemptTwoFactorChallenge(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	userE, err := LoadOrInitUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := userE.Value().VerifyTotp(code); err != nil {
+		return nil, err
+	}
+
+	if err := finishTwoFactorChallenge(challenge); err != nil {
+		return nil, err
+	}
+
+	return userE, nil
+}
+
+// finishTwoFactorChallenge deletes the answered challenge, it fails when
+// another answer already used it
+func finishTwoFactorChallenge(challenge string) error {
+	err := db.DeleteAuthSession(model.AuthSessionTwoFactor, challenge)
+	if isAuthSessionNotFound(err) {
+		return ErrTwoFactorChallengeExpired
+	}
+
+	return err
+}
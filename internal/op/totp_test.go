@@ -0,0 +1,184 @@
+package op
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/totp"
+	"github.com/synctv-org/synctv/utils"
+	"gorm.io/gorm"
+)
+
+func initTestDB(t *testing.T) {
+	t.Helper()
+
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.Database.Type = conf.DatabaseTypeSqlite3
+
+	d, err := gorm.Open(
+		sqlite.Open(filepath.Join(t.TempDir(), "synctv.db")),
+		&gorm.Config{TranslateError: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Init(d, conf.DatabaseTypeSqlite3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Init(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTotpUser returns a user with 2fa enabled, its secret and recovery codes
+func newTotpUser(t *testing.T) (*User, []byte, []string) {
+	t.Helper()
+
+	initTestDB(t)
+
+	userE, err := CreateUser("totp", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := userE.Value()
+
+	if _, _, err := u.NewTotpSetup(); err != nil {
+		t.Fatal(err)
+	}
+
+	setup, err := db.GetAuthSession(model.AuthSessionTotpSetup, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := utils.Decrypto(setup.Data, utils.GenCryptoKey(u.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the previous step, the current one is left for the tests
+	codes, err := u.EnableTotp(totp.Code(secret, totp.Step(time.Now())-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u, secret, codes
+}
+
+func TestVerifyTotpReplay(t *testing.T) {
+	u, secret, _ := newTotpUser(t)
+
+	step := totp.Step(time.Now())
+	code := totp.Code(secret, step)
+
+	if err := u.VerifyTotp(code); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.VerifyTotp(code); !errors.Is(err, ErrTotpInvalidCode) {
+		t.Fatalf("replayed code: %v", err)
+	}
+
+	// another node still holding the previous step loses the race in the
+	// database
+	if err := db.SetUserTotpLastStep(u.ID, step); err == nil {
+		t.Fatal("used step accepted again")
+	}
+}
+
+func TestVerifyTotpRecoveryCode(t *testing.T) {
+	u, _, codes := newTotpUser(t)
+
+	if err := u.VerifyTotp(codes[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.VerifyTotp(codes[0]); !errors.Is(err, ErrTotpInvalidCode) {
+		t.Fatalf("reused recovery code: %v", err)
+	}
+
+	if left := u.TotpRecoveryCodesLeft(); left != recoveryCodeCount-1 {
+		t.Fatalf("%d recovery codes left", left)
+	}
+
+	// codes read before the first one was used
+	stale := u.TotpRecoveryCodes + ",stale"
+	if err := db.SetUserTotpRecoveryCodes(u.ID, stale, ""); err == nil {
+		t.Fatal("recovery codes replaced from a stale read")
+	}
+}
+
+func TestTwoFactorChallengeExhausted(t *testing.T) {
+	u, secret, _ := newTotpUser(t)
+
+	challenge, err := NewTwoFactorChallenge(u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range maxChallengeAttempts {
+		if _, err := VerifyTwoFactorChallenge(challenge, "000000x"); !errors.Is(
+			err,
+			ErrTotpInvalidCode,
+		) {
+			t.Fatalf("invalid code: %v", err)
+		}
+	}
+
+	code := totp.Code(secret, totp.Step(time.Now()))
+	if _, err := VerifyTwoFactorChallenge(challenge, code); !errors.Is(
+		err,
+		ErrTwoFactorChallengeExpired,
+	) {
+		t.Fatalf("exhausted challenge: %v", err)
+	}
+}
+
+func TestVerifyTotpLockout(t *testing.T) {
+	u, secret, _ := newTotpUser(t)
+
+	for i := 1; i <= maxTotpFailures; i++ {
+		err := u.VerifyTotp("invalid")
+		if i < maxTotpFailures && !errors.Is(err, ErrTotpInvalidCode) {
+			t.Fatalf("failure %d: %v", i, err)
+		}
+
+		if i == maxTotpFailures && !errors.Is(err, ErrTotpLocked) {
+			t.Fatalf("not locked after %d failures: %v", i, err)
+		}
+	}
+
+	if err := u.VerifyTotp(totp.Code(secret, totp.Step(time.Now()))); !errors.Is(
+		err,
+		ErrTotpLocked,
+	) {
+		t.Fatalf("valid code while locked: %v", err)
+	}
+}
+
+func TestTwoFactorEnabledReset(t *testing.T) {
+	u, _, _ := newTotpUser(t)
+
+	// like the reset-2fa command, which does not go through the cache
+	if err := db.ResetUserTotp(u.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	enabled, err := u.TwoFactorEnabled()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if enabled {
+		t.Fatal("cached user still needs 2fa after a reset")
+	}
+}
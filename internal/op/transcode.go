@@ -0,0 +1,452 @@
+package op
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/cmd/flags"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/livelib/av"
+	"github.com/zijiren233/livelib/container/flv"
+	rtmps "github.com/zijiren233/livelib/server"
+)
+
+var (
+	ErrTranscodeNotEnabled = errors.New("live transcoding is not enabled")
+	ErrFFmpegNotFound      = errors.New("ffmpeg not found")
+	ErrTranscodeNotReady   = errors.New("live transcoding is not ready yet")
+	ErrTranscodeStopped    = errors.New("live transcoding stopped")
+	ErrTooManyTranscoders  = errors.New("too many live transcoders are running")
+)
+
+const (
+	transcodeMasterPlaylist = "master.m3u8"
+	// ffmpeg is stopped once nobody fetched its output for this long
+	transcodeIdleTimeout  = 30 * time.Second
+	transcodeReapInterval = 5 * time.Second
+	// packets kept before deciding whether the channel carries audio
+	transcodeProbePackets   = 256
+	transcodeSegmentSeconds = 2
+)
+
+// transcoders running on this node, limited by LiveTranscodeMaxConcurrent
+var runningTranscoders atomic.Int64
+
+func acquireTranscoder() bool {
+	for {
+		n := runningTranscoders.Load()
+		if limit := settings.LiveTranscodeMaxConcurrent.Get(); limit > 0 && n >= limit {
+			return false
+		}
+
+		if runningTranscoders.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+func releaseTranscoder() {
+	runningTranscoders.Add(-1)
+}
+
+// file names ffmpeg writes below the transcode dir
+var transcodeFileRe = regexp.MustCompile(`^\d+/(index\.m3u8|\d+\.ts)$`)
+
+var ffmpegPath = sync.OnceValues(func() (string, error) {
+	if conf.Conf.Server.FFmpegPath != "" {
+		return exec.LookPath(conf.Conf.Server.FFmpegPath)
+	}
+
+	return exec.LookPath("ffmpeg")
+})
+
+// TranscodeEnabled reports whether the live movie may be served as an adaptive ladder
+func (m *Movie) TranscodeEnabled() bool {
+	if !settings.LiveTranscode.Get() || !m.Live {
+		return false
+	}
+
+	return m.RtmpSource || (m.Proxy && !m.HlsPassthrough())
+}
+
+// Transcoder returns the running transcoder of the movie, starting one if needed
+func (m *Movie) Transcoder() (*Transcoder, error) {
+	if !m.TranscodeEnabled() {
+		return nil, ErrTranscodeNotEnabled
+	}
+
+	if t := m.transcoder.Load(); t != nil {
+		t.touch()
+		return t, nil
+	}
+
+	bin, err := ffmpegPath()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFFmpegNotFound, err)
+	}
+
+	renditions, err := model.ParseLiveRenditions(settings.LiveTranscodeRenditions.Get())
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := m.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	if !acquireTranscoder() {
+		return nil, ErrTooManyTranscoders
+	}
+
+	t := newTranscoder(
+		m,
+		c,
+		bin,
+		// a stopping ffmpeg may still be writing to the dir of the previous one
+		filepath.Join(flags.Global.DataDir, "transcode", m.ID, utils.SortUUID()),
+		renditions,
+	)
+	if !m.transcoder.CompareAndSwap(nil, t) {
+		releaseTranscoder()
+		return m.Transcoder()
+	}
+
+	if err := t.start(); err != nil {
+		m.transcoder.CompareAndSwap(t, nil)
+		releaseTranscoder()
+
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// RunningTranscoder returns the transcoder without starting one
+func (m *Movie) RunningTranscoder() (*Transcoder, bool) {
+	t := m.transcoder.Load()
+	if t == nil {
+		return nil, false
+	}
+
+	t.touch()
+
+	return t, true
+}
+
+// Transcoder pipes a channel into ffmpeg, which writes a multi-rendition hls ladder to disk
+type Transcoder struct {
+	movie      *Movie
+	channel    *rtmps.Channel
+	cmd        *exec.Cmd
+	w          *flv.Writer
+	stdin      interface{ Close() error }
+	done       chan struct{}
+	exited     chan struct{}
+	bin        string
+	dir        string
+	renditions []model.LiveRendition
+	pending    []*av.Packet
+	lastAccess atomic.Int64
+	mu         sync.Mutex
+	hasAudio   bool
+	closed     bool
+}
+
+func newTranscoder(
+	m *Movie,
+	c *rtmps.Channel,
+	bin, dir string,
+	renditions []model.LiveRendition,
+) *Transcoder {
+	t := &Transcoder{
+		movie:      m,
+		channel:    c,
+		bin:        bin,
+		dir:        dir,
+		renditions: renditions,
+		done:       make(chan struct{}),
+	}
+	t.touch()
+
+	return t
+}
+
+func (t *Transcoder) touch() {
+	t.lastAccess.Store(time.Now().UnixNano())
+}
+
+func (t *Transcoder) start() error {
+	if err := t.channel.AddPlayer(t); err != nil {
+		return err
+	}
+
+	go t.reap()
+
+	return nil
+}
+
+// reap stops the transcoder when nobody watches it anymore
+func (t *Transcoder) reap() {
+	ticker := time.NewTicker(transcodeReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			idle := time.Since(time.Unix(0, t.lastAccess.Load()))
+			if idle > transcodeIdleTimeout {
+				t.channel.DelPlayer(t)
+				// the channel may have dropped the player already
+				_ = t.Close()
+
+				return
+			}
+		}
+	}
+}
+
+// Write feeds the channel packets to ffmpeg, it is started once
+// the first frame shows whether the channel carries audio.
+func (t *Transcoder) Write(p *av.Packet) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return av.ErrClosed
+	}
+
+	if t.cmd != nil {
+		return t.w.Write(p)
+	}
+
+	t.pending = append(t.pending, p)
+	if p.IsAudio {
+		t.hasAudio = true
+	}
+
+	if !isVideoFrame(p) && len(t.pending) < transcodeProbePackets {
+		return nil
+	}
+
+	if err := t.spawn(); err != nil {
+		log.Errorf("start ffmpeg of movie %s error: %v", t.movie.ID, err)
+		return err
+	}
+
+	for _, p := range t.pending {
+		if err := t.w.Write(p); err != nil {
+			return err
+		}
+	}
+
+	t.pending = nil
+
+	return nil
+}
+
+func isVideoFrame(p *av.Packet) bool {
+	if !p.IsVideo {
+		return false
+	}
+
+	h, ok := p.Header.(av.VideoPacketHeader)
+
+	return ok && !h.IsSeq()
+}
+
+func (t *Transcoder) spawn() error {
+	for i := range t.renditions {
+		if err := os.MkdirAll(filepath.Join(t.dir, strconv.Itoa(i)), os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.CommandContext(context.Background(), t.bin, t.args()...)
+	cmd.Dir = t.dir
+
+	stderr := bytes.NewBuffer(nil)
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	t.cmd = cmd
+	t.stdin = stdin
+	t.w = flv.NewWriter(stdin)
+	t.exited = make(chan struct{})
+
+	go func() {
+		defer close(t.exited)
+
+		if err := cmd.Wait(); err != nil && !t.isClosed() {
+			log.Errorf(
+				"ffmpeg of movie %s exited: %v: %s",
+				t.movie.ID,
+				err,
+				strings.TrimSpace(stderr.String()),
+			)
+		}
+	}()
+
+	return nil
+}
+
+func (t *Transcoder) args() []string {
+	args := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-f", "flv", "-i", "pipe:0",
+	}
+
+	for range t.renditions {
+		args = append(args, "-map", "0:v:0")
+		if t.hasAudio {
+			args = append(args, "-map", "0:a:0")
+		}
+	}
+
+	streams := make([]string, len(t.renditions))
+	for i, r := range t.renditions {
+		args = append(args,
+			fmt.Sprintf("-filter:v:%d", i), fmt.Sprintf("scale=-2:%d", r.Height),
+			fmt.Sprintf("-c:v:%d", i), "libx264",
+			fmt.Sprintf("-b:v:%d", i), fmt.Sprintf("%dk", r.Bitrate),
+			fmt.Sprintf("-maxrate:v:%d", i), fmt.Sprintf("%dk", r.Bitrate*107/100),
+			fmt.Sprintf("-bufsize:v:%d", i), fmt.Sprintf("%dk", r.Bitrate*3/2),
+		)
+
+		streams[i] = fmt.Sprintf("v:%d", i)
+		if t.hasAudio {
+			streams[i] += fmt.Sprintf(",a:%d", i)
+		}
+	}
+
+	args = append(args,
+		"-preset", "veryfast",
+		"-tune", "zerolatency",
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", transcodeSegmentSeconds),
+	)
+
+	if t.hasAudio {
+		args = append(args, "-c:a", "aac", "-b:a", "128k", "-ac", "2")
+	}
+
+	return append(args,
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(transcodeSegmentSeconds),
+		"-hls_list_size", "6",
+		"-hls_flags", "delete_segments+independent_segments",
+		"-master_pl_name", transcodeMasterPlaylist,
+		"-hls_segment_filename", "%v/%d.ts",
+		"-var_stream_map", strings.Join(streams, " "),
+		"%v/index.m3u8",
+	)
+}
+
+func (t *Transcoder) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.closed
+}
+
+// Close stops ffmpeg and removes its output
+func (t *Transcoder) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return av.ErrClosed
+	}
+
+	t.closed = true
+	close(t.done)
+	t.movie.transcoder.CompareAndSwap(t, nil)
+	releaseTranscoder()
+
+	if t.cmd == nil {
+		_ = os.RemoveAll(t.dir)
+		return nil
+	}
+
+	_ = t.stdin.Close()
+
+	go func(cmd *exec.Cmd, exited chan struct{}) {
+		// ffmpeg exits by itself once stdin is closed
+		select {
+		case <-exited:
+		case <-time.After(5 * time.Second):
+			_ = cmd.Process.Kill()
+			<-exited
+		}
+
+		_ = os.RemoveAll(t.dir)
+	}(t.cmd, t.exited)
+
+	return nil
+}
+
+// MasterPlaylist returns the ladder with every rendition playlist rewritten
+// by uri, ErrTranscodeNotReady until ffmpeg wrote it.
+func (t *Transcoder) MasterPlaylist(uri func(rendition string) string) ([]byte, error) {
+	select {
+	case <-t.done:
+		return nil, ErrTranscodeStopped
+	default:
+	}
+
+	b, err := os.ReadFile(filepath.Join(t.dir, transcodeMasterPlaylist))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrTranscodeNotReady
+		}
+
+		return nil, err
+	}
+
+	return rewritePlaylist(b, uri), nil
+}
+
+// File returns the path of a rendition playlist or segment
+func (t *Transcoder) File(name string) (string, error) {
+	if !transcodeFileRe.MatchString(name) {
+		return "", fmt.Errorf("invalid transcode file: %s", name)
+	}
+
+	return filepath.Join(t.dir, filepath.FromSlash(name)), nil
+}
+
+func rewritePlaylist(b []byte, uri func(string) string) []byte {
+	lines := strings.Split(string(b), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines[i] = uri(line)
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -1,9 +1,11 @@
 package op
 
 import (
+	"context"
 	"errors"
 	"hash/crc32"
 	"sync/atomic"
+	"time"
 
 	"github.com/synctv-org/synctv/internal/cache"
 	"github.com/synctv-org/synctv/internal/db"
@@ -73,6 +75,15 @@ func (u *User) SetPassword(password string) error {
 		return errors.New("guest cannot set password")
 	}
 
+	ldapUser, err := u.IsLdapUser()
+	if err != nil {
+		return err
+	}
+
+	if ldapUser {
+		return ErrLdapUser
+	}
+
 	if u.CheckPassword(password) {
 		return errors.New("password is the same")
 	}
@@ -85,10 +96,15 @@ func (u *User) SetPassword(password string) error {
 		return err
 	}
 
+	if err := db.SetUserHashedPassword(u.ID, hashedPassword); err != nil {
+		return err
+	}
+
 	atomic.StoreUint32(&u.version, crc32.ChecksumIEEE(hashedPassword))
 	u.HashedPassword = hashedPassword
+	publishUserEvent(clusterEventUser, u.ID)
 
-	return db.SetUserHashedPassword(u.ID, hashedPassword)
+	return nil
 }
 
 func (u *User) CreateRoom(name, password string, conf ...db.CreateRoomConfig) (*RoomEntry, error) {
@@ -164,6 +180,196 @@ func (u *User) AddRoomMovie(room *Room, movie *model.MovieBase) (*model.Movie, e
 	})
 }
 
+func (u *User) broadcastMovieRequests(room *Room) error {
+	return room.Broadcast(&pb.Message{
+		Type: pb.MessageType_MOVIE_REQUESTS,
+		Sender: &pb.Sender{
+			Username: u.Username,
+			UserId:   u.ID,
+		},
+	})
+}
+
+func (u *User) RequestRoomMovie(room *Room, movie *model.MovieBase) (*model.MovieRequest, error) {
+	if u.IsGuest() {
+		return nil, model.ErrNoPermission
+	}
+
+	if movie == nil {
+		return nil, errors.New("movie is nil")
+	}
+
+	request := &model.MovieRequest{
+		MovieBase: *movie,
+		CreatorID: u.ID,
+	}
+
+	if err := room.AddMovieRequest(request); err != nil {
+		return nil, err
+	}
+
+	return request, u.broadcastMovieRequests(room)
+}
+
+func (u *User) VoteRoomMovieRequest(room *Room, id string) error {
+	if u.IsGuest() {
+		return model.ErrNoPermission
+	}
+
+	m, err := room.VoteMovieRequest(id, u.ID)
+	if err != nil {
+		return err
+	}
+
+	if m != nil {
+		if err := room.Broadcast(&pb.Message{
+			Type: pb.MessageType_MOVIES,
+			Sender: &pb.Sender{
+				Username: u.Username,
+				UserId:   u.ID,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return u.broadcastMovieRequests(room)
+}
+
+func (u *User) UnvoteRoomMovieRequest(room *Room, id string) error {
+	if u.IsGuest() {
+		return model.ErrNoPermission
+	}
+
+	if err := room.UnvoteMovieRequest(id, u.ID); err != nil {
+		return err
+	}
+
+	return u.broadcastMovieRequests(room)
+}
+
+func (u *User) PromoteRoomMovieRequest(room *Room, id string) (*model.Movie, error) {
+	if !u.HasRoomPermission(room, model.PermissionAddMovie) {
+		return nil, model.ErrNoPermission
+	}
+
+	m, err := room.PromoteMovieRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := room.Broadcast(&pb.Message{
+		Type: pb.MessageType_MOVIES,
+		Sender: &pb.Sender{
+			Username: u.Username,
+			UserId:   u.ID,
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return m, u.broadcastMovieRequests(room)
+}
+
+// DeleteRoomMovieRequest rejects a request, members can withdraw their own requests.
+func (u *User) DeleteRoomMovieRequest(room *Room, id string) error {
+	request, err := room.GetMovieRequest(id)
+	if err != nil {
+		return err
+	}
+
+	if request.CreatorID != u.ID && !u.HasRoomPermission(room, model.PermissionAddMovie) {
+		return model.ErrNoPermission
+	}
+
+	if err := room.DeleteMovieRequest(id); err != nil {
+		return err
+	}
+
+	return u.broadcastMovieRequests(room)
+}
+
+func (u *User) CreateRoomSchedule(room *Room, schedule *model.RoomSchedule) error {
+	if !u.HasRoomPermission(room, model.PermissionSetCurrentMovie) {
+		return model.ErrNoPermission
+	}
+
+	if !room.IsLeader(u.ID) {
+		return model.ErrNotLeader
+	}
+
+	schedule.CreatorID = u.ID
+
+	return room.AddSchedule(schedule)
+}
+
+// DeleteRoomSchedule cancels a schedule, only its creator and room admins can cancel it.
+func (u *User) DeleteRoomSchedule(room *Room, id string) error {
+	schedule, err := room.GetSchedule(id)
+	if err != nil {
+		return err
+	}
+
+	if schedule.CreatorID != u.ID && !u.IsAdmin() && !u.IsRoomAdmin(room) {
+		return model.ErrNoPermission
+	}
+
+	return room.DeleteSchedule(id)
+}
+
+func (u *User) SubscribeRoomSchedule(room *Room, id string) error {
+	if u.IsGuest() {
+		return model.ErrNoPermission
+	}
+
+	return room.SubscribeSchedule(id, u.ID)
+}
+
+func (u *User) UnsubscribeRoomSchedule(room *Room, id string) error {
+	return room.UnsubscribeSchedule(id, u.ID)
+}
+
+func (u *User) GetRoomLiveStats(room *Room) ([]*LiveStats, error) {
+	if !u.IsAdmin() && !u.IsRoomAdmin(room) {
+		return nil, model.ErrNoPermission
+	}
+
+	return room.LiveStats(), nil
+}
+
+// RevokeRoomPublishKey is allowed for the creator of the movie and room admins
+func (u *User) RevokeRoomPublishKey(room *Room, movieID string) error {
+	m, err := room.GetMovieByID(movieID)
+	if err != nil {
+		return err
+	}
+
+	if m.CreatorID != u.ID && !u.IsAdmin() && !u.IsRoomAdmin(room) {
+		return model.ErrNoPermission
+	}
+
+	return room.RevokePublishKey(movieID)
+}
+
+func (u *User) DeleteRoomRecording(room *Room, id string) error {
+	recording, err := room.GetRecording(id)
+	if err != nil {
+		return err
+	}
+
+	if recording.CreatorID != u.ID && !u.HasRoomPermission(room, model.PermissionDeleteMovie) {
+		return model.ErrNoPermission
+	}
+
+	if err := room.DeleteRecording(id); err != nil {
+		return err
+	}
+
+	return room.Broadcast(&pb.Message{
+		Type: pb.MessageType_MOVIES,
+	})
+}
+
 func (u *User) NewMovies(movies []*model.MovieBase) ([]*model.Movie, error) {
 	ms := make([]*model.Movie, len(movies))
 	for i, m := range movies {
@@ -257,7 +463,15 @@ func (u *User) DeleteRoom(room *RoomEntry) error {
 	if !u.HasRoomAdminPermission(room.Value(), model.PermissionDeleteRoom) {
 		return model.ErrNoPermission
 	}
-	return CompareAndDeleteRoom(room)
+
+	if err := CompareAndDeleteRoom(room); err != nil {
+		return err
+	}
+
+	r := room.Value()
+	u.RecordAudit(model.AuditRoomDelete, r.ID, r.ID, map[string]string{"name": r.Name}, nil)
+
+	return nil
 }
 
 func (u *User) SetRoomPassword(room *Room, password string) error {
@@ -275,7 +489,21 @@ func (u *User) SetRoomPassword(room *Room, password string) error {
 		}
 	}
 
-	return room.SetPassword(password)
+	before := room.NeedPassword()
+
+	if err := room.SetPassword(password); err != nil {
+		return err
+	}
+
+	u.RecordAudit(
+		model.AuditRoomPassword,
+		room.ID,
+		room.ID,
+		map[string]bool{"needPassword": before},
+		map[string]bool{"needPassword": password != ""},
+	)
+
+	return nil
 }
 
 func (u *User) SetUserRole() error {
@@ -288,6 +516,7 @@ func (u *User) SetUserRole() error {
 	}
 
 	u.Role = model.RoleUser
+	publishUserEvent(clusterEventUser, u.ID)
 
 	return nil
 }
@@ -302,6 +531,7 @@ func (u *User) SetAdminRole() error {
 	}
 
 	u.Role = model.RoleAdmin
+	publishUserEvent(clusterEventUser, u.ID)
 
 	return nil
 }
@@ -316,6 +546,7 @@ func (u *User) SetRootRole() error {
 	}
 
 	u.Role = model.RoleRoot
+	publishUserEvent(clusterEventUser, u.ID)
 
 	return nil
 }
@@ -330,6 +561,7 @@ func (u *User) Ban() error {
 	}
 
 	u.Role = model.RoleBanned
+	publishUserEvent(clusterEventUser, u.ID)
 
 	return nil
 }
@@ -340,6 +572,7 @@ func (u *User) Unban() error {
 	}
 
 	u.Role = model.RoleUser
+	publishUserEvent(clusterEventUser, u.ID)
 
 	return nil
 }
@@ -350,6 +583,7 @@ func (u *User) SetUsername(username string) error {
 	}
 
 	u.Username = username
+	publishUserEvent(clusterEventUser, u.ID)
 
 	return nil
 }
@@ -377,14 +611,30 @@ func (u *User) SetRoomSettings(room *Room, setting *model.RoomSettings) error {
 	if !u.HasRoomAdminPermission(room, model.PermissionSetRoomSettings) {
 		return model.ErrNoPermission
 	}
-	return room.SetSettings(setting)
+
+	before, err := room.setSettings(setting)
+	if err != nil {
+		return err
+	}
+
+	u.RecordAudit(model.AuditRoomSettings, room.ID, room.ID, before, setting)
+
+	return nil
 }
 
 func (u *User) UpdateRoomSettings(room *Room, settings map[string]any) error {
 	if !u.HasRoomAdminPermission(room, model.PermissionSetRoomSettings) {
 		return model.ErrNoPermission
 	}
-	return room.UpdateSettings(settings)
+
+	before, err := room.updateSettings(settings)
+	if err != nil {
+		return err
+	}
+
+	u.RecordAudit(model.AuditRoomSettings, room.ID, room.ID, before, settings)
+
+	return nil
 }
 
 func (u *User) DeleteRoomMovieByID(room *Room, movieID string) error {
@@ -487,11 +737,21 @@ func (u *User) SetRoomCurrentMovie(room *Room, movieID, subPath string, play boo
 		return model.ErrNoPermission
 	}
 
+	if !room.IsLeader(u.ID) {
+		return model.ErrNotLeader
+	}
+
 	err := room.SetCurrentMovie(movieID, subPath, play)
 	if err != nil {
 		return err
 	}
 
+	if room.Settings.LeaderMode == model.LeaderModeSetter {
+		if err := room.setCurrentLeader(u.ID); err != nil {
+			return err
+		}
+	}
+
 	return room.Broadcast(&pb.Message{
 		Type: pb.MessageType_CURRENT,
 		Sender: &pb.Sender{
@@ -525,6 +785,7 @@ func (u *User) BindEmail(e string) error {
 	}
 
 	u.Email = model.EmptyNullString(e)
+	publishUserEvent(clusterEventUser, u.ID)
 
 	return nil
 }
@@ -536,6 +797,7 @@ func (u *User) UnbindEmail() error {
 	}
 
 	u.Email = ""
+	publishUserEvent(clusterEventUser, u.ID)
 
 	return nil
 }
@@ -566,6 +828,7 @@ func (u *User) VerifyRetrievePasswordCaptchaEmail(e, captcha string) (bool, erro
 }
 
 func (u *User) GetRoomMoviesWithPage(
+	ctx context.Context,
 	room *Room,
 	keyword string,
 	page, pageSize int,
@@ -574,7 +837,7 @@ func (u *User) GetRoomMoviesWithPage(
 	if !u.HasRoomPermission(room, model.PermissionGetMovieList) {
 		return nil, 0, model.ErrNoPermission
 	}
-	return room.GetMoviesWithPage(keyword, page, pageSize, parentID)
+	return room.GetMoviesWithPage(ctx, keyword, page, pageSize, parentID)
 }
 
 func (u *User) SetRoomCurrentStatus(
@@ -585,9 +848,57 @@ func (u *User) SetRoomCurrentStatus(
 	if !u.HasRoomPermission(room, model.PermissionSetCurrentStatus) {
 		return nil, model.ErrNoPermission
 	}
+
+	if !room.IsLeader(u.ID) {
+		return nil, model.ErrNotLeader
+	}
+
 	return room.SetCurrentStatus(playing, seek, rate, timeDiff), nil
 }
 
+// ReportRoomCurrentDuration records the duration of the current movie, only
+// the clients that control playback are trusted with it.
+func (u *User) ReportRoomCurrentDuration(room *Room, movieID string, duration float64) error {
+	if !u.HasRoomPermission(room, model.PermissionSetCurrentStatus) {
+		return model.ErrNoPermission
+	}
+
+	if !room.IsLeader(u.ID) {
+		return model.ErrNotLeader
+	}
+
+	room.SetCurrentDuration(movieID, duration)
+
+	return nil
+}
+
+func (u *User) EndRoomCurrentMovie(room *Room, movieID string) error {
+	if !u.HasRoomPermission(room, model.PermissionSetCurrentStatus) {
+		return model.ErrNoPermission
+	}
+
+	if !room.IsLeader(u.ID) {
+		return model.ErrNotLeader
+	}
+
+	return room.EndCurrentMovie(movieID)
+}
+
+func (u *User) TransferRoomLeader(room *Room, userID string) error {
+	leader := room.Leader()
+	if leader != u.ID && !u.HasRoomAdminPermission(room, model.PermissionSetRoomSettings) {
+		return model.ErrNoPermission
+	}
+
+	if err := room.TransferLeader(userID); err != nil {
+		return err
+	}
+
+	u.RecordAudit(model.AuditRoomLeader, room.ID, userID, leader, userID)
+
+	return nil
+}
+
 func (u *User) BanRoomMember(room *Room, userID string) error {
 	if !u.HasRoomAdminPermission(room, model.PermissionBanRoomMember) {
 		return model.ErrNoPermission
@@ -601,7 +912,138 @@ func (u *User) BanRoomMember(room *Room, userID string) error {
 		return errors.New("cannot ban admin")
 	}
 
-	return room.BanMember(userID)
+	return u.auditMemberChange(room, model.AuditMemberBan, userID, func() error {
+		return room.BanMember(userID)
+	})
+}
+
+func (u *User) MuteRoomMember(room *Room, userID string, duration time.Duration) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionMuteRoomMember) {
+		return model.ErrNoPermission
+	}
+
+	if u.ID == userID {
+		return errors.New("cannot mute yourself")
+	}
+
+	if room.IsAdmin(userID) && !u.IsRoomCreator(room) {
+		return errors.New("cannot mute admin")
+	}
+
+	if duration <= 0 {
+		return errors.New("mute duration must be greater than 0")
+	}
+
+	if duration > model.MaxMuteDuration {
+		return errors.New("mute duration is too long")
+	}
+
+	err := u.auditMemberChange(room, model.AuditMemberMute, userID, func() error {
+		return room.MuteMember(userID, time.Now().Add(duration))
+	})
+	if err != nil {
+		return err
+	}
+
+	return room.SendToUserWithID(userID, &pb.Message{
+		Type: pb.MessageType_MY_STATUS,
+		Sender: &pb.Sender{
+			Username: u.Username,
+			UserId:   u.ID,
+		},
+	})
+}
+
+func (u *User) UnmuteRoomMember(room *Room, userID string) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionMuteRoomMember) {
+		return model.ErrNoPermission
+	}
+
+	if u.ID == userID {
+		return errors.New("cannot unmute yourself")
+	}
+
+	err := u.auditMemberChange(room, model.AuditMemberUnmute, userID, func() error {
+		return room.UnmuteMember(userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return room.SendToUserWithID(userID, &pb.Message{
+		Type: pb.MessageType_MY_STATUS,
+		Sender: &pb.Sender{
+			Username: u.Username,
+			UserId:   u.ID,
+		},
+	})
+}
+
+func (u *User) DeleteRoomChatMessage(room *Room, id string) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionDeleteChatMessage) {
+		return model.ErrNoPermission
+	}
+
+	err := room.DeleteChatMessage(id)
+	if err != nil {
+		return err
+	}
+
+	u.RecordAudit(model.AuditChatDelete, room.ID, id, nil, nil)
+
+	return room.Broadcast(&pb.Message{
+		Type:   pb.MessageType_CHAT_DELETE,
+		ChatId: id,
+		Sender: &pb.Sender{
+			Username: u.Username,
+			UserId:   u.ID,
+		},
+	})
+}
+
+func (u *User) PinRoomChatMessage(room *Room, id string) error {
+	return u.setRoomChatMessagePinned(room, id, true)
+}
+
+func (u *User) UnpinRoomChatMessage(room *Room, id string) error {
+	return u.setRoomChatMessagePinned(room, id, false)
+}
+
+func (u *User) setRoomChatMessagePinned(room *Room, id string, pinned bool) error {
+	if !u.HasRoomAdminPermission(room, model.PermissionPinChatMessage) {
+		return model.ErrNoPermission
+	}
+
+	m, err := room.SetChatMessagePinned(id, pinned)
+	if err != nil {
+		return err
+	}
+
+	t, action := pb.MessageType_CHAT_UNPIN, model.AuditChatUnpin
+	if pinned {
+		t, action = pb.MessageType_CHAT_PIN, model.AuditChatPin
+	}
+
+	u.RecordAudit(action, room.ID, id, nil, nil)
+
+	return room.Broadcast(NewChatPinMessage(t, m))
+}
+
+// NewChatPinMessage carries the pinned message itself, so clients that
+// never received the original chat line can still render it
+func NewChatPinMessage(t pb.MessageType, m *model.ChatMessage) *pb.Message {
+	return &pb.Message{
+		Type:      t,
+		Timestamp: m.CreatedAt.UnixMilli(),
+		ChatId:    m.ID,
+		Sender: &pb.Sender{
+			Username: m.Username,
+			UserId:   m.UserID,
+		},
+		Payload: &pb.Message_ChatContent{
+			ChatContent: m.Content,
+		},
+	}
 }
 
 func (u *User) UnbanRoomMember(room *Room, userID string) error {
@@ -613,14 +1055,19 @@ func (u *User) UnbanRoomMember(room *Room, userID string) error {
 		return errors.New("cannot unban yourself")
 	}
 
-	return room.UnbanMember(userID)
+	return u.auditMemberChange(room, model.AuditMemberUnban, userID, func() error {
+		return room.UnbanMember(userID)
+	})
 }
 
 func (u *User) DeleteRoomMember(room *Room, userID string) error {
 	if !u.HasRoomAdminPermission(room, model.PermissionApprovePendingMember) {
 		return model.ErrNoPermission
 	}
-	return room.DeleteMember(userID)
+
+	return u.auditMemberChange(room, model.AuditMemberDelete, userID, func() error {
+		return room.DeleteMember(userID)
+	})
 }
 
 func (u *User) SetMemberPermissions(
@@ -636,7 +1083,9 @@ func (u *User) SetMemberPermissions(
 		return errors.New("cannot set admin permissions")
 	}
 
-	err := room.SetMemberPermissions(userID, permissions)
+	err := u.auditMemberChange(room, model.AuditMemberPermission, userID, func() error {
+		return room.SetMemberPermissions(userID, permissions)
+	})
 	if err != nil {
 		return err
 	}
@@ -663,7 +1112,9 @@ func (u *User) AddMemberPermissions(
 		return errors.New("cannot add admin permissions")
 	}
 
-	err := room.AddMemberPermissions(userID, permissions)
+	err := u.auditMemberChange(room, model.AuditMemberPermission, userID, func() error {
+		return room.AddMemberPermissions(userID, permissions)
+	})
 	if err != nil {
 		return err
 	}
@@ -690,7 +1141,9 @@ func (u *User) RemoveMemberPermissions(
 		return errors.New("cannot remove admin permissions")
 	}
 
-	err := room.RemoveMemberPermissions(userID, permissions)
+	err := u.auditMemberChange(room, model.AuditMemberPermission, userID, func() error {
+		return room.RemoveMemberPermissions(userID, permissions)
+	})
 	if err != nil {
 		return err
 	}
@@ -713,7 +1166,9 @@ func (u *User) ResetMemberPermissions(room *Room, userID string) error {
 		return errors.New("cannot reset admin permissions")
 	}
 
-	err := room.ResetMemberPermissions(userID)
+	err := u.auditMemberChange(room, model.AuditMemberPermission, userID, func() error {
+		return room.ResetMemberPermissions(userID)
+	})
 	if err != nil {
 		return err
 	}
@@ -731,7 +1186,10 @@ func (u *User) ApproveRoomPendingMember(room *Room, userID string) error {
 	if !u.HasRoomAdminPermission(room, model.PermissionApprovePendingMember) {
 		return model.ErrNoPermission
 	}
-	return room.ApprovePendingMember(userID)
+
+	return u.auditMemberChange(room, model.AuditMemberApprove, userID, func() error {
+		return room.ApprovePendingMember(userID)
+	})
 }
 
 func (u *User) SetRoomAdmin(
@@ -743,7 +1201,9 @@ func (u *User) SetRoomAdmin(
 		return model.ErrNoPermission
 	}
 
-	err := room.SetAdmin(userID, permissions)
+	err := u.auditMemberChange(room, model.AuditMemberAdmin, userID, func() error {
+		return room.SetAdmin(userID, permissions)
+	})
 	if err != nil {
 		return err
 	}
@@ -766,7 +1226,9 @@ func (u *User) SetRoomMember(
 		return model.ErrNoPermission
 	}
 
-	err := room.SetMember(userID, permissions)
+	err := u.auditMemberChange(room, model.AuditMemberAdmin, userID, func() error {
+		return room.SetMember(userID, permissions)
+	})
 	if err != nil {
 		return err
 	}
@@ -789,7 +1251,9 @@ func (u *User) SetRoomAdminPermissions(
 		return model.ErrNoPermission
 	}
 
-	err := room.SetAdminPermissions(userID, permissions)
+	err := u.auditMemberChange(room, model.AuditMemberAdmin, userID, func() error {
+		return room.SetAdminPermissions(userID, permissions)
+	})
 	if err != nil {
 		return err
 	}
@@ -812,7 +1276,9 @@ func (u *User) AddRoomAdminPermissions(
 		return model.ErrNoPermission
 	}
 
-	err := room.AddAdminPermissions(userID, permissions)
+	err := u.auditMemberChange(room, model.AuditMemberAdmin, userID, func() error {
+		return room.AddAdminPermissions(userID, permissions)
+	})
 	if err != nil {
 		return err
 	}
@@ -835,7 +1301,9 @@ func (u *User) RemoveRoomAdminPermissions(
 		return model.ErrNoPermission
 	}
 
-	err := room.RemoveAdminPermissions(userID, permissions)
+	err := u.auditMemberChange(room, model.AuditMemberAdmin, userID, func() error {
+		return room.RemoveAdminPermissions(userID, permissions)
+	})
 	if err != nil {
 		return err
 	}
@@ -854,7 +1322,9 @@ func (u *User) ResetRoomAdminPermissions(room *Room, userID string) error {
 		return model.ErrNoPermission
 	}
 
-	err := room.ResetAdminPermissions(userID)
+	err := u.auditMemberChange(room, model.AuditMemberAdmin, userID, func() error {
+		return room.ResetAdminPermissions(userID)
+	})
 	if err != nil {
 		return err
 	}
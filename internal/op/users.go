@@ -123,6 +123,8 @@ func CompareAndDeleteUser(user *UserEntry) error {
 		return err
 	}
 
+	publishUserEvent(clusterEventUserClosed, id)
+
 	return CompareAndCloseUser(user)
 }
 
@@ -136,6 +138,8 @@ func DeleteUserByID(id string) error {
 		return err
 	}
 
+	publishUserEvent(clusterEventUserClosed, id)
+
 	return CloseUserByID(id)
 }
 
@@ -0,0 +1,495 @@
+package op
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+var (
+	ErrWebAuthnSessionExpired = errors.New("passkey session expired, try again")
+	ErrWebAuthnClonedKey      = errors.New("passkey counter went backwards, it may be cloned")
+)
+
+const webauthnTimeout = time.Minute * 5
+
+// NewWebAuthn returns the relying party of host, e.g. https://example.com,
+// the rp id is the hostname of it
+func NewWebAuthn(host string) (*webauthn.WebAuthn, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid host: %s", host)
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          u.Hostname(),
+		RPDisplayName: appName,
+		RPOrigins:     []string{u.Scheme + "://" + u.Host},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: webauthnTimeout,
+			},
+			Registration: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: webauthnTimeout,
+			},
+		},
+	})
+}
+
+// webauthnUser is the user handle of a passkey, which is the user id
+type webauthnUser struct {
+	user        *User
+	credentials []*model.WebAuthnCredential
+}
+
+func (u *webauthnUser) WebAuthnID() []byte {
+	return []byte(u.user.ID)
+}
+
+func (u *webauthnUser) WebAuthnName() string {
+	return u.user.Username
+}
+
+func (u *webauthnUser) WebAuthnDisplayName() string {
+	return u.user.Username
+}
+
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		id, err := base64.RawURLEncoding.DecodeString(c.CredentialID)
+		if err != nil {
+			continue
+		}
+
+		var transports []protocol.AuthenticatorTransport
+		if c.Transports != "" {
+			for _, t := range strings.Split(c.Transports, ",") {
+				transports = append(transports, protocol.AuthenticatorTransport(t))
+			}
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(c.Flags)),
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		})
+	}
+
+	return credentials
+}
+
+func (u *webauthnUser) credential(id []byte) *model.WebAuthnCredential {
+	encoded := base64.RawURLEncoding.EncodeToString(id)
+	for _, c := range u.credentials {
+		if c.CredentialID == encoded {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (u *User) newWebAuthnUser() (*webauthnUser, error) {
+	credentials, err := db.GetWebAuthnCredentialsByUserID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webauthnUser{user: u, credentials: credentials}, nil
+}
+
+func credentialFlags(f webauthn.CredentialFlags) uint8 {
+	var flags protocol.AuthenticatorFlags
+	if f.UserPresent {
+		flags |= protocol.FlagUserPresent
+	}
+
+	if f.UserVerified {
+		flags |= protocol.FlagUserVerified
+	}
+
+	if f.BackupEligible {
+		flags |= protocol.FlagBackupEligible
+	}
+
+	if f.BackupState {
+		flags |= protocol.FlagBackupState
+	}
+
+	return uint8(flags)
+}
+
+// updateWebAuthnCredential stores the counter of a verified assertion
+func (u *webauthnUser) updateWebAuthnCredential(c *webauthn.Credential) error {
+	if c.Authenticator.CloneWarning {
+		return ErrWebAuthnClonedKey
+	}
+
+	stored := u.credential(c.ID)
+	if stored == nil {
+		return errors.New("passkey not found")
+	}
+
+	return db.UpdateWebAuthnCredentialUsed(
+		stored.ID,
+		c.Authenticator.SignCount,
+		credentialFlags(c.Flags),
+	)
+}
+
+func newWebAuthnSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// saveWebAuthnSession stores the session data of a ceremony, userID is empty
+// for a login, the user is found by the passkey
+func saveWebAuthnSession(
+	kind model.AuthSessionKind,
+	id, userID string,
+	data *webauthn.SessionData,
+) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return db.SaveAuthSession(&model.AuthSession{
+		ID:        id,
+		Kind:      kind,
+		UserID:    userID,
+		Data:      b,
+		ExpiresAt: time.Now().Add(webauthnTimeout),
+	})
+}
+
+func storeWebAuthnSession(userID string, data *webauthn.SessionData) (string, error) {
+	id, err := newWebAuthnSessionID()
+	if err != nil {
+		return "", err
+	}
+
+	return id, saveWebAuthnSession(model.AuthSessionWebAuthn, id, userID, data)
+}
+
+// takeWebAuthnSession returns the session once, it must belong to userID
+func takeWebAuthnSession(
+	kind model.AuthSessionKind,
+	id, userID string,
+) (*webauthn.SessionData, error) {
+	s, err := db.TakeAuthSession(kind, id)
+	if err != nil {
+		if isAuthSessionNotFound(err) {
+			return nil, ErrWebAuthnSessionExpired
+		}
+
+		return nil, err
+	}
+
+	if s.UserID != userID {
+		return nil, ErrWebAuthnSessionExpired
+	}
+
+	data := new(webauthn.SessionData)
+	if err := json.Unmarshal(s.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (u *User) HasWebAuthnCredentials() (bool, error) {
+	n, err := db.CountWebAuthnCredentialsByUserID(u.ID)
+	return n > 0, err
+}
+
+func (u *User) ListWebAuthnCredentials() ([]*model.WebAuthnCredential, error) {
+	return db.GetWebAuthnCredentialsByUserID(u.ID)
+}
+
+// BeginWebAuthnRegistration returns the options of navigator.credentials.create
+// and the session to finish it with
+func (u *User) BeginWebAuthnRegistration(
+	w *webauthn.WebAuthn,
+) (*protocol.CredentialCreation, string, error) {
+	if u.IsGuest() {
+		return nil, "", errors.New("guest cannot add a passkey")
+	}
+
+	wu, err := u.newWebAuthnUser()
+	if err != nil {
+		return nil, "", err
+	}
+
+	creation, data, err := w.BeginRegistration(
+		wu,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(
+			webauthn.Credentials(wu.WebAuthnCredentials()).CredentialDescriptors(),
+		),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	session, err := storeWebAuthnSession(u.ID, data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return creation, session, nil
+}
+
+func (u *User) FinishWebAuthnRegistration(
+	w *webauthn.WebAuthn,
+	session, name string,
+	body io.Reader,
+) (*model.WebAuthnCredential, error) {
+	data, err := takeWebAuthnSession(model.AuthSessionWebAuthn, session, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	wu, err := u.newWebAuthnUser()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := w.CreateCredential(wu, *data, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	transports := make([]string, len(c.Transport))
+	for i, t := range c.Transport {
+		transports[i] = string(t)
+	}
+
+	credential := &model.WebAuthnCredential{
+		UserID:          u.ID,
+		Name:            name,
+		CredentialID:    base64.RawURLEncoding.EncodeToString(c.ID),
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          c.Authenticator.AAGUID,
+		SignCount:       c.Authenticator.SignCount,
+		Flags:           credentialFlags(c.Flags),
+		LastUsedAt:      time.Now(),
+	}
+	if len(credential.CredentialID) > 512 {
+		return nil, errors.New("passkey id is too long")
+	}
+
+	if err := db.CreateWebAuthnCredential(credential); err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+func (u *User) RenameWebAuthnCredential(id, name string) error {
+	return db.RenameWebAuthnCredential(u.ID, id, name)
+}
+
+// DeleteWebAuthnCredential keeps the last passkey of a user who needs 2fa
+// and has no totp
+func (u *User) DeleteWebAuthnCredential(id string) error {
+	if u.TotpRequired() && !u.TotpEnabled() {
+		n, err := db.CountWebAuthnCredentialsByUserID(u.ID)
+		if err != nil {
+			return err
+		}
+
+		if n <= 1 {
+			return ErrTotpRequired
+		}
+	}
+
+	return db.DeleteWebAuthnCredential(u.ID, id)
+}
+
+// BeginWebAuthnLogin starts a passwordless login, any passkey of any user can
+// answer it
+func BeginWebAuthnLogin(w *webauthn.WebAuthn) (*protocol.CredentialAssertion, string, error) {
+	assertion, data, err := w.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	session, err := storeWebAuthnSession("", data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return assertion, session, nil
+}
+
+// FinishWebAuthnLogin returns the user of the passkey, the passkey is
+// verified by the user so no second factor is asked
+func FinishWebAuthnLogin(w *webauthn.WebAuthn, session string, body io.Reader) (*UserEntry, error) {
+	data, err := takeWebAuthnSession(model.AuthSessionWebAuthn, session, "")
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		userE *UserEntry
+		wu    *webauthnUser
+	)
+
+	_, c, err := w.ValidatePasskeyLogin(
+		func(_, userHandle []byte) (webauthn.User, error) {
+			var err error
+
+			userE, err = LoadOrInitUserByID(string(userHandle))
+			if err != nil {
+				return nil, err
+			}
+
+			wu, err = userE.Value().newWebAuthnUser()
+
+			return wu, err
+		},
+		*data,
+		parsed,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wu.updateWebAuthnCredential(c); err != nil {
+		return nil, err
+	}
+
+	return userE, nil
+}
+
+// BeginTwoFactorWebAuthn answers a challenge of NewTwoFactorChallenge with a
+// passkey instead of a code
+func BeginTwoFactorWebAuthn(
+	w *webauthn.WebAuthn,
+	challenge string,
+) (*protocol.CredentialAssertion, error) {
+	s, err := db.GetAuthSession(model.AuthSessionTwoFactor, challenge)
+	if err != nil {
+		if isAuthSessionNotFound(err) {
+			return nil, ErrTwoFactorChallengeExpired
+		}
+
+		return nil, err
+	}
+
+	userE, err := LoadOrInitUserByID(s.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	wu, err := userE.Value().newWebAuthnUser()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wu.credentials) == 0 {
+		return nil, errors.New("user has no passkey")
+	}
+
+	assertion, data, err := w.BeginLogin(wu)
+	if err != nil {
+		return nil, err
+	}
+
+	err = saveWebAuthnSession(model.AuthSessionTwoFactorWebAuthn, challenge, s.UserID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return assertion, nil
+}
+
+func VerifyTwoFactorChallengeWebAuthn(
+	w *webauthn.WebAuthn,
+	challenge string,
+	body io.Reader,
+) (*UserEntry, error) {
+	userID, err := attemptTwoFactorChallenge(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := takeWebAuthnSession(model.AuthSessionTwoFactorWebAuthn, challenge, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	userE, err := LoadOrInitUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	wu, err := userE.Value().newWebAuthnUser()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := w.ValidateLogin(wu, *data, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wu.updateWebAuthnCredential(c); err != nil {
+		return nil, err
+	}
+
+	if err := finishTwoFactorChallenge(challenge); err != nil {
+		return nil, err
+	}
+
+	return userE, nil
+}
@@ -0,0 +1,102 @@
+package op
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/settings"
+)
+
+func requireAdmin2FA(t *testing.T) {
+	t.Helper()
+
+	err := db.FirstOrCreateSettingItemValue(&model.Setting{
+		Name:  settings.RequireAdmin2FA.Name(),
+		Type:  model.SettingTypeBool,
+		Group: model.SettingGroupUser,
+		Value: "0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := settings.RequireAdmin2FA.Set(true); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = settings.RequireAdmin2FA.Set(false) })
+}
+
+func TestPasskeySecondFactor(t *testing.T) {
+	initTestDB(t)
+	requireAdmin2FA(t)
+
+	userE, err := CreateUser("admin", "password", db.WithRole(model.RoleAdmin))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := userE.Value()
+
+	if err := u.CheckTotpRequired(); !errors.Is(err, ErrTotpRequired) {
+		t.Fatalf("admin without 2fa: %v", err)
+	}
+
+	c := &model.WebAuthnCredential{
+		UserID:       u.ID,
+		Name:         "key",
+		CredentialID: "credential",
+		PublicKey:    []byte{1},
+	}
+	if err := db.CreateWebAuthnCredential(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.CheckTotpRequired(); err != nil {
+		t.Fatalf("passkey not accepted as the required 2fa: %v", err)
+	}
+
+	enabled, err := u.TwoFactorEnabled()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !enabled {
+		t.Fatal("login with a passkey does not need a second factor")
+	}
+
+	if err := u.DeleteWebAuthnCredential(c.ID); !errors.Is(err, ErrTotpRequired) {
+		t.Fatalf("last passkey of a required 2fa deleted: %v", err)
+	}
+}
+
+func TestPasskeyOptionalSecondFactor(t *testing.T) {
+	initTestDB(t)
+
+	userE, err := CreateUser("user", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := userE.Value()
+
+	if err := db.CreateWebAuthnCredential(&model.WebAuthnCredential{
+		UserID:       u.ID,
+		Name:         "key",
+		CredentialID: "credential",
+		PublicKey:    []byte{1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	enabled, err := u.TwoFactorEnabled()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if enabled {
+		t.Fatal("a passkey made the password login of a user need a second factor")
+	}
+}
@@ -31,3 +31,9 @@ type Interface interface {
 	NewAuthURL(ctx context.Context, state string) (string, error)
 	GetUserInfo(ctx context.Context, code string) (*UserInfo, error)
 }
+
+// StateInterface is implemented by providers that bind data such as a nonce
+// to the state of an auth url, the callback hands the state back to them
+type StateInterface interface {
+	GetUserInfoWithState(ctx context.Context, state, code string) (*UserInfo, error)
+}
@@ -0,0 +1,318 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/synctv-org/synctv/internal/provider"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/gencontainer/synccache"
+	"golang.org/x/oauth2"
+)
+
+// an auth url must be completed within this time, like the oauth2 state
+const oidcSessionTTL = 5 * time.Minute
+
+var (
+	oidcNameReg = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+	errOidcSessionNotFound = errors.New("oidc auth request not found or expired")
+)
+
+// oidcSession is bound to the state of an auth url
+type oidcSession struct {
+	nonce    string
+	verifier string
+}
+
+// oidcProvider is a generic openid connect provider, the endpoints and keys
+// are discovered from the issuer and the id token is verified against them
+type oidcProvider struct {
+	discovered    *oidc.Provider
+	sessions      *synccache.SyncCache[string, oidcSession]
+	name          string
+	issuer        string
+	idClaim       string
+	usernameClaim string
+	scopes        []string
+	opt           provider.Oauth2Option
+	mu            sync.RWMutex
+}
+
+// RegisterOidcProvider adds an oidc provider named name, the issuer is the
+// default of its issuer setting
+func RegisterOidcProvider(name, issuer string) error {
+	if !oidcNameReg.MatchString(name) {
+		return fmt.Errorf("invalid oidc provider name: %s", name)
+	}
+
+	if _, ok := allProviders.Load(name); ok {
+		return fmt.Errorf("oidc provider name %s is already used", name)
+	}
+
+	RegisterProvider(newOidcProvider(name, issuer))
+
+	return nil
+}
+
+func newOidcProvider(name, issuer string) *oidcProvider {
+	return &oidcProvider{
+		name:          name,
+		issuer:        issuer,
+		idClaim:       "sub",
+		usernameClaim: "preferred_username",
+		scopes:        []string{"profile", "email"},
+		sessions:      synccache.NewSyncCache[string, oidcSession](time.Minute),
+	}
+}
+
+func (p *oidcProvider) Init(opt provider.Oauth2Option) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.opt = opt
+}
+
+func (p *oidcProvider) Provider() provider.OAuth2Provider {
+	return p.name
+}
+
+// discover loads the openid configuration of the issuer once, it is
+// loaded again after the issuer changes
+func (p *oidcProvider) discover(ctx context.Context) (*oidc.Provider, oauth2.Config, error) {
+	p.mu.RLock()
+	discovered, issuer := p.discovered, p.issuer
+	p.mu.RUnlock()
+
+	if issuer == "" {
+		return nil, oauth2.Config{}, fmt.Errorf("%s: issuer is not set", p.name)
+	}
+
+	if discovered == nil {
+		var err error
+
+		discovered, err = oidc.NewProvider(ctx, issuer)
+		if err != nil {
+			return nil, oauth2.Config{}, fmt.Errorf("%s: discovery failed: %w", p.name, err)
+		}
+
+		p.mu.Lock()
+		if p.issuer == issuer {
+			p.discovered = discovered
+		}
+		p.mu.Unlock()
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return discovered, oauth2.Config{
+		ClientID:     p.opt.ClientID,
+		ClientSecret: p.opt.ClientSecret,
+		RedirectURL:  p.opt.RedirectURL,
+		Endpoint:     discovered.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, p.scopes...),
+	}, nil
+}
+
+// NewAuthURL asks for a code bound to a nonce and an s256 pkce challenge,
+// both are kept with the state until the callback
+func (p *oidcProvider) NewAuthURL(ctx context.Context, state string) (string, error) {
+	_, config, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	session := oidcSession{
+		nonce:    utils.RandString(32),
+		verifier: oauth2.GenerateVerifier(),
+	}
+	p.sessions.Store(state, session, oidcSessionTTL)
+
+	return config.AuthCodeURL(
+		state,
+		oauth2.AccessTypeOnline,
+		oidc.Nonce(session.nonce),
+		oauth2.S256ChallengeOption(session.verifier),
+	), nil
+}
+
+func (p *oidcProvider) GetUserInfo(context.Context, string) (*provider.UserInfo, error) {
+	return nil, errOidcSessionNotFound
+}
+
+func (p *oidcProvider) GetUserInfoWithState(
+	ctx context.Context,
+	state, code string,
+) (*provider.UserInfo, error) {
+	entry, ok := p.sessions.LoadAndDelete(state)
+	if !ok {
+		return nil, errOidcSessionNotFound
+	}
+
+	session := entry.Value()
+
+	discovered, config, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tk, err := config.Exchange(ctx, code, oauth2.VerifierOption(session.verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := tk.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("no id_token in token response")
+	}
+
+	idToken, err := discovered.Verifier(&oidc.Config{ClientID: config.ClientID}).
+		Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if idToken.Nonce != session.nonce {
+		return nil, errors.New("id token nonce does not match the auth request")
+	}
+
+	claims := make(map[string]any)
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	idClaim, usernameClaim := p.idClaim, p.usernameClaim
+	p.mu.RUnlock()
+
+	// some idps only put the profile claims in the userinfo response
+	if _, ok := claims[usernameClaim].(string); !ok && discovered.UserInfoEndpoint() != "" {
+		ui, err := discovered.UserInfo(ctx, oauth2.StaticTokenSource(tk))
+		if err != nil {
+			return nil, err
+		}
+
+		// the userinfo response may belong to another user, see 5.3.2 of
+		// openid connect core
+		if ui.Subject != idToken.Subject {
+			return nil, errors.New("userinfo subject does not match the id token")
+		}
+
+		if err := ui.Claims(&claims); err != nil {
+			return nil, err
+		}
+	}
+
+	id, _ := claims[idClaim].(string)
+	if id == "" {
+		return nil, fmt.Errorf("claim %s is missing", idClaim)
+	}
+
+	username, _ := claims[usernameClaim].(string)
+	if username == "" {
+		username, _ = claims["name"].(string)
+	}
+
+	return &provider.UserInfo{
+		ProviderUserID: oidcUserID(idToken.Issuer, id),
+		Username:       username,
+	}, nil
+}
+
+// oidcUserID keys a binding on the issuer and the subject, the same subject
+// of another issuer is another user. The hash fits the provider user id
+// column whatever the length of the issuer.
+func oidcUserID(issuer, id string) string {
+	sum := sha256.Sum256([]byte(issuer + "\x00" + id))
+	return hex.EncodeToString(sum[:])
+}
+
+func (p *oidcProvider) RegistSetting(group string) {
+	setIssuer := func(_ settings.StringSetting, s string) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.issuer = s
+		p.discovered = nil
+	}
+	settings.NewStringSetting(
+		group+"_issuer", p.issuer, group,
+		settings.WithAfterInitString(setIssuer),
+		settings.WithBeforeSetString(func(_ settings.StringSetting, s string) (string, error) {
+			// the issuer must match the discovery document exactly, keep any
+			// trailing slash
+			if s == "" {
+				return s, nil
+			}
+
+			u, err := url.Parse(s)
+			if err != nil {
+				return "", err
+			}
+
+			if u.Scheme != "https" && u.Scheme != "http" {
+				return "", fmt.Errorf("invalid issuer: %s", s)
+			}
+
+			return s, nil
+		}),
+		settings.WithAfterSetString(setIssuer),
+	)
+
+	setScopes := func(_ settings.StringSetting, s string) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.scopes = strings.Fields(strings.ReplaceAll(s, ",", " "))
+	}
+	settings.NewStringSetting(
+		group+"_scopes", strings.Join(p.scopes, " "), group,
+		settings.WithAfterInitString(setScopes),
+		settings.WithAfterSetString(setScopes),
+	)
+
+	setIDClaim := func(_ settings.StringSetting, s string) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.idClaim = s
+	}
+	settings.NewStringSetting(
+		group+"_id_claim", p.idClaim, group,
+		settings.WithAfterInitString(setIDClaim),
+		settings.WithBeforeSetString(notEmptyClaim),
+		settings.WithAfterSetString(setIDClaim),
+	)
+
+	setUsernameClaim := func(_ settings.StringSetting, s string) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.usernameClaim = s
+	}
+	settings.NewStringSetting(
+		group+"_username_claim", p.usernameClaim, group,
+		settings.WithAfterInitString(setUsernameClaim),
+		settings.WithBeforeSetString(notEmptyClaim),
+		settings.WithAfterSetString(setUsernameClaim),
+	)
+}
+
+func notEmptyClaim(_ settings.StringSetting, s string) (string, error) {
+	if s == "" {
+		return "", errors.New("claim cannot be empty")
+	}
+
+	return s, nil
+}
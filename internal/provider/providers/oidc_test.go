@@ -0,0 +1,220 @@
+package providers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/synctv-org/synctv/internal/provider"
+)
+
+// fakeIdP answers a single authorization code, the auth request is read from
+// the auth url
+type fakeIdP struct {
+	srv       *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+	// overrides of the id token nonce and the userinfo subject
+	tokenNonce  string
+	userinfoSub string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &fakeIdP{key: key, userinfoSub: "alice"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/.well-known/openid-configuration",
+		func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, map[string]any{
+				"issuer":                                idp.srv.URL,
+				"authorization_endpoint":                idp.srv.URL + "/auth",
+				"token_endpoint":                        idp.srv.URL + "/token",
+				"userinfo_endpoint":                     idp.srv.URL + "/userinfo",
+				"jwks_uri":                              idp.srv.URL + "/jwks",
+				"id_token_signing_alg_values_supported": []string{"RS256"},
+			})
+		},
+	)
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(
+				big.NewInt(int64(key.E)).Bytes(),
+			),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if r.PostFormValue("code") != "code" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+
+			return
+		}
+
+		nonce := idp.nonce
+		if idp.tokenNonce != "" {
+			nonce = idp.tokenNonce
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   idp.srv.URL,
+			"sub":   "alice",
+			"aud":   "client",
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": nonce,
+		})
+		token.Header["kid"] = "test"
+
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     idToken,
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]string{
+			"sub":                idp.userinfoSub,
+			"preferred_username": "alice",
+		})
+	})
+
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+
+	return idp
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// authorize starts an auth request and records it as the idp would
+func (idp *fakeIdP) authorize(t *testing.T, p *oidcProvider, state string) {
+	t.Helper()
+
+	authURL, err := p.NewAuthURL(context.Background(), state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("code challenge method %q", q.Get("code_challenge_method"))
+	}
+
+	if q.Get("nonce") == "" {
+		t.Fatal("auth url has no nonce")
+	}
+
+	idp.challenge, idp.nonce = q.Get("code_challenge"), q.Get("nonce")
+}
+
+func newTestOidcProvider(idp *fakeIdP) *oidcProvider {
+	p := newOidcProvider("test", idp.srv.URL)
+	p.Init(provider.Oauth2Option{ClientID: "client", ClientSecret: "secret"})
+
+	return p
+}
+
+func TestOidcGetUserInfo(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newTestOidcProvider(idp)
+
+	idp.authorize(t, p, "state")
+
+	ui, err := p.GetUserInfoWithState(context.Background(), "state", "code")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ui.Username != "alice" || ui.ProviderUserID != oidcUserID(idp.srv.URL, "alice") {
+		t.Fatalf("unexpected user info %+v", ui)
+	}
+
+	if _, err := p.GetUserInfoWithState(context.Background(), "state", "code"); err == nil {
+		t.Fatal("auth request was used twice")
+	}
+}
+
+func TestOidcGetUserInfoRejected(t *testing.T) {
+	for _, tc := range []struct {
+		setup func(idp *fakeIdP)
+		name  string
+		state string
+	}{
+		{name: "unknown state", state: "other"},
+		{name: "nonce mismatch", setup: func(idp *fakeIdP) { idp.tokenNonce = "replayed" }},
+		{name: "userinfo subject mismatch", setup: func(idp *fakeIdP) { idp.userinfoSub = "mallory" }},
+		{name: "pkce verifier mismatch", setup: func(idp *fakeIdP) { idp.challenge = "other" }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idp := newFakeIdP(t)
+			p := newTestOidcProvider(idp)
+
+			idp.authorize(t, p, "state")
+
+			if tc.setup != nil {
+				tc.setup(idp)
+			}
+
+			state := "state"
+			if tc.state != "" {
+				state = tc.state
+			}
+
+			if _, err := p.GetUserInfoWithState(context.Background(), state, "code"); err == nil {
+				t.Fatal("user info accepted")
+			}
+		})
+	}
+}
+
+func TestOidcUserIDIssuer(t *testing.T) {
+	a := oidcUserID("https://a.example.com", "alice")
+	if a == oidcUserID("https://b.example.com", "alice") {
+		t.Fatal("same subject of different issuers share an id")
+	}
+
+	if len(a) > 64 {
+		t.Fatalf("id of %d characters does not fit the provider user id", len(a))
+	}
+}
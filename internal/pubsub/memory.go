@@ -0,0 +1,108 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+var _ PubSub = (*Memory)(nil)
+
+// Memory is an in-process PubSub, every subscriber receives every message
+// in publish order.
+type Memory struct {
+	subs   map[*memorySubscriber]struct{}
+	lock   sync.RWMutex
+	closed bool
+}
+
+type memorySubscriber struct {
+	c       chan []byte
+	exit    chan struct{}
+	handler func([]byte)
+	once    sync.Once
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		subs: make(map[*memorySubscriber]struct{}),
+	}
+}
+
+func (m *Memory) Publish(ctx context.Context, data []byte) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	for s := range m.subs {
+		select {
+		case s.c <- data:
+		case <-s.exit:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) Subscribe(handler func(data []byte)) (func(), error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.closed {
+		return nil, ErrClosed
+	}
+
+	s := &memorySubscriber{
+		c:       make(chan []byte, 128),
+		exit:    make(chan struct{}),
+		handler: handler,
+	}
+	m.subs[s] = struct{}{}
+
+	go s.serve()
+
+	return func() {
+		m.lock.Lock()
+		delete(m.subs, s)
+		m.lock.Unlock()
+		s.close()
+	}, nil
+}
+
+func (m *Memory) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	m.closed = true
+	for s := range m.subs {
+		delete(m.subs, s)
+		s.close()
+	}
+
+	return nil
+}
+
+func (s *memorySubscriber) serve() {
+	for {
+		select {
+		case data := <-s.c:
+			s.handler(data)
+		case <-s.exit:
+			return
+		}
+	}
+}
+
+func (s *memorySubscriber) close() {
+	s.once.Do(func() {
+		close(s.exit)
+	})
+}
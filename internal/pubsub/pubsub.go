@@ -0,0 +1,16 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrClosed = errors.New("pubsub closed")
+
+// PubSub fans messages out to every subscriber of the same backend,
+// which is how several synctv nodes share room events.
+type PubSub interface {
+	Publish(ctx context.Context, data []byte) error
+	Subscribe(handler func(data []byte)) (unsubscribe func(), err error)
+	Close() error
+}
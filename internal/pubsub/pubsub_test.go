@@ -0,0 +1,64 @@
+package pubsub_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/synctv-org/synctv/internal/pubsub"
+)
+
+func testFanOut(t *testing.T, nodeA, nodeB pubsub.PubSub) {
+	t.Helper()
+
+	recvA := make(chan string, 4)
+	recvB := make(chan string, 4)
+
+	unsubA, err := nodeA.Subscribe(func(data []byte) { recvA <- string(data) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubA()
+
+	unsubB, err := nodeB.Subscribe(func(data []byte) { recvB <- string(data) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubB()
+
+	if err := nodeA.Publish(context.Background(), []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []chan string{recvA, recvB} {
+		select {
+		case got := <-c:
+			if got != "hello" {
+				t.Fatalf("got %q, want %q", got, "hello")
+			}
+		case <-time.After(time.Second * 3):
+			t.Fatal("timeout waiting for message")
+		}
+	}
+}
+
+func TestMemory(t *testing.T) {
+	m := pubsub.NewMemory()
+	defer m.Close()
+
+	testFanOut(t, m, m)
+}
+
+func TestRedis(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	nodeA := pubsub.NewRedis(redis.NewClient(&redis.Options{Addr: s.Addr()}), "synctv")
+	defer nodeA.Close()
+
+	nodeB := pubsub.NewRedis(redis.NewClient(&redis.Options{Addr: s.Addr()}), "synctv")
+	defer nodeB.Close()
+
+	testFanOut(t, nodeA, nodeB)
+}
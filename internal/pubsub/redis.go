@@ -0,0 +1,83 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ PubSub = (*Redis)(nil)
+
+// Redis is a PubSub backed by a single redis pub/sub channel.
+type Redis struct {
+	client  redis.UniversalClient
+	channel string
+	subs    map[*redis.PubSub]struct{}
+	lock    sync.Mutex
+	closed  bool
+}
+
+func NewRedis(client redis.UniversalClient, channel string) *Redis {
+	return &Redis{
+		client:  client,
+		channel: channel,
+		subs:    make(map[*redis.PubSub]struct{}),
+	}
+}
+
+func (r *Redis) Publish(ctx context.Context, data []byte) error {
+	return r.client.Publish(ctx, r.channel, data).Err()
+}
+
+func (r *Redis) Subscribe(handler func(data []byte)) (func(), error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	ctx := context.Background()
+	ps := r.client.Subscribe(ctx, r.channel)
+
+	// wait for the subscription to be confirmed, so that messages published
+	// after Subscribe returns are never missed
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+
+	r.subs[ps] = struct{}{}
+
+	go func() {
+		for msg := range ps.Channel() {
+			handler([]byte(msg.Payload))
+		}
+	}()
+
+	return func() {
+		r.lock.Lock()
+		delete(r.subs, ps)
+		r.lock.Unlock()
+
+		_ = ps.Close()
+	}, nil
+}
+
+func (r *Redis) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+
+	r.closed = true
+	for ps := range r.subs {
+		delete(r.subs, ps)
+		_ = ps.Close()
+	}
+
+	return r.client.Close()
+}
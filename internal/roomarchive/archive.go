@@ -0,0 +1,123 @@
+// Package roomarchive moves a room between instances as a zip archive
+// holding the room, its settings, its movie tree and its members.
+//
+// Users are only matched by username on import when asked to, a username
+// may belong to someone else on the target instance. Members that are not
+// matched are skipped and movie creators fall back to the new owner.
+// Vendor movies keep their vendor info, but they only play once the new
+// creator has bound the same vendor account.
+package roomarchive
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+// Version is bumped whenever the archive layout changes incompatibly
+const Version = 1
+
+const roomFile = "room.json"
+
+// maxRoomFileSize bounds the decompressed room file, zip fails reads past
+// the declared size so checking the header is enough
+const maxRoomFileSize = 32 << 20
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported room archive version")
+	ErrArchiveTooLarge    = errors.New("room archive is too large")
+)
+
+type Archive struct {
+	Settings   *model.RoomSettings `json:"settings"`
+	Room       Room                `json:"room"`
+	Movies     []*Movie            `json:"movies"`
+	Members    []*Member           `json:"members"`
+	Version    int                 `json:"version"`
+	ExportedAt int64               `json:"exportedAt"`
+}
+
+type Room struct {
+	Name           string           `json:"name"`
+	HashedPassword []byte           `json:"hashedPassword,omitempty"`
+	Status         model.RoomStatus `json:"status"`
+}
+
+// Movie keeps its exported id so that children can refer to their folder
+// through MovieBase.ParentID, new ids are generated on import.
+type Movie struct {
+	ID              string `json:"id"`
+	CreatorUsername string `json:"creator"`
+	model.MovieBase `json:"base"`
+	Position        uint `json:"position"`
+}
+
+type Member struct {
+	Username         string                     `json:"username"`
+	Permissions      model.RoomMemberPermission `json:"permissions"`
+	AdminPermissions model.RoomAdminPermission  `json:"adminPermissions"`
+	Status           model.RoomMemberStatus     `json:"status"`
+	Role             model.RoomMemberRole       `json:"role"`
+}
+
+// Write encodes the archive as a zip file
+func Write(w io.Writer, a *Archive) error {
+	zw := zip.NewWriter(w)
+
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     roomFile,
+		Method:   zip.Deflate,
+		Modified: time.UnixMilli(a.ExportedAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(a); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// Read decodes an archive written by Write
+func Read(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid room archive: %w", err)
+	}
+
+	f, err := zr.Open(roomFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid room archive: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("invalid room archive: %w", err)
+	}
+
+	if fi.Size() > maxRoomFileSize {
+		return nil, ErrArchiveTooLarge
+	}
+
+	a := new(Archive)
+	if err := json.NewDecoder(f).Decode(a); err != nil {
+		return nil, fmt.Errorf("invalid room archive: %w", err)
+	}
+
+	if a.Version < 1 || a.Version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, a.Version)
+	}
+
+	return a, nil
+}
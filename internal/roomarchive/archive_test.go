@@ -0,0 +1,132 @@
+package roomarchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+func TestWriteRead(t *testing.T) {
+	a := &Archive{
+		Version:  Version,
+		Room:     Room{Name: "room"},
+		Settings: model.DefaultRoomSettings(),
+		Movies: []*Movie{
+			{ID: "folder", MovieBase: model.MovieBase{Name: "folder", IsFolder: true}},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := Write(buf, a); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Room.Name != "room" || len(got.Movies) != 1 || !got.Movies[0].IsFolder {
+		t.Fatalf("unexpected archive: %+v", got)
+	}
+
+	a.Version = Version + 1
+	buf.Reset()
+
+	if err := Write(buf, a); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected unsupported version, got %v", err)
+	}
+}
+
+func TestReadTooLarge(t *testing.T) {
+	name := strings.Repeat("a", maxRoomFileSize)
+	body := []byte(`{"room":{"name":"` + name + `"}}`)
+
+	compressed := bytes.NewBuffer(nil)
+
+	fw, err := flate.NewWriter(compressed, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fw.Write(body); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// zip refuses to read past a forged declared size
+	for _, declared := range []uint64{uint64(len(body)), 1024} {
+		buf := bytes.NewBuffer(nil)
+		zw := zip.NewWriter(buf)
+
+		f, err := zw.CreateRaw(&zip.FileHeader{
+			Name:               roomFile,
+			Method:             zip.Deflate,
+			CRC32:              crc32.ChecksumIEEE(body),
+			CompressedSize64:   uint64(compressed.Len()),
+			UncompressedSize64: declared,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := f.Write(compressed.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if declared > maxRoomFileSize && !errors.Is(err, ErrArchiveTooLarge) {
+			t.Fatalf("declared size %d: expected too large, got %v", declared, err)
+		}
+
+		if err == nil {
+			t.Fatalf("declared size %d: archive accepted", declared)
+		}
+	}
+}
+
+func TestImportMovies(t *testing.T) {
+	archived := []*Movie{
+		{ID: "child", MovieBase: model.MovieBase{Name: "child", ParentID: "folder"}},
+		{ID: "folder", MovieBase: model.MovieBase{Name: "folder", IsFolder: true}},
+	}
+
+	movies, err := importMovies(archived, func(string) string { return "owner" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(movies) != 2 || movies[0].Name != "folder" || movies[1].Name != "child" {
+		t.Fatalf("folders must come before their children: %+v", movies)
+	}
+
+	if movies[1].ParentID.String() != movies[0].ID || movies[0].ID == "folder" {
+		t.Fatalf("parent id not remapped: %s", movies[1].ParentID)
+	}
+
+	_, err = importMovies([]*Movie{
+		{ID: "a", MovieBase: model.MovieBase{ParentID: "b"}},
+		{ID: "b", MovieBase: model.MovieBase{ParentID: "a"}},
+	}, func(string) string { return "owner" })
+	if err == nil {
+		t.Fatal("expected cycle error")
+	}
+}
@@ -0,0 +1,271 @@
+package roomarchive
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+// Export reads the room from the database
+func Export(roomID string) (*Archive, error) {
+	room, err := db.GetRoomByID(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := db.CreateOrLoadRoomSettings(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make(map[string]string)
+	username := func(userID string) string {
+		if name, ok := usernames[userID]; ok {
+			return name
+		}
+
+		var name string
+		if u, err := db.GetUserByID(userID); err == nil {
+			name = u.Username
+		}
+
+		usernames[userID] = name
+
+		return name
+	}
+
+	members, err := db.GetRoomMembers(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		Version:    Version,
+		ExportedAt: time.Now().UnixMilli(),
+		Room: Room{
+			Name:           room.Name,
+			HashedPassword: room.HashedPassword,
+			Status:         room.Status,
+		},
+		Settings: settings,
+		Members:  make([]*Member, 0, len(members)),
+	}
+
+	for _, m := range members {
+		name := username(m.UserID)
+		if name == "" {
+			continue
+		}
+
+		a.Members = append(a.Members, &Member{
+			Username:         name,
+			Role:             m.Role,
+			Status:           m.Status,
+			Permissions:      m.Permissions,
+			AdminPermissions: m.AdminPermissions,
+		})
+	}
+
+	// export the leader by username, it is mapped back on import
+	if settings.LeaderID != "" {
+		a.Settings.LeaderID = username(settings.LeaderID)
+	}
+
+	movies, err := db.GetMoviesByRoomID(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Movies = make([]*Movie, 0, len(movies))
+	for _, m := range movies {
+		// recording files are kept on disk and are not part of the archive
+		if m.RecordingID != "" {
+			continue
+		}
+
+		a.Movies = append(a.Movies, &Movie{
+			ID:              m.ID,
+			CreatorUsername: username(m.CreatorID),
+			Position:        m.Position,
+			MovieBase:       m.MovieBase,
+		})
+	}
+
+	return a, nil
+}
+
+type ImportConfig func(*importConfig)
+
+type importConfig struct {
+	name       string
+	matchUsers bool
+}
+
+// WithName imports the room under another name, e.g. when the name is taken
+func WithName(name string) ImportConfig {
+	return func(c *importConfig) {
+		c.name = name
+	}
+}
+
+// WithMatchUsers maps archived members, the leader and movie creators to the
+// users of the same username, without it they are skipped
+func WithMatchUsers() ImportConfig {
+	return func(c *importConfig) {
+		c.matchUsers = true
+	}
+}
+
+// Import recreates the archived room owned by owner
+func Import(a *Archive, owner *model.User, conf ...ImportConfig) (*model.Room, error) {
+	if a.Settings == nil {
+		return nil, errors.New("room archive has no settings")
+	}
+
+	c := importConfig{name: a.Room.Name}
+	for _, f := range conf {
+		f(&c)
+	}
+
+	if c.name == "" {
+		return nil, errors.New("room name is empty")
+	}
+
+	userIDs := make(map[string]string)
+	userID := func(username string) string {
+		if username == "" || !c.matchUsers {
+			return ""
+		}
+
+		if id, ok := userIDs[username]; ok {
+			return id
+		}
+
+		var id string
+		if u, err := db.GetUserByUsername(username); err == nil {
+			id = u.ID
+		}
+
+		userIDs[username] = id
+
+		return id
+	}
+
+	settings := *a.Settings
+	settings.ID = ""
+	settings.LeaderID = userID(a.Settings.LeaderID)
+
+	if settings.LeaderMode == model.LeaderModeMember && settings.LeaderID == "" {
+		settings.LeaderMode = model.LeaderModeNone
+	}
+
+	room := &model.Room{
+		Name:           c.name,
+		HashedPassword: a.Room.HashedPassword,
+		Status:         a.Room.Status,
+		CreatorID:      owner.ID,
+		Settings:       &settings,
+		RoomMembers: []*model.RoomMember{
+			{
+				UserID:           owner.ID,
+				Status:           model.RoomMemberStatusActive,
+				Role:             model.RoomMemberRoleCreator,
+				Permissions:      model.AllPermissions,
+				AdminPermissions: model.AllAdminPermissions,
+			},
+		},
+	}
+
+	for _, m := range a.Members {
+		id := userID(m.Username)
+		if id == "" || id == owner.ID {
+			continue
+		}
+
+		role := m.Role
+		// the previous creator stays in charge of the room as an admin
+		if role.IsCreator() {
+			role = model.RoomMemberRoleAdmin
+		}
+
+		room.RoomMembers = append(room.RoomMembers, &model.RoomMember{
+			UserID:           id,
+			Role:             role,
+			Status:           m.Status,
+			Permissions:      m.Permissions,
+			AdminPermissions: m.AdminPermissions,
+		})
+	}
+
+	movies, err := importMovies(a.Movies, func(username string) string {
+		if id := userID(username); id != "" {
+			return id
+		}
+		return owner.ID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.ImportRoom(room, movies); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+// importMovies assigns new ids and orders folders before their children
+func importMovies(archived []*Movie, creatorID func(string) string) ([]*model.Movie, error) {
+	ids := make(map[string]string, len(archived))
+	children := make(map[string][]*Movie, len(archived))
+
+	for _, m := range archived {
+		if _, ok := ids[m.ID]; ok {
+			return nil, fmt.Errorf("duplicate movie id in room archive: %s", m.ID)
+		}
+
+		ids[m.ID] = utils.SortUUID()
+		children[m.ParentID.String()] = append(children[m.ParentID.String()], m)
+	}
+
+	for parentID := range children {
+		if _, ok := ids[parentID]; parentID != "" && !ok {
+			return nil, fmt.Errorf("movie parent not found in room archive: %s", parentID)
+		}
+	}
+
+	movies := make([]*model.Movie, 0, len(archived))
+
+	// breadth first from the root so that every parent is created first
+	queue := []string{""}
+	for len(queue) != 0 {
+		parentID := queue[0]
+		queue = queue[1:]
+
+		for _, m := range children[parentID] {
+			base := m.MovieBase
+			if parentID != "" {
+				base.ParentID = model.EmptyNullString(ids[parentID])
+			}
+
+			movies = append(movies, &model.Movie{
+				ID:        ids[m.ID],
+				CreatorID: creatorID(m.CreatorUsername),
+				Position:  m.Position,
+				MovieBase: base,
+			})
+
+			queue = append(queue, m.ID)
+		}
+	}
+
+	if len(movies) != len(archived) {
+		return nil, errors.New("room archive movies contain a cycle")
+	}
+
+	return movies, nil
+}
@@ -7,18 +7,21 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/synctv-org/synctv/internal/conf"
-	rtmps "github.com/zijiren233/livelib/server"
 	"github.com/zijiren233/stream"
 )
 
-var s *rtmps.Server
+var s *RtmpServer
+
+var ErrAuthFailed = errors.New("auth failed")
 
 type Claims struct {
 	MovieID string `json:"m"`
+	// the publish key version of the movie when the key was issued
+	Version uint32 `json:"v,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func AuthRtmpPublish(authorization string) (movieID string, err error) {
+func AuthRtmpPublish(authorization string) (*Claims, error) {
 	t, err := jwt.ParseWithClaims(
 		strings.TrimPrefix(authorization, `Bearer `),
 		&Claims{},
@@ -27,33 +30,40 @@ func AuthRtmpPublish(authorization string) (movieID string, err error) {
 		},
 	)
 	if err != nil {
-		return "", errors.New("auth failed")
+		return nil, ErrAuthFailed
 	}
 
 	claims, ok := t.Claims.(*Claims)
 	if !ok {
-		return "", errors.New("auth failed")
+		return nil, ErrAuthFailed
 	}
 
-	return claims.MovieID, nil
+	return claims, nil
 }
 
-func NewRtmpAuthorization(movieID string) (string, error) {
+// NewRtmpAuthorization signs a publish key, it never expires if expire is 0
+func NewRtmpAuthorization(movieID string, version uint32, expire time.Duration) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		MovieID: movieID,
+		Version: version,
 		RegisteredClaims: jwt.RegisteredClaims{
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
+	if expire > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(expire))
+	}
+
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).
 		SignedString(stream.StringToBytes(conf.Conf.Jwt.Secret))
 }
 
-func Init(rs *rtmps.Server) {
+func Init(rs *RtmpServer) {
 	s = rs
 }
 
-func Server() *rtmps.Server {
+func Server() *RtmpServer {
 	return s
 }
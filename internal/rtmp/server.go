@@ -0,0 +1,95 @@
+package rtmp
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/zijiren233/livelib/av"
+	"github.com/zijiren233/livelib/protocol/rtmp"
+	"github.com/zijiren233/livelib/protocol/rtmp/core"
+	rtmps "github.com/zijiren233/livelib/server"
+)
+
+// PublishFunc pushes a publisher into its channel until it leaves,
+// closing r disconnects the publisher
+type PublishFunc func(app, name string, r av.ReadCloser) error
+
+type PlayFunc func(app, name string) (*rtmps.Channel, error)
+
+// RtmpServer serves rtmp connections like the livelib server, but hands
+// publishers over as readers so they can be kicked
+type RtmpServer struct {
+	publish PublishFunc
+	play    PlayFunc
+}
+
+func NewRtmpServer(publish PublishFunc, play PlayFunc) *RtmpServer {
+	return &RtmpServer{
+		publish: publish,
+		play:    play,
+	}
+}
+
+func (s *RtmpServer) Serve(l net.Listener) error {
+	for {
+		netconn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			continue
+		}
+
+		go s.handleConn(core.NewConn(netconn, 0))
+	}
+}
+
+func (s *RtmpServer) handleConn(conn *core.Conn) error {
+	if err := conn.HandshakeServer(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	connServer := core.NewConnServer(conn)
+	defer connServer.Close()
+
+	if err := connServer.ReadInitMsg(); err != nil {
+		return err
+	}
+
+	app, name := connServer.ConnInfo.App, connServer.PublishInfo.Name
+
+	if connServer.IsPublisher() {
+		reader := &publisher{Reader: rtmp.NewReader(connServer), conn: connServer}
+		defer reader.Close()
+
+		return s.publish(app, name, reader)
+	}
+
+	channel, err := s.play(app, name)
+	if err != nil {
+		return err
+	}
+
+	writer := rtmp.NewWriter(connServer)
+	defer writer.Close()
+
+	if err := channel.AddPlayer(writer); err != nil {
+		return err
+	}
+
+	return writer.SendPacket(context.Background())
+}
+
+// publisher drops the connection on close, the livelib reader only stops
+// reading and would leave the client connected
+type publisher struct {
+	*rtmp.Reader
+	conn *core.ConnServer
+}
+
+func (p *publisher) Close() error {
+	_ = p.Reader.Close()
+	return p.conn.Close()
+}
@@ -28,6 +28,13 @@ var (
 	)
 )
 
+var (
+	// seconds a client may drift from the room before its playback rate is nudged
+	SyncDriftThreshold Float64Setting
+	// seconds of drift beyond which a client is seeked instead of nudged
+	SyncSeekThreshold Float64Setting
+)
+
 func init() {
 	RoomMustNeedPwd = NewBoolSetting(
 		"room_must_need_pwd",
@@ -57,6 +64,47 @@ func init() {
 			return b, nil
 		}),
 	)
+
+	SyncDriftThreshold = NewFloat64Setting(
+		"sync_drift_threshold",
+		1,
+		model.SettingGroupRoom,
+		WithValidatorFloat64(func(f float64) error {
+			if f <= 0 {
+				return errors.New("sync drift threshold must be greater than 0")
+			}
+			return nil
+		}),
+		WithBeforeSetFloat64(func(_ Float64Setting, f float64) (float64, error) {
+			if f >= SyncSeekThreshold.Get() {
+				return 0, errors.New(
+					"sync_drift_threshold must be less than sync_seek_threshold",
+				)
+			}
+
+			return f, nil
+		}),
+	)
+	SyncSeekThreshold = NewFloat64Setting(
+		"sync_seek_threshold",
+		10,
+		model.SettingGroupRoom,
+		WithValidatorFloat64(func(f float64) error {
+			if f <= 0 {
+				return errors.New("sync seek threshold must be greater than 0")
+			}
+			return nil
+		}),
+		WithBeforeSetFloat64(func(_ Float64Setting, f float64) (float64, error) {
+			if f <= SyncDriftThreshold.Get() {
+				return 0, errors.New(
+					"sync_seek_threshold must be greater than sync_drift_threshold",
+				)
+			}
+
+			return f, nil
+		}),
+	)
 }
 
 var (
@@ -74,6 +122,8 @@ var (
 	)
 	UserMaxRoomCount = NewInt64Setting("user_max_room_count", 3, model.SettingGroupUser)
 	EnableGuest      = NewBoolSetting("enable_guest", true, model.SettingGroupUser)
+	// admins and root without 2fa cannot use the admin api
+	RequireAdmin2FA = NewBoolSetting("require_admin_2fa", false, model.SettingGroupUser)
 )
 
 var (
@@ -88,8 +138,118 @@ var (
 	RtmpPlayer = NewBoolSetting("rtmp_player", false, model.SettingGroupRtmp)
 	// default use http header host
 	CustomPublishHost = NewStringSetting("custom_publish_host", "", model.SettingGroupRtmp)
+	// hours a new publish key stays valid, 0 never expires
+	PublishKeyExpire = NewInt64Setting(
+		"publish_key_expire",
+		24*30,
+		model.SettingGroupRtmp,
+		WithValidatorInt64(func(i int64) error {
+			if i < 0 {
+				return errors.New("publish key expire must not be negative")
+			}
+			return nil
+		}),
+	)
 	// disguise the .ts file as a .png file
 	TSDisguisedAsPng = NewBoolSetting("ts_disguised_as_png", true, model.SettingGroupRtmp)
+	// pull proxied live m3u8 into a channel so it can be served as flv, time
+	// shifted, recorded and transcoded, otherwise the playlist is passed through
+	LiveHlsIngest = NewBoolSetting("live_hls_ingest", false, model.SettingGroupRtmp)
+	// transcode live channels into several bitrates with ffmpeg while they are watched
+	LiveTranscode = NewBoolSetting("live_transcode", false, model.SettingGroupRtmp)
+	// ffmpeg processes a node runs at once, further viewers get the source, 0 is unlimited
+	LiveTranscodeMaxConcurrent = NewInt64Setting(
+		"live_transcode_max_concurrent",
+		2,
+		model.SettingGroupRtmp,
+		WithValidatorInt64(func(i int64) error {
+			if i < 0 {
+				return errors.New("live transcode max concurrent must not be negative")
+			}
+			return nil
+		}),
+	)
+	// height:kbps of each rendition
+	LiveTranscodeRenditions = NewStringSetting(
+		"live_transcode_renditions",
+		"720:2800,480:1400,360:800",
+		model.SettingGroupRtmp,
+		WithValidatorString(func(s string) error {
+			renditions, err := model.ParseLiveRenditions(s)
+			if err != nil {
+				return err
+			}
+			if len(renditions) == 0 {
+				return errors.New("at least one rendition is required")
+			}
+			return nil
+		}),
+	)
+	// minutes of hls segments kept on disk so live viewers can rewind, 0 disables it
+	LiveTimeShift = NewInt64Setting(
+		"live_time_shift",
+		0,
+		model.SettingGroupRtmp,
+		WithValidatorInt64(func(i int64) error {
+			if i < 0 {
+				return errors.New("live time shift must not be negative")
+			}
+			return nil
+		}),
+	)
+	// MiB of hls segments kept for the time shift of each live movie
+	LiveTimeShiftMaxSize = NewInt64Setting(
+		"live_time_shift_max_size",
+		512,
+		model.SettingGroupRtmp,
+		WithValidatorInt64(func(i int64) error {
+			if i <= 0 {
+				return errors.New("live time shift max size must be positive")
+			}
+			return nil
+		}),
+	)
+)
+
+var (
+	// allow live movies to be recorded to disk
+	RecordEnable = NewBoolSetting("record_enable", false, model.SettingGroupRecord)
+	// minutes, a recording is stopped when it reaches this length, 0 is unlimited
+	RecordMaxDuration = NewInt64Setting(
+		"record_max_duration",
+		240,
+		model.SettingGroupRecord,
+		WithValidatorInt64(func(i int64) error {
+			if i < 0 {
+				return errors.New("record max duration must not be negative")
+			}
+			return nil
+		}),
+	)
+	// MiB of recordings a room may keep, 0 is unlimited
+	RecordRoomQuota = NewInt64Setting(
+		"record_room_quota",
+		2048,
+		model.SettingGroupRecord,
+		WithValidatorInt64(func(i int64) error {
+			if i < 0 {
+				return errors.New("record room quota must not be negative")
+			}
+			return nil
+		}),
+	)
+	// MiB of recordings all rooms may keep, 0 is unlimited
+	RecordTotalQuota = NewInt64Setting(
+		"record_total_quota",
+		20480,
+		model.SettingGroupRecord,
+		WithValidatorInt64(func(i int64) error {
+			if i < 0 {
+				return errors.New("record total quota must not be negative")
+			}
+			return nil
+		}),
+	)
 )
 
 var DatabaseVersion = NewStringSetting(
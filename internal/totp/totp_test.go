@@ -0,0 +1,50 @@
+package totp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/synctv-org/synctv/internal/totp"
+)
+
+// https://www.rfc-editor.org/rfc/rfc6238#appendix-B, the last 6 digits
+func TestCode(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	for _, tc := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	} {
+		if got := totp.Code(secret, totp.Step(time.Unix(tc.unix, 0))); got != tc.code {
+			t.Errorf("%d: got %s, want %s", tc.unix, got, tc.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code := totp.Code(secret, totp.Step(now.Add(-totp.Period*time.Second)))
+
+	step, ok := totp.Validate(secret, code, now, 0)
+	if !ok {
+		t.Fatal("code of the previous step was rejected")
+	}
+
+	if _, ok := totp.Validate(secret, code, now, step); ok {
+		t.Fatal("used code was accepted again")
+	}
+
+	if _, ok := totp.Validate(secret, totp.Code(secret, totp.Step(now)+5), now, 0); ok {
+		t.Fatal("code outside of the window was accepted")
+	}
+}
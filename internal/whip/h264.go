@@ -0,0 +1,119 @@
+package whip
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/pion/rtp/codecs"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media/samplebuilder"
+	"github.com/synctv-org/synctv/internal/flvtag"
+	"github.com/zijiren233/livelib/av"
+)
+
+// rtp packets kept for reordering
+const maxLatePackets = 256
+
+func readH264(track *webrtc.TrackRemote, q *packetQueue) error {
+	sb := samplebuilder.New(maxLatePackets, &codecs.H264Packet{}, track.Codec().ClockRate)
+	m := &avcMuxer{clockRate: track.Codec().ClockRate}
+
+	for {
+		pkt, _, err := track.ReadRTP()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		sb.Push(pkt)
+
+		for sample := sb.Pop(); sample != nil; sample = sb.Pop() {
+			packets, err := m.mux(sample.Data, sample.PacketTimestamp)
+			if err != nil {
+				return err
+			}
+
+			for _, p := range packets {
+				if err := q.Write(p); err != nil {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// avcMuxer turns annex-b access units into flv video tags
+type avcMuxer struct {
+	sps, pps   []byte
+	clockRate  uint32
+	baseTS     uint32
+	started    bool
+	sentConfig bool
+}
+
+func (m *avcMuxer) mux(accessUnit []byte, rtpTS uint32) ([]*av.Packet, error) {
+	var (
+		nalus [][]byte
+		key   bool
+	)
+
+	for _, nalu := range flvtag.SplitAnnexB(accessUnit) {
+		switch nalu[0] & 0x1f {
+		case flvtag.NaluTypeSPS:
+			if !bytes.Equal(m.sps, nalu) {
+				m.sps = bytes.Clone(nalu)
+				m.sentConfig = false
+			}
+		case flvtag.NaluTypePPS:
+			if !bytes.Equal(m.pps, nalu) {
+				m.pps = bytes.Clone(nalu)
+				m.sentConfig = false
+			}
+		case flvtag.NaluTypeAUD:
+		case flvtag.NaluTypeIDR:
+			key = true
+			nalus = append(nalus, nalu)
+		default:
+			nalus = append(nalus, nalu)
+		}
+	}
+
+	if !m.started {
+		m.baseTS = rtpTS
+		m.started = true
+	}
+
+	ts := uint32(uint64(rtpTS-m.baseTS) * 1000 / uint64(m.clockRate))
+
+	var packets []*av.Packet
+
+	if !m.sentConfig {
+		// players need the decoder config before the first keyframe
+		if !key || len(m.sps) < 4 || len(m.pps) == 0 {
+			return nil, nil
+		}
+
+		p, err := flvtag.Packet(true, ts, flvtag.AVCConfig(m.sps, m.pps))
+		if err != nil {
+			return nil, err
+		}
+
+		packets = append(packets, p)
+		m.sentConfig = true
+	}
+
+	if len(nalus) == 0 {
+		return packets, nil
+	}
+
+	// webrtc has no b-frames, so the composition time is always zero
+	p, err := flvtag.Packet(true, ts, flvtag.AVCFrame(nalus, key, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(packets, p), nil
+}
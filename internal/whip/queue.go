@@ -0,0 +1,48 @@
+package whip
+
+import (
+	"io"
+	"sync"
+
+	"github.com/zijiren233/livelib/av"
+)
+
+// packetQueue is the av.Reader a channel publication reads from
+type packetQueue struct {
+	ch   chan *av.Packet
+	done chan struct{}
+	once sync.Once
+}
+
+func newPacketQueue() *packetQueue {
+	return &packetQueue{
+		ch:   make(chan *av.Packet, 1024),
+		done: make(chan struct{}),
+	}
+}
+
+func (q *packetQueue) Read() (*av.Packet, error) {
+	select {
+	case p := <-q.ch:
+		return p, nil
+	case <-q.done:
+		return nil, io.EOF
+	}
+}
+
+func (q *packetQueue) Write(p *av.Packet) error {
+	select {
+	case q.ch <- p:
+		return nil
+	case <-q.done:
+		return av.ErrClosed
+	}
+}
+
+func (q *packetQueue) Close() error {
+	q.once.Do(func() {
+		close(q.done)
+	})
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package whip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+	log "github.com/sirupsen/logrus"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/utils"
+	"github.com/zijiren233/livelib/av"
+)
+
+var (
+	ErrNotEnabled      = errors.New("whip is not enabled")
+	ErrSessionNotFound = errors.New("whip session not found")
+)
+
+// keyframes are requested this often, so hls segments are cut on time
+const pliInterval = 3 * time.Second
+
+var s *Server
+
+type Server struct {
+	api      *webrtc.API
+	sessions sync.Map
+}
+
+func Init(c conf.WHIPServerConfig) error {
+	if !c.Enable {
+		return nil
+	}
+
+	srv, err := New(c)
+	if err != nil {
+		return err
+	}
+
+	s = srv
+
+	return nil
+}
+
+// Default returns nil when whip is disabled
+func Default() *Server {
+	return s
+}
+
+func New(c conf.WHIPServerConfig) (*Server, error) {
+	m := &webrtc.MediaEngine{}
+
+	// only h264 can be remuxed to flv and hls without transcoding
+	err := m.RegisterCodec(webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{
+			MimeType:    webrtc.MimeTypeH264,
+			ClockRate:   90000,
+			SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+			RTCPFeedback: []webrtc.RTCPFeedback{
+				{Type: "nack"},
+				{Type: "nack", Parameter: "pli"},
+			},
+		},
+		PayloadType: 102,
+	}, webrtc.RTPCodecTypeVideo)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(m, i); err != nil {
+		return nil, err
+	}
+
+	se := webrtc.SettingEngine{}
+
+	if c.UDPPort != 0 {
+		mux, err := ice.NewMultiUDPMuxFromPort(int(c.UDPPort))
+		if err != nil {
+			return nil, fmt.Errorf("listen whip udp port error: %w", err)
+		}
+
+		se.SetICEUDPMux(mux)
+	}
+
+	if c.PublicIP != "" {
+		if net.ParseIP(c.PublicIP) == nil {
+			return nil, fmt.Errorf("invalid whip public ip: %s", c.PublicIP)
+		}
+
+		se.SetNAT1To1IPs([]string{c.PublicIP}, webrtc.ICECandidateTypeHost)
+	}
+
+	return &Server{
+		api: webrtc.NewAPI(
+			webrtc.WithMediaEngine(m),
+			webrtc.WithInterceptorRegistry(i),
+			webrtc.WithSettingEngine(se),
+		),
+	}, nil
+}
+
+type session struct {
+	pc    *webrtc.PeerConnection
+	queue *packetQueue
+	// the movie the session publishes to, only its publish key may end it
+	roomID  string
+	movieID string
+	once    sync.Once
+}
+
+func (ss *session) close() {
+	ss.once.Do(func() {
+		_ = ss.queue.Close()
+		_ = ss.pc.Close()
+	})
+}
+
+// Publish answers the sdp offer and hands the received video to push,
+// it returns the answer and the id of the session.
+func (srv *Server) Publish(
+	roomID, movieID string,
+	push func(av.ReadCloser) error,
+	offer string,
+) (string, string, error) {
+	pc, err := srv.api.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		return "", "", err
+	}
+
+	ss := &session{
+		pc:      pc,
+		queue:   newPacketQueue(),
+		roomID:  roomID,
+		movieID: movieID,
+	}
+	id := utils.SortUUID()
+
+	pc.OnTrack(func(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		if track.Kind() != webrtc.RTPCodecTypeVideo {
+			return
+		}
+
+		go requestKeyframes(pc, track, ss.queue)
+
+		if err := readH264(track, ss.queue); err != nil {
+			log.Errorf("whip: read track of session %s error: %v", id, err)
+		}
+
+		ss.close()
+	})
+
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		switch state {
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed:
+			ss.close()
+		}
+	})
+
+	answer, err := negotiate(pc, offer)
+	if err != nil {
+		ss.close()
+		return "", "", err
+	}
+
+	srv.sessions.Store(id, ss)
+
+	go func() {
+		defer srv.sessions.Delete(id)
+		defer ss.close()
+
+		if err := push(ss.queue); err != nil && !errors.Is(err, io.EOF) {
+			log.Errorf("whip: push session %s error: %v", id, err)
+		}
+	}()
+
+	return answer, id, nil
+}
+
+func negotiate(pc *webrtc.PeerConnection, offer string) (string, error) {
+	err := pc.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeOffer,
+		SDP:  offer,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		return "", err
+	}
+
+	// candidates are sent in the answer, trickle ice is not supported
+	gathered := webrtc.GatheringCompletePromise(pc)
+
+	if err := pc.SetLocalDescription(answer); err != nil {
+		return "", err
+	}
+
+	<-gathered
+
+	return pc.LocalDescription().SDP, nil
+}
+
+// Unpublish ends the session of the movie, the sessions of other movies are
+// not found
+func (srv *Server) Unpublish(roomID, movieID, id string) error {
+	v, ok := srv.sessions.Load(id)
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	ss := v.(*session)
+	if ss.roomID != roomID || ss.movieID != movieID {
+		return ErrSessionNotFound
+	}
+
+	srv.sessions.CompareAndDelete(id, ss)
+	ss.close()
+
+	return nil
+}
+
+func requestKeyframes(pc *webrtc.PeerConnection, track *webrtc.TrackRemote, q *packetQueue) {
+	t := time.NewTicker(pliInterval)
+	defer t.Stop()
+
+	for {
+		err := pc.WriteRTCP([]rtcp.Packet{
+			&rtcp.PictureLossIndication{MediaSSRC: uint32(track.SSRC())},
+		})
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-q.done:
+			return
+		case <-t.C:
+		}
+	}
+}
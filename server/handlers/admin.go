@@ -7,6 +7,7 @@ import (
 	"maps"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/synctv-org/synctv/internal/email"
 	dbModel "github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/roomarchive"
 	"github.com/synctv-org/synctv/internal/settings"
 	"github.com/synctv-org/synctv/internal/vendor"
 	"github.com/synctv-org/synctv/server/middlewares"
@@ -26,7 +28,7 @@ import (
 )
 
 func AdminEditSettings(ctx *gin.Context) {
-	// user := middlewares.GetUserEntry(ctx)
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	req := model.AdminSettingsReq{}
@@ -35,18 +37,50 @@ func AdminEditSettings(ctx *gin.Context) {
 		return
 	}
 
+	before := make(map[string]any, len(req))
+	after := make(map[string]any, len(req))
+
+	// the settings set before a failing one stay applied and are audited
+	defer func() {
+		if len(after) != 0 {
+			user.RecordAudit(dbModel.AuditSettingsUpdate, "", "", before, after)
+		}
+	}()
+
 	for k, v := range req {
+		prev := auditSettingValue(k)
+
 		err := settings.SetValue(k, v)
 		if err != nil {
 			log.Errorf("set value error: %v", err)
 			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
 			return
 		}
+
+		before[k] = prev
+		after[k] = auditSettingValue(k)
 	}
 
 	ctx.Status(http.StatusNoContent)
 }
 
+// auditSettingValue hides the value of credentials such as oauth2 client
+// secrets and the smtp password
+func auditSettingValue(name string) any {
+	s, ok := settings.Settings[name]
+	if !ok {
+		return nil
+	}
+
+	for _, suffix := range []string{"_secret", "_password", "_token"} {
+		if strings.HasSuffix(name, suffix) {
+			return "******"
+		}
+	}
+
+	return s.Interface()
+}
+
 func AdminSettings(ctx *gin.Context) {
 	// user := middlewares.GetUserEntry(ctx)
 	log := middlewares.GetLogger(ctx)
@@ -122,7 +156,7 @@ func AdminGetUsers(ctx *gin.Context) {
 		return
 	}
 
-	scopes := []func(db *gorm.DB) *gorm.DB{}
+	scopes := []func(db *gorm.DB) *gorm.DB{db.WithContext(ctx)}
 
 	switch ctx.Query("role") {
 	case "admin":
@@ -232,7 +266,7 @@ func AdminGetRoomMembers(ctx *gin.Context) {
 		return
 	}
 
-	scopes := []func(db *gorm.DB) *gorm.DB{}
+	scopes := []func(db *gorm.DB) *gorm.DB{db.WithContext(ctx)}
 
 	switch ctx.DefaultQuery("status", "active") {
 	case "pending":
@@ -348,6 +382,7 @@ func genRoomMemberListResp(us []*dbModel.User, room *op.Room) []*model.RoomMembe
 			RoomID:           v.RoomMembers[0].RoomID,
 			Permissions:      permissions,
 			AdminPermissions: v.RoomMembers[0].AdminPermissions,
+			MutedUntil:       v.RoomMembers[0].MutedUntilUnixMilli(),
 		}
 	}
 
@@ -355,6 +390,7 @@ func genRoomMemberListResp(us []*dbModel.User, room *op.Room) []*model.RoomMembe
 }
 
 func AdminApprovePendingUser(ctx *gin.Context) {
+	actor := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	req := model.UserIDReq{}
@@ -389,6 +425,8 @@ func AdminApprovePendingUser(ctx *gin.Context) {
 		return
 	}
 
+	actor.RecordAudit(dbModel.AuditUserApprove, "", user.ID, nil, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -446,11 +484,13 @@ func AdminBanUser(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditUserBan, "", req.ID, nil, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
 func AdminUnBanUser(ctx *gin.Context) {
-	// user := middlewares.GetUserEntry(ctx)
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	req := model.UserIDReq{}
@@ -483,6 +523,8 @@ func AdminUnBanUser(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditUserUnban, "", req.ID, nil, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -497,7 +539,7 @@ func AdminGetRooms(ctx *gin.Context) {
 		return
 	}
 
-	scopes := []func(db *gorm.DB) *gorm.DB{}
+	scopes := []func(db *gorm.DB) *gorm.DB{db.WithContext(ctx)}
 
 	switch ctx.Query("status") {
 	case "active":
@@ -600,6 +642,7 @@ func AdminGetUserRooms(ctx *gin.Context) {
 	}
 
 	scopes := []func(db *gorm.DB) *gorm.DB{
+		db.WithContext(ctx),
 		db.WhereCreatorID(id),
 	}
 
@@ -686,6 +729,7 @@ func AdminGetUserJoinedRooms(ctx *gin.Context) {
 	}
 
 	scopes := []func(db *gorm.DB) *gorm.DB{
+		db.WithContext(ctx),
 		func(db *gorm.DB) *gorm.DB {
 			return db.
 				InnerJoins(
@@ -766,6 +810,7 @@ func AdminGetUserJoinedRooms(ctx *gin.Context) {
 }
 
 func AdminApprovePendingRoom(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	req := model.RoomIDReq{}
@@ -800,6 +845,8 @@ func AdminApprovePendingRoom(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditRoomApprove, req.ID, req.ID, nil, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -860,11 +907,13 @@ func AdminBanRoom(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditRoomBan, req.ID, req.ID, nil, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
 func AdminUnBanRoom(ctx *gin.Context) {
-	// user := middlewares.GetUserEntry(ctx)
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	req := model.RoomIDReq{}
@@ -899,6 +948,8 @@ func AdminUnBanRoom(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditRoomUnban, req.ID, req.ID, nil, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -922,13 +973,18 @@ func AdminAddUser(ctx *gin.Context) {
 		return
 	}
 
-	_, err := op.CreateUser(req.Username, req.Password, db.WithRole(req.Role))
+	u, err := op.CreateUser(req.Username, req.Password, db.WithRole(req.Role))
 	if err != nil {
 		log.Errorf("create user error: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditUserAdd, "", u.Value().ID, nil, gin.H{
+		"username": req.Username,
+		"role":     req.Role,
+	})
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -979,12 +1035,16 @@ func AdminDeleteUser(ctx *gin.Context) {
 		return
 	}
 
+	before := gin.H{"username": u.Value().Username, "role": u.Value().Role}
+
 	if err := op.DeleteUserByID(req.ID); err != nil {
 		log.Errorf("delete user by id error: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditUserDelete, "", req.ID, before, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -1038,15 +1098,136 @@ func AdminDeleteRoom(ctx *gin.Context) {
 		}
 	}
 
+	before := gin.H{"name": room.Name, "creatorId": room.CreatorID}
+
 	if err := op.DeleteRoomByID(req.ID); err != nil {
 		log.Errorf("delete room by id error: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditRoomDelete, req.ID, req.ID, before, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
+// maxRoomArchiveSize limits uploaded room archives
+const maxRoomArchiveSize = 64 << 20
+
+func AdminExportRoom(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	id := ctx.Query("id")
+	if len(id) != 32 {
+		log.Error("room id error")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorStringResp("room id error"))
+		return
+	}
+
+	a, err := roomarchive.Export(id)
+	if err != nil {
+		log.Errorf("export room error: %v", err)
+
+		if errors.Is(err, db.NotFoundError(db.ErrRoomNotFound)) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+
+		return
+	}
+
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="room-%s.zip"`, id))
+	ctx.Status(http.StatusOK)
+
+	if err := roomarchive.Write(ctx.Writer, a); err != nil {
+		log.Errorf("write room archive error: %v", err)
+	}
+}
+
+// AdminImportRoom takes a multipart form with the archive file,
+// an optional ownerId defaulting to the current user and an optional new room name.
+func AdminImportRoom(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxRoomArchiveSize)
+
+	fh, err := ctx.FormFile("archive")
+	if err != nil {
+		log.Errorf("get room archive error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	owner := user
+	if ownerID := ctx.PostForm("ownerId"); ownerID != "" && ownerID != user.ID {
+		u, err := op.LoadOrInitUserByID(ownerID)
+		if err != nil {
+			log.Errorf("get user by id error: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+			return
+		}
+
+		owner = u.Value()
+
+		if owner.IsRoot() && !user.IsRoot() {
+			log.Error("cannot import room for root")
+			ctx.AbortWithStatusJSON(
+				http.StatusForbidden,
+				model.NewAPIErrorStringResp("cannot import room for root"),
+			)
+
+			return
+		}
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		log.Errorf("open room archive error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+	defer f.Close()
+
+	a, err := roomarchive.Read(f, fh.Size)
+	if err != nil {
+		log.Errorf("read room archive error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	var conf []roomarchive.ImportConfig
+	if name := ctx.PostForm("name"); name != "" {
+		conf = append(conf, roomarchive.WithName(name))
+	}
+
+	if ctx.PostForm("matchUsers") == "true" {
+		conf = append(conf, roomarchive.WithMatchUsers())
+	}
+
+	r, err := roomarchive.Import(a, &owner.User, conf...)
+	if err != nil {
+		log.Errorf("import room error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	user.RecordAudit(
+		dbModel.AuditRoomImport,
+		r.ID,
+		r.ID,
+		nil,
+		gin.H{"name": r.Name, "creatorId": owner.ID},
+	)
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+		"id": r.ID,
+	}))
+}
+
 func AdminUserPassword(ctx *gin.Context) {
 	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
@@ -1100,6 +1281,8 @@ func AdminUserPassword(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditUserPassword, "", req.ID, nil, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -1146,6 +1329,8 @@ func AdminUsername(ctx *gin.Context) {
 		}
 	}
 
+	oldUsername := u.Value().Username
+
 	if err := u.Value().SetUsername(req.Username); err != nil {
 		log.Errorf("set username error: %v", err)
 		ctx.AbortWithStatusJSON(
@@ -1156,6 +1341,8 @@ func AdminUsername(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditUserUsername, "", req.ID, oldUsername, req.Username)
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -1215,6 +1402,8 @@ func AdminRoomPassword(ctx *gin.Context) {
 		}
 	}
 
+	before := room.NeedPassword()
+
 	if err := room.SetPassword(req.Password); err != nil {
 		log.Errorf("set password error: %v", err)
 		ctx.AbortWithStatusJSON(
@@ -1225,6 +1414,14 @@ func AdminRoomPassword(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(
+		dbModel.AuditRoomPassword,
+		req.ID,
+		req.ID,
+		gin.H{"needPassword": before},
+		gin.H{"needPassword": room.NeedPassword()},
+	)
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -1278,7 +1475,7 @@ func AdminGetVendorBackends(ctx *gin.Context) {
 }
 
 func AdminAddVendorBackend(ctx *gin.Context) {
-	// user := middlewares.GetUserEntry(ctx)
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	var req model.AddVendorBackendReq
@@ -1293,11 +1490,19 @@ func AdminAddVendorBackend(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(
+		dbModel.AuditVendorAdd,
+		"",
+		req.Backend.Endpoint,
+		nil,
+		auditVendorBackend(&req),
+	)
+
 	ctx.Status(http.StatusNoContent)
 }
 
 func AdminDeleteVendorBackends(ctx *gin.Context) {
-	// user := middlewares.GetUserEntry(ctx)
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	var req model.VendorBackendEndpointsReq
@@ -1312,11 +1517,15 @@ func AdminDeleteVendorBackends(ctx *gin.Context) {
 		return
 	}
 
+	for _, endpoint := range req.Endpoints {
+		user.RecordAudit(dbModel.AuditVendorDelete, "", endpoint, nil, nil)
+	}
+
 	ctx.Status(http.StatusNoContent)
 }
 
 func AdminUpdateVendorBackends(ctx *gin.Context) {
-	// user := middlewares.GetUserEntry(ctx)
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	var req model.AddVendorBackendReq
@@ -1331,11 +1540,30 @@ func AdminUpdateVendorBackends(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(
+		dbModel.AuditVendorUpdate,
+		"",
+		req.Backend.Endpoint,
+		nil,
+		auditVendorBackend(&req),
+	)
+
 	ctx.Status(http.StatusNoContent)
 }
 
+// auditVendorBackend leaves out the jwt secret and the registry credentials
+func auditVendorBackend(req *model.AddVendorBackendReq) gin.H {
+	return gin.H{
+		"usedBy":   req.UsedBy,
+		"endpoint": req.Backend.Endpoint,
+		"comment":  req.Backend.Comment,
+		"timeOut":  req.Backend.TimeOut,
+		"tls":      req.Backend.TLS,
+	}
+}
+
 func AdminReconnectVendorBackends(ctx *gin.Context) {
-	// user := middlewares.GetUserEntry(ctx)
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	var req model.VendorBackendEndpointsReq
@@ -1365,11 +1593,15 @@ func AdminReconnectVendorBackends(ctx *gin.Context) {
 		}
 	}
 
+	for _, endpoint := range req.Endpoints {
+		user.RecordAudit(dbModel.AuditVendorReconnect, "", endpoint, nil, nil)
+	}
+
 	ctx.Status(http.StatusNoContent)
 }
 
 func AdminEnableVendorBackends(ctx *gin.Context) {
-	// user := middlewares.GetUserEntry(ctx)
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	var req model.VendorBackendEndpointsReq
@@ -1384,11 +1616,15 @@ func AdminEnableVendorBackends(ctx *gin.Context) {
 		return
 	}
 
+	for _, endpoint := range req.Endpoints {
+		user.RecordAudit(dbModel.AuditVendorEnable, "", endpoint, nil, nil)
+	}
+
 	ctx.Status(http.StatusNoContent)
 }
 
 func AdminDisableVendorBackends(ctx *gin.Context) {
-	// user := middlewares.GetUserEntry(ctx)
+	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
 
 	var req model.VendorBackendEndpointsReq
@@ -1403,9 +1639,110 @@ func AdminDisableVendorBackends(ctx *gin.Context) {
 		return
 	}
 
+	for _, endpoint := range req.Endpoints {
+		user.RecordAudit(dbModel.AuditVendorDisable, "", endpoint, nil, nil)
+	}
+
 	ctx.Status(http.StatusNoContent)
 }
 
+func AdminAuditLogs(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("get page and max error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	scopes := []func(db *gorm.DB) *gorm.DB{db.WithContext(ctx)}
+
+	if actorID := ctx.Query("actorId"); actorID != "" {
+		scopes = append(scopes, db.WhereActorID(actorID))
+	}
+
+	if action := ctx.Query("action"); action != "" {
+		scopes = append(scopes, db.WhereAuditAction(action))
+	}
+
+	if roomID := ctx.Query("roomId"); roomID != "" {
+		scopes = append(scopes, db.WhereRoomID(roomID))
+	}
+
+	if targetID := ctx.Query("targetId"); targetID != "" {
+		scopes = append(scopes, db.WhereTargetID(targetID))
+	}
+
+	var since, until time.Time
+
+	for name, t := range map[string]*time.Time{"since": &since, "until": &until} {
+		v := ctx.Query(name)
+		if v == "" {
+			continue
+		}
+
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.Errorf("parse %s error: %v", name, err)
+			ctx.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				model.NewAPIErrorStringResp(name+" must be a unix milli timestamp"),
+			)
+
+			return
+		}
+
+		*t = time.UnixMilli(ms)
+	}
+
+	scopes = append(scopes, db.WhereCreatedAtBetween(since, until))
+
+	total, err := db.GetAuditLogsCount(scopes...)
+	if err != nil {
+		log.Errorf("get audit logs count error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	logs, err := db.GetAuditLogs(append(scopes, db.OrderByIDDesc, db.Paginate(page, pageSize))...)
+	if err != nil {
+		log.Errorf("get audit logs error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+		"total": total,
+		"list":  genAuditLogListResp(logs),
+	}))
+}
+
+func genAuditLogListResp(logs []*dbModel.AuditLog) []*model.AuditLogResp {
+	resp := make([]*model.AuditLogResp, len(logs))
+	for i, l := range logs {
+		resp[i] = &model.AuditLogResp{
+			ID:        l.ID,
+			CreatedAt: l.CreatedAt.UnixMilli(),
+			ActorID:   l.ActorID,
+			ActorName: l.ActorName,
+			Action:    l.Action,
+			RoomID:    l.RoomID,
+			TargetID:  l.TargetID,
+		}
+
+		if l.Before != "" {
+			resp[i].Before = []byte(l.Before)
+		}
+
+		if l.After != "" {
+			resp[i].After = []byte(l.After)
+		}
+	}
+
+	return resp
+}
+
 func AdminSendTestEmail(ctx *gin.Context) {
 	user := middlewares.GetUserEntry(ctx).Value()
 	log := middlewares.GetLogger(ctx)
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+// ChatHistory returns the chat messages of the room from newest to oldest,
+// the optional before query (unix milli) keeps the pages stable while new messages arrive
+func ChatHistory(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("get chat history failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	var before time.Time
+	if b := ctx.Query("before"); b != "" {
+		ms, err := strconv.ParseInt(b, 10, 64)
+		if err != nil {
+			log.Errorf("get chat history failed: %v", err)
+			ctx.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				model.NewAPIErrorStringResp("before must be a unix milli timestamp"),
+			)
+
+			return
+		}
+
+		before = time.UnixMilli(ms)
+	}
+
+	messages, total, err := room.GetChatMessagesWithPage(ctx, page, pageSize, before)
+	if err != nil {
+		log.Errorf("get chat history failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+		"total": total,
+		"list":  genChatMessageListResp(messages),
+	}))
+}
+
+func genChatMessageListResp(messages []*dbModel.ChatMessage) []*model.ChatMessageResp {
+	resp := make([]*model.ChatMessageResp, len(messages))
+	for i, m := range messages {
+		resp[i] = &model.ChatMessageResp{
+			ID:        m.ID,
+			UserID:    m.UserID,
+			Username:  m.Username,
+			Content:   m.Content,
+			CreatedAt: m.CreatedAt.UnixMilli(),
+			Pinned:    m.Pinned,
+		}
+	}
+
+	return resp
+}
+
+func RoomAdminDeleteChatMessage(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	room := middlewares.GetRoomEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.ChatMessageIDReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode delete chat message req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteRoomChatMessage(room, req.ID); err != nil {
+		log.Errorf("delete chat message failed: %v", err)
+		handleChatModerationError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RoomAdminPinChatMessage(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	room := middlewares.GetRoomEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.ChatMessageIDReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode pin chat message req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.PinRoomChatMessage(room, req.ID); err != nil {
+		log.Errorf("pin chat message failed: %v", err)
+		handleChatModerationError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RoomAdminUnpinChatMessage(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	room := middlewares.GetRoomEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.ChatMessageIDReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode unpin chat message req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.UnpinRoomChatMessage(room, req.ID); err != nil {
+		log.Errorf("unpin chat message failed: %v", err)
+		handleChatModerationError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func handleChatModerationError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+	case errors.Is(err, db.NotFoundError(db.ErrChatMessageNotFound)):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+	}
+}
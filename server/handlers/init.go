@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/conf"
+	dbModel "github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/server/handlers/vendors"
 	"github.com/synctv-org/synctv/server/handlers/vendors/vendoralist"
 	"github.com/synctv-org/synctv/server/handlers/vendors/vendorbilibili"
@@ -11,6 +13,10 @@ import (
 )
 
 func Init(e *gin.Engine) {
+	if conf.Conf.Server.Metrics.Enable && conf.Conf.Server.Metrics.Port == 0 {
+		e.GET("/metrics", gin.WrapH(MetricsHandler()))
+	}
+
 	api := e.Group("/api")
 
 	needAuthUserAPI := api.Group("", middlewares.AuthUserMiddleware)
@@ -59,6 +65,54 @@ func Init(e *gin.Engine) {
 
 		initVendor(vendor)
 	}
+
+	initAPITokenScopes()
+}
+
+// initAPITokenScopes lists the routes personal access tokens can use, the
+// other routes, such as managing the tokens, need a login
+func initAPITokenScopes() {
+	middlewares.AllowAPIToken(dbModel.APITokenScopeReadRooms,
+		"GET /api/user/me",
+		"GET /api/user/rooms",
+		"GET /api/user/rooms/joined",
+		"GET /api/room/joined",
+		"GET /api/room/me",
+		"GET /api/room/info",
+		"GET /api/room/chat/history",
+		"GET /api/room/schedules",
+		"GET /api/room/settings",
+		"GET /api/room/members",
+		"GET /api/room/movie/current",
+		"GET /api/room/movie/movies",
+		"GET /api/room/movie/requests",
+		"GET /api/room/movie/recordings",
+	)
+
+	middlewares.AllowAPIToken(dbModel.APITokenScopePushMovies,
+		"POST /api/room/movie/push",
+		"POST /api/room/movie/pushs",
+		"POST /api/room/movie/edit",
+		"POST /api/room/movie/swap",
+		"POST /api/room/movie/requests/push",
+	)
+
+	middlewares.AllowAPIToken(dbModel.APITokenScopeDeleteMovies,
+		"POST /api/room/movie/delete",
+		"POST /api/room/movie/clear",
+	)
+
+	// playback is synced over the websocket, which only takes the playback
+	// messages of api tokens
+	middlewares.AllowAPIToken(dbModel.APITokenScopeControlPlayback,
+		"POST /api/room/movie/current",
+		"GET /api/room/ws",
+	)
+
+	// the root api is refused by AuthRootMiddleware
+	middlewares.AllowAPIToken(dbModel.APITokenScopeAdmin,
+		"* /api/admin/*",
+	)
 }
 
 func initAdmin(admin, root *gin.RouterGroup) {
@@ -71,6 +125,8 @@ func initAdmin(admin, root *gin.RouterGroup) {
 
 		admin.POST("/email/test", AdminSendTestEmail)
 
+		admin.GET("/audit", AdminAuditLogs)
+
 		admin.GET("/vendors", AdminGetVendorBackends)
 
 		admin.POST("/vendors/add", AdminAddVendorBackend)
@@ -126,6 +182,10 @@ func initAdmin(admin, root *gin.RouterGroup) {
 			room.POST("/delete", AdminDeleteRoom)
 
 			room.GET("/members", AdminGetRoomMembers)
+
+			room.GET("/export", AdminExportRoom)
+
+			room.POST("/import", AdminImportRoom)
 		}
 	}
 
@@ -155,6 +215,18 @@ func initRoom(room, needAuthUser, needAuthRoom, needAuthWithoutGuestRoom *gin.Ro
 
 	needAuthRoom.GET("/ws", NewWebSocketHandler(utils.NewWebSocketServer()))
 
+	needAuthRoom.GET("/chat/history", ChatHistory)
+
+	needAuthRoom.GET("/schedules", RoomSchedules)
+
+	needAuthRoom.POST("/schedules/create", CreateRoomSchedule)
+
+	needAuthRoom.POST("/schedules/delete", DeleteRoomSchedule)
+
+	needAuthWithoutGuestRoom.POST("/schedules/subscribe", SubscribeRoomSchedule)
+
+	needAuthWithoutGuestRoom.POST("/schedules/unsubscribe", UnsubscribeRoomSchedule)
+
 	needAuthWithoutGuestRoom.GET("/settings", RoomPiblicSettings)
 
 	needAuthWithoutGuestRoom.GET("/members", RoomMembers)
@@ -183,6 +255,18 @@ func initRoom(room, needAuthUser, needAuthRoom, needAuthWithoutGuestRoom *gin.Ro
 
 		needAuthRoomAdmin.POST("/members/unban", RoomAdminUnbanMember)
 
+		needAuthRoomAdmin.POST("/members/mute", RoomAdminMuteMember)
+
+		needAuthRoomAdmin.POST("/members/unmute", RoomAdminUnmuteMember)
+
+		needAuthRoomAdmin.POST("/chat/delete", RoomAdminDeleteChatMessage)
+
+		needAuthRoomAdmin.POST("/chat/pin", RoomAdminPinChatMessage)
+
+		needAuthRoomAdmin.POST("/chat/unpin", RoomAdminUnpinChatMessage)
+
+		needAuthRoomAdmin.GET("/live/stats", RoomAdminLiveStats)
+
 		needAuthRoomCreator.POST("/members/member", RoomSetMember)
 
 		needAuthRoomCreator.POST("/members/member/permissions", RoomSetMemberPermissions)
@@ -205,6 +289,18 @@ func initMovie(movie, needAuthMovie *gin.RouterGroup) {
 
 	needAuthMovie.POST("/pushs", PushMovies)
 
+	needAuthMovie.GET("/requests", MovieRequests)
+
+	needAuthMovie.POST("/requests/push", PushMovieRequest)
+
+	needAuthMovie.POST("/requests/vote", VoteMovieRequest)
+
+	needAuthMovie.POST("/requests/unvote", UnvoteMovieRequest)
+
+	needAuthMovie.POST("/requests/promote", PromoteMovieRequest)
+
+	needAuthMovie.POST("/requests/delete", DeleteMovieRequest)
+
 	needAuthMovie.POST("/edit", EditMovie)
 
 	needAuthMovie.POST("/swap", SwapMovie)
@@ -225,19 +321,45 @@ func initMovie(movie, needAuthMovie *gin.RouterGroup) {
 
 		needAuthLive.POST("/publishKey", NewPublishKey)
 
+		needAuthLive.POST("/publishKey/revoke", RevokePublishKey)
+
 		needAuthLive.GET("/flv/:movieId", JoinFlvLive)
 
 		needAuthLive.GET("/hls/list/:movieId", JoinHlsLive)
 
+		needAuthLive.GET("/hls/timeshift/:movieId", JoinHlsTimeShift)
+
 		live.GET("/hls/data/:roomId/:movieId/:dataId", ServeHlsLive)
+
+		live.GET("/hls/abr/:roomId/:movieId/*file", ServeHlsTranscoded)
+
+		live.POST("/whip/:roomId", WHIPPublish)
+
+		live.DELETE("/whip/:roomId/:sessionId", WHIPUnpublish)
 	}
 
 	needAuthMovie.GET("/danmu/:movieId", StreamDanmu)
+
+	needAuthMovie.GET("/recordings", RoomRecordings)
+
+	needAuthMovie.POST("/recordings/delete", DeleteRoomRecording)
+
+	needAuthMovie.GET("/recording/:recordingId/*file", ServeRecording)
 }
 
 func initUser(user, needAuthUser *gin.RouterGroup) {
 	user.POST("/login", LoginUser)
 
+	user.POST("/login/2fa", LoginUserTwoFactor)
+
+	user.POST("/login/2fa/webauthn/begin", LoginUserTwoFactorWebAuthnBegin)
+
+	user.POST("/login/2fa/webauthn", LoginUserTwoFactorWebAuthn)
+
+	user.POST("/webauthn/login/begin", WebAuthnLoginBegin)
+
+	user.POST("/webauthn/login", WebAuthnLogin)
+
 	user.POST("/signup", UserSignupPassword)
 
 	user.GET("/signup/email/captcha", GetUserSignupEmailStep1Captcha)
@@ -274,6 +396,32 @@ func initUser(user, needAuthUser *gin.RouterGroup) {
 
 	needAuthUser.POST("/unbind/email", UserUnbindEmail)
 
+	needAuthUser.GET("/2fa", UserTwoFactor)
+
+	needAuthUser.POST("/2fa/totp/setup", UserTotpSetup)
+
+	needAuthUser.POST("/2fa/totp/enable", UserTotpEnable)
+
+	needAuthUser.POST("/2fa/totp/disable", UserTotpDisable)
+
+	needAuthUser.POST("/2fa/recovery", UserTotpRecoveryCodes)
+
+	needAuthUser.GET("/webauthn", WebAuthnCredentials)
+
+	needAuthUser.POST("/webauthn/register/begin", WebAuthnRegisterBegin)
+
+	needAuthUser.POST("/webauthn/register", WebAuthnRegister)
+
+	needAuthUser.POST("/webauthn/rename", WebAuthnRename)
+
+	needAuthUser.POST("/webauthn/delete", WebAuthnDelete)
+
+	needAuthUser.GET("/tokens", UserAPITokens)
+
+	needAuthUser.POST("/tokens", CreateUserAPIToken)
+
+	needAuthUser.POST("/tokens/revoke", RevokeUserAPIToken)
+
 	{
 		needAuthRoom := needAuthUser.Group("/room")
 
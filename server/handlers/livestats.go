@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/op"
+	pb "github.com/synctv-org/synctv/proto/message"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func RoomAdminLiveStats(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	stats, err := user.GetRoomLiveStats(room)
+	if err != nil {
+		log.Errorf("get room live stats failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+		return
+	}
+
+	list := make([]*model.LiveStatsResp, len(stats))
+	for i, s := range stats {
+		list[i] = &model.LiveStatsResp{
+			MovieID:          s.MovieID,
+			Publishing:       s.Publishing,
+			VideoCodec:       s.VideoCodec,
+			AudioCodec:       s.AudioCodec,
+			Width:            s.Width,
+			Height:           s.Height,
+			FrameRate:        s.FrameRate,
+			Bitrate:          s.Bitrate,
+			KeyframeInterval: s.KeyframeInterval.Milliseconds(),
+			FlvViewers:       s.FlvViewers,
+			HlsViewers:       s.HlsViewers,
+		}
+		if s.Publishing {
+			list[i].PublishedAt = s.PublishedAt.UnixMilli()
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(list))
+}
+
+func genLiveStatsPb(stats []*op.LiveStats) []*pb.LiveStats {
+	list := make([]*pb.LiveStats, len(stats))
+	for i, s := range stats {
+		list[i] = &pb.LiveStats{
+			MovieId:          s.MovieID,
+			Publishing:       s.Publishing,
+			VideoCodec:       s.VideoCodec,
+			AudioCodec:       s.AudioCodec,
+			Width:            uint32(s.Width),
+			Height:           uint32(s.Height),
+			FrameRate:        s.FrameRate,
+			Bitrate:          s.Bitrate,
+			KeyframeInterval: uint32(s.KeyframeInterval.Milliseconds()),
+			FlvViewers:       uint32(s.FlvViewers),
+			HlsViewers:       uint32(s.HlsViewers),
+		}
+		if s.Publishing {
+			list[i].PublishedAt = s.PublishedAt.UnixMilli()
+		}
+	}
+
+	return list
+}
@@ -23,7 +23,7 @@ func RoomMembers(ctx *gin.Context) {
 		return
 	}
 
-	scopes := []func(db *gorm.DB) *gorm.DB{}
+	scopes := []func(db *gorm.DB) *gorm.DB{db.WithContext(ctx)}
 
 	switch ctx.DefaultQuery("role", "") {
 	case "admin":
@@ -123,7 +123,7 @@ func RoomAdminMembers(ctx *gin.Context) {
 		return
 	}
 
-	scopes := []func(db *gorm.DB) *gorm.DB{}
+	scopes := []func(db *gorm.DB) *gorm.DB{db.WithContext(ctx)}
 
 	switch ctx.DefaultQuery("status", "active") {
 	case "pending":
@@ -396,3 +396,47 @@ func RoomSetAdminPermissions(ctx *gin.Context) {
 
 	ctx.Status(http.StatusNoContent)
 }
+
+func RoomAdminMuteMember(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	room := middlewares.GetRoomEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.RoomMuteMemberReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode room mute user req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	err := user.MuteRoomMember(room, req.ID, req.DurationTime())
+	if err != nil {
+		log.Errorf("mute room user failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func RoomAdminUnmuteMember(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	room := middlewares.GetRoomEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.RoomUnmuteMemberReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("decode room unmute user req failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	err := user.UnmuteRoomMember(room, req.ID)
+	if err != nil {
+		log.Errorf("unmute room user failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/metrics"
+)
+
+// MetricsHandler serves the prometheus metrics, a bearer token is required
+// when one is configured
+func MetricsHandler() http.Handler {
+	return metrics.Handler(conf.Conf.Server.Metrics.Token)
+}
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/synctv-org/synctv/internal/conf"
@@ -28,6 +29,7 @@ import (
 	"github.com/synctv-org/synctv/utils"
 	"github.com/zijiren233/livelib/protocol/hls"
 	"github.com/zijiren233/livelib/protocol/httpflv"
+	rtmps "github.com/zijiren233/livelib/server"
 )
 
 func GetPageItems[T any](ctx *gin.Context, items []T) ([]T, error) {
@@ -73,6 +75,21 @@ func genMovieInfo(
 		if err != nil {
 			return nil, err
 		}
+	case movie.RecordingID != "":
+		recording, err := room.GetRecording(movie.RecordingID)
+		if err != nil {
+			return nil, err
+		}
+
+		movie.URL = fmt.Sprintf(
+			"/api/room/movie/recording/%s/%s?token=%s&roomId=%s",
+			recording.ID,
+			recording.Format.FileName(),
+			userToken,
+			opMovie.RoomID,
+		)
+		movie.Type = recording.Format.MovieType()
+		movie.Headers = nil
 	case movie.RtmpSource:
 		movie.URL = fmt.Sprintf(
 			"/api/room/movie/live/hls/list/%s.m3u8?token=%s&roomId=%s",
@@ -91,9 +108,10 @@ func genMovieInfo(
 			),
 			Type: "flv",
 		})
+		movie.MoreSources = appendTimeShiftSource(movie.MoreSources, opMovie, userToken)
 		movie.Headers = nil
 	case movie.Live && movie.Proxy:
-		if !utils.IsM3u8Url(movie.URL) {
+		if !opMovie.HlsPassthrough() {
 			movie.MoreSources = append(movie.MoreSources, &dbModel.MoreSource{
 				Name: "flv",
 				URL: fmt.Sprintf(
@@ -104,6 +122,7 @@ func genMovieInfo(
 				),
 				Type: "flv",
 			})
+			movie.MoreSources = appendTimeShiftSource(movie.MoreSources, opMovie, userToken)
 		}
 
 		movie.URL = fmt.Sprintf(
@@ -152,6 +171,27 @@ func genMovieInfo(
 	return resp, nil
 }
 
+func appendTimeShiftSource(
+	sources []*dbModel.MoreSource,
+	opMovie *op.Movie,
+	userToken string,
+) []*dbModel.MoreSource {
+	if !opMovie.TimeShiftEnabled() {
+		return sources
+	}
+
+	return append(sources, &dbModel.MoreSource{
+		Name: "timeshift",
+		URL: fmt.Sprintf(
+			"/api/room/movie/live/hls/timeshift/%s.m3u8?token=%s&roomId=%s",
+			opMovie.ID,
+			userToken,
+			opMovie.RoomID,
+		),
+		Type: "m3u8",
+	})
+}
+
 func genCurrentRespWithCurrent(
 	ctx context.Context,
 	room *op.Room,
@@ -181,9 +221,10 @@ func genCurrentRespWithCurrent(
 	}
 
 	resp := &model.CurrentMovieResp{
-		Status:   current.UpdateStatus(),
-		Movie:    mr,
-		ExpireID: expireID,
+		Status:    current.UpdateStatus(),
+		Movie:     mr,
+		ExpireID:  expireID,
+		TimeShift: current.Movie.TimeShift,
 	}
 
 	return resp, nil
@@ -281,7 +322,7 @@ func Movies(ctx *gin.Context) {
 		}
 	}
 
-	m, total, err := user.GetRoomMoviesWithPage(room, ctx.Query("keyword"), page, _max, id)
+	m, total, err := user.GetRoomMoviesWithPage(ctx, room, ctx.Query("keyword"), page, _max, id)
 	if err != nil {
 		log.Errorf("get room movies with page error: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
@@ -508,7 +549,16 @@ func NewPublishKey(ctx *gin.Context) {
 		return
 	}
 
-	token, err := rtmp.NewRtmpAuthorization(movie.ID)
+	version, err := movie.LoadPublishKeyVersion()
+	if err != nil {
+		log.Errorf("new publish key error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	expire := time.Duration(settings.PublishKeyExpire.Get()) * time.Hour
+
+	token, err := rtmp.NewRtmpAuthorization(movie.ID, version, expire)
 	if err != nil {
 		log.Errorf("new publish key error: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
@@ -531,11 +581,56 @@ func NewPublishKey(ctx *gin.Context) {
 		host = ctx.Request.Host
 	}
 
-	ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+	resp := gin.H{
 		"host":  host,
 		"app":   room.ID,
 		"token": token,
-	}))
+	}
+	if expire > 0 {
+		resp["expireAt"] = time.Now().Add(expire).UnixMilli()
+	}
+
+	if conf.Conf.Server.WHIP.Enable {
+		resp["whip"] = "/api/room/movie/live/whip/" + room.ID
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(resp))
+}
+
+// RevokePublishKey rejects every publish key issued for the movie so far and
+// disconnects its publisher, a new key must be requested afterwards
+func RevokePublishKey(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.IDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("revoke publish key error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.RevokeRoomPublishKey(room, req.ID); err != nil {
+		log.Errorf("revoke publish key error: %v", err)
+
+		if errors.Is(err, dbModel.ErrNoPermission) {
+			ctx.AbortWithStatusJSON(
+				http.StatusForbidden,
+				model.NewAPIErrorResp(
+					fmt.Errorf("revoke publish key error: %w", err),
+				),
+			)
+
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
 }
 
 func EditMovie(ctx *gin.Context) {
@@ -672,7 +767,7 @@ func ChangeCurrentMovie(ctx *gin.Context) {
 	if err != nil {
 		log.Errorf("change current movie error: %v", err)
 
-		if errors.Is(err, dbModel.ErrNoPermission) {
+		if errors.Is(err, dbModel.ErrNoPermission) || errors.Is(err, dbModel.ErrNotLeader) {
 			ctx.AbortWithStatusJSON(
 				http.StatusForbidden,
 				model.NewAPIErrorResp(
@@ -940,6 +1035,8 @@ func JoinFlvLive(ctx *gin.Context) {
 		return
 	}
 
+	defer m.WatchFlv()()
+
 	err = w.SendPacket(ctx.Request.Context())
 	if err != nil {
 		log.Errorf("join flv live error: %v", err)
@@ -987,7 +1084,9 @@ func JoinHlsLive(ctx *gin.Context) {
 		return
 	}
 
-	if utils.IsM3u8Url(m.URL) {
+	m.WatchHls(ctx.ClientIP())
+
+	if m.HlsPassthrough() {
 		err = proxy.M3u8(ctx,
 			m.URL,
 			m.Headers,
@@ -1004,6 +1103,21 @@ func JoinHlsLive(ctx *gin.Context) {
 		return
 	}
 
+	if m.TranscodeEnabled() && ctx.Query("source") == "" {
+		b, err := genTranscodedM3U8File(room.ID, m)
+		if err != nil {
+			if !errors.Is(err, op.ErrTranscodeNotReady) {
+				log.Warnf("join transcoded hls live error, fall back to the source: %v", err)
+			}
+
+			b = sourceMasterPlaylist(ctx.Request.URL)
+		}
+
+		ctx.Data(http.StatusOK, hls.M3U8ContentType, b)
+
+		return
+	}
+
 	channel, err := m.Channel()
 	if err != nil {
 		log.Errorf("join hls live error: %v", err)
@@ -1034,6 +1148,149 @@ func JoinHlsLive(ctx *gin.Context) {
 	ctx.Data(http.StatusOK, hls.M3U8ContentType, b)
 }
 
+// nominal bandwidth of the source when it is the only variant
+const sourceBandwidth = 5000000
+
+func genTranscodedM3U8File(roomID string, m *op.Movie) ([]byte, error) {
+	t, err := m.Transcoder()
+	if err != nil {
+		return nil, err
+	}
+
+	return t.MasterPlaylist(func(rendition string) string {
+		return fmt.Sprintf("/api/room/movie/live/hls/abr/%s/%s/%s", roomID, m.ID, rendition)
+	})
+}
+
+// sourceMasterPlaylist is served while the ladder is not ready, players
+// reload the variant and so keep playing the source instead of switching
+// playlist types.
+func sourceMasterPlaylist(u *url.URL) []byte {
+	src := *u
+
+	q := src.Query()
+	q.Set("source", "1")
+	src.RawQuery = q.Encode()
+
+	return fmt.Appendf(
+		nil,
+		"#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=%d\n%s\n",
+		sourceBandwidth,
+		src.String(),
+	)
+}
+
+func ServeHlsTranscoded(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	roomE, err := op.LoadRoomByID(ctx.Param("roomId"))
+	if err != nil {
+		log.Errorf("serve transcoded hls live error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+		return
+	}
+
+	m, err := roomE.Value().GetMovieByID(ctx.Param("movieId"))
+	if err != nil {
+		log.Errorf("serve transcoded hls live error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+		return
+	}
+
+	t, ok := m.RunningTranscoder()
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(op.ErrTranscodeStopped))
+		return
+	}
+
+	file, err := t.File(strings.TrimPrefix(ctx.Param("file"), "/"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if filepath.Ext(file) == ".m3u8" {
+		m.WatchHls(ctx.ClientIP())
+		ctx.Header("Cache-Control", "no-store")
+		ctx.Header("Content-Type", hls.M3U8ContentType)
+	} else {
+		ctx.Header("Cache-Control", "public, max-age=90")
+		ctx.Header("Content-Type", hls.TSContentType)
+	}
+
+	ctx.File(file)
+}
+
+func JoinHlsTimeShift(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	ctx.Header("Cache-Control", "no-store")
+	room := middlewares.GetRoomEntry(ctx).Value()
+	movieID := strings.TrimSuffix(strings.Trim(ctx.Param("movieId"), "/"), ".m3u8")
+
+	m, err := room.GetMovieByID(movieID)
+	if err != nil {
+		log.Errorf("join hls time shift error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if m.RtmpSource && !conf.Conf.Server.RTMP.Enable {
+		log.Error("join hls time shift error: rtmp is not enabled")
+		ctx.AbortWithStatusJSON(
+			http.StatusBadRequest,
+			model.NewAPIErrorStringResp("rtmp is not enabled"),
+		)
+
+		return
+	}
+
+	ts, err := m.TimeShift()
+	if err != nil {
+		log.Errorf("join hls time shift error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	b, err := ts.GenM3U8File(func(tsName string) (tsPath string) {
+		ext := "ts"
+		if settings.TSDisguisedAsPng.Get() {
+			ext = "png"
+		}
+
+		return fmt.Sprintf(
+			"/api/room/movie/live/hls/data/%s/%s/%s.%s",
+			room.ID,
+			movieID,
+			tsName,
+			ext,
+		)
+	})
+	if err != nil {
+		log.Errorf("join hls time shift error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+		return
+	}
+
+	m.WatchHls(ctx.ClientIP())
+	ctx.Data(http.StatusOK, hls.M3U8ContentType, b)
+}
+
+// getLiveTsFile falls back to the time shift window once a segment left the live playlist
+func getLiveTsFile(m *op.Movie, channel *rtmps.Channel, tsName string) ([]byte, error) {
+	b, err := channel.GetTsFile(tsName)
+	if err == nil {
+		return b, nil
+	}
+
+	ts, tsErr := m.TimeShift()
+	if tsErr != nil {
+		return nil, err
+	}
+
+	return ts.GetSegment(tsName)
+}
+
 //nolint:gosec
 func ServeHlsLive(ctx *gin.Context) {
 	log := middlewares.GetLogger(ctx)
@@ -1105,7 +1362,7 @@ func ServeHlsLive(ctx *gin.Context) {
 			return
 		}
 
-		b, err := channel.GetTsFile(strings.TrimSuffix(dataID, fileExt))
+		b, err := getLiveTsFile(m, channel, strings.TrimSuffix(dataID, fileExt))
 		if err != nil {
 			log.Errorf("serve hls live error: %v", err)
 			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
@@ -1125,7 +1382,7 @@ func ServeHlsLive(ctx *gin.Context) {
 			return
 		}
 
-		b, err := channel.GetTsFile(strings.TrimSuffix(dataID, fileExt))
+		b, err := getLiveTsFile(m, channel, strings.TrimSuffix(dataID, fileExt))
 		if err != nil {
 			log.Errorf("serve hls live error: %v", err)
 			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
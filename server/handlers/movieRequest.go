@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/synctv-org/synctv/utils"
+)
+
+func MovieRequests(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	page, pageSize, err := utils.GetPageAndMax(ctx)
+	if err != nil {
+		log.Errorf("get movie requests failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	requests, total, err := room.GetMovieRequestsWithPage(page, pageSize)
+	if err != nil {
+		log.Errorf("get movie requests failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ids := make([]string, len(requests))
+	for i, r := range requests {
+		ids[i] = r.ID
+	}
+
+	voted, err := db.GetVotedMovieRequestIDs(user.ID, ids)
+	if err != nil {
+		log.Errorf("get movie requests failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	list := make([]*model.MovieRequestResp, len(requests))
+	for i, r := range requests {
+		_, ok := voted[r.ID]
+		list[i] = &model.MovieRequestResp{
+			ID:        r.ID,
+			Creator:   op.GetUserName(r.CreatorID),
+			CreatorID: r.CreatorID,
+			Base:      r.MovieBase,
+			CreatedAt: r.CreatedAt.UnixMilli(),
+			Votes:     r.Votes,
+			Voted:     ok,
+		}
+		// hide url and headers when proxy
+		if user.ID != r.CreatorID && r.Proxy {
+			list[i].Base.URL = ""
+			list[i].Base.Headers = nil
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+		"total": total,
+		"list":  list,
+	}))
+}
+
+func PushMovieRequest(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.PushMovieRequestReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("push movie request failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	r, err := user.RequestRoomMovie(room, (*dbModel.MovieBase)(&req))
+	if err != nil {
+		log.Errorf("push movie request failed: %v", err)
+		handleMovieRequestError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(r))
+}
+
+func VoteMovieRequest(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.MovieRequestIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("vote movie request failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.VoteRoomMovieRequest(room, req.ID); err != nil {
+		log.Errorf("vote movie request failed: %v", err)
+		handleMovieRequestError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func UnvoteMovieRequest(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.MovieRequestIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("unvote movie request failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.UnvoteRoomMovieRequest(room, req.ID); err != nil {
+		log.Errorf("unvote movie request failed: %v", err)
+		handleMovieRequestError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func PromoteMovieRequest(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.MovieRequestIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("promote movie request failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	m, err := user.PromoteRoomMovieRequest(room, req.ID)
+	if err != nil {
+		log.Errorf("promote movie request failed: %v", err)
+		handleMovieRequestError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(m))
+}
+
+func DeleteMovieRequest(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.MovieRequestIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("delete movie request failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteRoomMovieRequest(room, req.ID); err != nil {
+		log.Errorf("delete movie request failed: %v", err)
+		handleMovieRequestError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func handleMovieRequestError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+	case errors.Is(err, db.NotFoundError(db.ErrMovieRequestNotFound)):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+	}
+}
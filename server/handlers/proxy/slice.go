@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/synctv-org/synctv/internal/metrics"
 	"github.com/zijiren233/ksync"
 	"github.com/zijiren233/stream"
 )
@@ -255,6 +256,7 @@ func (c *SliceCacheProxy) getCacheItem(alignedOffset int64) (*CacheItem, bool, e
 	}
 
 	if ok {
+		metrics.ProxyCache(true, len(slice.Data))
 		return slice, true, nil
 	}
 
@@ -264,6 +266,8 @@ func (c *SliceCacheProxy) getCacheItem(alignedOffset int64) (*CacheItem, bool, e
 		return nil, false, fmt.Errorf("failed to fetch item from source: %w", err)
 	}
 
+	metrics.ProxyCache(false, len(slice.Data))
+
 	// Store in cache
 	if err = c.cache.Set(cacheKey, slice); err != nil {
 		return nil, false, fmt.Errorf("failed to store item in cache: %w", err)
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+	"github.com/zijiren233/livelib/protocol/hls"
+)
+
+func RoomRecordings(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	if !user.HasRoomPermission(room, dbModel.PermissionGetMovieList) {
+		ctx.AbortWithStatusJSON(
+			http.StatusForbidden,
+			model.NewAPIErrorResp(dbModel.ErrNoPermission),
+		)
+
+		return
+	}
+
+	recordings, err := room.GetRecordings()
+	if err != nil {
+		log.Errorf("get room recordings failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	list := make([]*model.RecordingResp, len(recordings))
+	for i, r := range recordings {
+		list[i] = &model.RecordingResp{
+			ID:            r.ID,
+			Name:          r.Name,
+			SourceMovieID: r.SourceMovieID,
+			MovieID:       r.MovieID,
+			Creator:       op.GetUserName(r.CreatorID),
+			CreatorID:     r.CreatorID,
+			Format:        string(r.Format),
+			Status:        string(r.Status),
+			CreatedAt:     r.CreatedAt.UnixMilli(),
+			Size:          r.Size,
+			Duration:      r.Duration,
+		}
+		if r.EndedAt != nil {
+			list[i].EndedAt = r.EndedAt.UnixMilli()
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+		"total": len(list),
+		"list":  list,
+	}))
+}
+
+func DeleteRoomRecording(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.IDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("delete room recording failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteRoomRecording(room, req.ID); err != nil {
+		log.Errorf("delete room recording failed: %v", err)
+		handleRecordingError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func ServeRecording(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	if !user.HasRoomPermission(room, dbModel.PermissionGetMovieList) {
+		ctx.AbortWithStatusJSON(
+			http.StatusForbidden,
+			model.NewAPIErrorResp(dbModel.ErrNoPermission),
+		)
+
+		return
+	}
+
+	recording, err := room.GetRecording(ctx.Param("recordingId"))
+	if err != nil {
+		log.Errorf("serve recording error: %v", err)
+		handleRecordingError(ctx, err)
+		return
+	}
+
+	if recording.Status != dbModel.RecordingStatusFinished {
+		ctx.AbortWithStatusJSON(
+			http.StatusBadRequest,
+			model.NewAPIErrorResp(op.ErrRecordingInProgress),
+		)
+
+		return
+	}
+
+	name := filepath.Base(ctx.Param("file"))
+	file := filepath.Join(op.RecordingDir(recording.RoomID, recording.ID), name)
+
+	if name != dbModel.RecordingHLSIndex {
+		if filepath.Ext(name) == ".ts" {
+			ctx.Header("Content-Type", hls.TSContentType)
+		}
+
+		ctx.File(file)
+
+		return
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		log.Errorf("serve recording error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+		return
+	}
+
+	// segments are served by this handler too, so they need the same token
+	query := ctx.Request.URL.RawQuery
+	buf := bytes.NewBuffer(make([]byte, 0, len(b)))
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		if line != "" && !strings.HasPrefix(line, "#") && query != "" {
+			line += "?" + query
+		}
+
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+
+	ctx.Data(http.StatusOK, hls.M3U8ContentType, buf.Bytes())
+}
+
+func handleRecordingError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+	case errors.Is(err, db.NotFoundError(db.ErrRecordingNotFound)):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+	}
+}
@@ -48,6 +48,7 @@ func RoomMe(ctx *gin.Context) {
 		Role:             member.Role,
 		Permissions:      member.Permissions,
 		AdminPermissions: member.AdminPermissions,
+		MutedUntil:       member.MutedUntilUnixMilli(),
 	}))
 }
 
@@ -201,6 +202,7 @@ func RoomList(ctx *gin.Context) {
 	}
 
 	scopes := []func(db *gorm.DB) *gorm.DB{
+		db.WithContext(ctx),
 		func(db *gorm.DB) *gorm.DB {
 			return db.InnerJoins("JOIN room_settings ON rooms.id = room_settings.id")
 		},
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	dbModel "github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/op"
 	"github.com/synctv-org/synctv/server/middlewares"
 	"github.com/synctv-org/synctv/server/model"
@@ -57,6 +58,8 @@ func RootAddAdmin(ctx *gin.Context) {
 		return
 	}
 
+	user.RecordAudit(dbModel.AuditUserAddAdmin, "", req.ID, nil, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -108,5 +111,7 @@ func RootDeleteAdmin(ctx *gin.Context) {
 		return
 	}
 
+	user.Value().RecordAudit(dbModel.AuditUserDeleteAdmin, "", req.ID, nil, nil)
+
 	ctx.Status(http.StatusNoContent)
 }
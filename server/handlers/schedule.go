@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func RoomSchedules(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	schedules, err := room.GetUpcomingSchedules()
+	if err != nil {
+		log.Errorf("get room schedules failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ids := make([]string, len(schedules))
+	for i, s := range schedules {
+		ids[i] = s.ID
+	}
+
+	subscribed, err := db.GetSubscribedRoomScheduleIDs(user.ID, ids)
+	if err != nil {
+		log.Errorf("get room schedules failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	list := make([]*model.ScheduleResp, len(schedules))
+	for i, s := range schedules {
+		_, ok := subscribed[s.ID]
+		list[i] = &model.ScheduleResp{
+			ID:         s.ID,
+			Title:      s.Title,
+			MovieID:    s.MovieID,
+			SubPath:    s.SubPath,
+			Creator:    op.GetUserName(s.CreatorID),
+			CreatorID:  s.CreatorID,
+			StartAt:    s.StartAt.UnixMilli(),
+			Subscribed: ok,
+		}
+		if s.RemindAt != nil {
+			list[i].RemindBefore = int64(s.StartAt.Sub(*s.RemindAt) / time.Second)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+		"total": len(list),
+		"list":  list,
+	}))
+}
+
+func CreateRoomSchedule(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.CreateScheduleReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("create room schedule failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	schedule := &dbModel.RoomSchedule{
+		Title:    req.Title,
+		MovieID:  req.MovieID,
+		SubPath:  req.SubPath,
+		StartAt:  time.UnixMilli(req.StartAt),
+		RemindAt: req.RemindAt(),
+	}
+
+	if err := user.CreateRoomSchedule(room, schedule); err != nil {
+		log.Errorf("create room schedule failed: %v", err)
+		handleScheduleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+		"id": schedule.ID,
+	}))
+}
+
+func DeleteRoomSchedule(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.ScheduleIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("delete room schedule failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteRoomSchedule(room, req.ID); err != nil {
+		log.Errorf("delete room schedule failed: %v", err)
+		handleScheduleError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func SubscribeRoomSchedule(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.ScheduleIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("subscribe room schedule failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if user.Email == "" {
+		ctx.AbortWithStatusJSON(
+			http.StatusBadRequest,
+			model.NewAPIErrorStringResp("bind an email to receive reminders"),
+		)
+		return
+	}
+
+	if err := user.SubscribeRoomSchedule(room, req.ID); err != nil {
+		log.Errorf("subscribe room schedule failed: %v", err)
+		handleScheduleError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func UnsubscribeRoomSchedule(ctx *gin.Context) {
+	room := middlewares.GetRoomEntry(ctx).Value()
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	req := model.ScheduleIDReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("unsubscribe room schedule failed: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.UnsubscribeRoomSchedule(room, req.ID); err != nil {
+		log.Errorf("unsubscribe room schedule failed: %v", err)
+		handleScheduleError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func handleScheduleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, dbModel.ErrNoPermission), errors.Is(err, dbModel.ErrNotLeader):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+	case errors.Is(err, db.NotFoundError(db.ErrRoomScheduleNotFound)):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+func genAPITokenResp(t *dbModel.APIToken) *model.APITokenResp {
+	resp := &model.APITokenResp{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		RoomID:     t.RoomID,
+		CreatedAt:  t.CreatedAt.UnixMilli(),
+		LastUsedAt: t.LastUsedAt.UnixMilli(),
+	}
+	if t.ExpiresAt != nil {
+		resp.ExpiresAt = t.ExpiresAt.UnixMilli()
+	}
+
+	return resp
+}
+
+func UserAPITokens(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	tokens, err := user.ListAPITokens()
+	if err != nil {
+		log.Errorf("failed to get api tokens: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	resp := make([]*model.APITokenResp, len(tokens))
+	for i, t := range tokens {
+		resp[i] = genAPITokenResp(t)
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(resp))
+}
+
+func CreateUserAPIToken(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.CreateAPITokenReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != 0 {
+		t := time.UnixMilli(req.ExpiresAt)
+		expiresAt = &t
+	}
+
+	token, t, err := user.CreateAPIToken(req.Name, req.Scopes, req.RoomID, expiresAt)
+	if err != nil {
+		log.Errorf("failed to create api token: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(&model.CreateAPITokenResp{
+		APITokenResp: genAPITokenResp(t),
+		Token:        token,
+	}))
+}
+
+func RevokeUserAPIToken(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.IDReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.RevokeAPIToken(req.ID); err != nil {
+		log.Errorf("failed to revoke api token: %v", err)
+
+		if errors.Is(err, db.NotFoundError(db.ErrAPITokenNotFound)) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/synctv-org/synctv/internal/captcha"
 	"github.com/synctv-org/synctv/internal/db"
 	"github.com/synctv-org/synctv/internal/email"
+	"github.com/synctv-org/synctv/internal/ldap"
 	dbModel "github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/op"
 	"github.com/synctv-org/synctv/internal/provider"
@@ -47,6 +48,12 @@ func LoginUser(ctx *gin.Context) {
 		return
 	}
 
+	if a := ldap.Default(); a != nil && req.Username != "" {
+		if handleLdapLogin(ctx, a, &req) {
+			return
+		}
+	}
+
 	var (
 		user *synccache.Entry[*op.User]
 		err  error
@@ -84,6 +91,22 @@ func LoginUser(ctx *gin.Context) {
 		return
 	}
 
+	// ldap users fall back to the local accounts only when ldap does not
+	// answer for them, their local password is never checked
+	ldapUser, err := user.Value().IsLdapUser()
+	if err != nil {
+		log.Errorf("failed to get bind providers: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if ldapUser {
+		log.Errorf("ldap user login without ldap")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(op.ErrLdapUser))
+
+		return
+	}
+
 	if ok := user.Value().CheckPassword(req.Password); !ok {
 		log.Errorf("password incorrect")
 		ctx.AbortWithStatusJSON(
@@ -94,9 +117,227 @@ func LoginUser(ctx *gin.Context) {
 		return
 	}
 
+	handleUserLogin(ctx, user.Value())
+}
+
+// handleLdapLogin returns false when the login falls back to the local
+// accounts: the user is not in ldap or not provisioned, or the server is
+// unreachable
+func handleLdapLogin(ctx *gin.Context, a *ldap.Authenticator, req *model.LoginUserReq) bool {
+	log := middlewares.GetLogger(ctx)
+
+	e, err := a.Authenticate(req.Username, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, ldap.ErrUserNotFound):
+			return false
+		case errors.Is(err, ldap.ErrInvalidCredentials):
+			log.Errorf("password incorrect")
+			ctx.AbortWithStatusJSON(
+				http.StatusForbidden,
+				model.NewAPIErrorStringResp("password incorrect"),
+			)
+		case errors.Is(err, ldap.ErrNotInGroup):
+			log.Errorf("ldap login failed: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+		default:
+			log.Errorf("ldap login failed, fall back to local user: %v", err)
+			return false
+		}
+
+		return true
+	}
+
+	user, err := op.LoadOrCreateLdapUser(e, a.AutoProvision())
+	if err != nil {
+		// not provisioned, the user may have a local account
+		if errors.Is(err, db.NotFoundError(db.ErrUserNotFound)) {
+			return false
+		}
+
+		log.Errorf("failed to load ldap user: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+
+		return true
+	}
+
+	handleUserLogin(ctx, user.Value())
+
+	return true
+}
+
+// handleUserLogin issues a token, or a challenge when the user has totp or a
+// passkey. totp tells whether it can be answered with LoginUserTwoFactor and
+// passkey whether with LoginUserTwoFactorWebAuthn
+func handleUserLogin(ctx *gin.Context, user *op.User) {
+	log := middlewares.GetLogger(ctx)
+
+	twoFactor, err := user.TwoFactorEnabled()
+	if err != nil {
+		log.Errorf("failed to load 2fa: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if !twoFactor {
+		handleUserToken(ctx, user)
+		return
+	}
+
+	passkey, err := user.HasWebAuthnCredentials()
+	if err != nil {
+		log.Errorf("failed to get passkeys: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	challenge, err := op.NewTwoFactorChallenge(user.ID)
+	if err != nil {
+		log.Errorf("failed to create 2fa challenge: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+		"twoFactor": true,
+		"challenge": challenge,
+		"totp":      user.TotpEnabled(),
+		"passkey":   passkey,
+	}))
+}
+
+func LoginUserTwoFactor(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	req := model.LoginUserTwoFactorReq{}
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	user, err := op.VerifyTwoFactorChallenge(req.Challenge, req.Code)
+	if err != nil {
+		log.Errorf("2fa login failed: %v", err)
+		ctx.AbortWithStatusJSON(totpErrorStatus(err), model.NewAPIErrorResp(err))
+		return
+	}
+
 	handleUserToken(ctx, user.Value())
 }
 
+func totpErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, op.ErrTotpInvalidCode),
+		errors.Is(err, op.ErrTwoFactorChallengeExpired),
+		errors.Is(err, op.ErrTotpRequired),
+		errors.Is(err, op.ErrUserBanned),
+		errors.Is(err, op.ErrUserPending):
+		return http.StatusForbidden
+	case errors.Is(err, op.ErrTotpLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, op.ErrTotpNotEnabled),
+		errors.Is(err, op.ErrTotpEnabled),
+		errors.Is(err, op.ErrTotpSetupExpired):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func UserTwoFactor(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(&model.UserTwoFactorResp{
+		Enabled:           user.TotpEnabled(),
+		Required:          user.TotpRequired(),
+		RecoveryCodesLeft: user.TotpRecoveryCodesLeft(),
+	}))
+}
+
+func UserTotpSetup(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	secret, url, err := user.NewTotpSetup()
+	if err != nil {
+		log.Errorf("failed to setup 2fa: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(&model.UserTotpSetupResp{
+		Secret: secret,
+		URL:    url,
+	}))
+}
+
+func UserTotpEnable(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.UserTotpCodeReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	codes, err := user.EnableTotp(req.Code)
+	if err != nil {
+		log.Errorf("failed to enable 2fa: %v", err)
+		ctx.AbortWithStatusJSON(totpErrorStatus(err), model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(&model.UserTotpRecoveryCodesResp{
+		RecoveryCodes: codes,
+	}))
+}
+
+func UserTotpDisable(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.UserTotpCodeReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.DisableTotp(req.Code); err != nil {
+		log.Errorf("failed to disable 2fa: %v", err)
+		ctx.AbortWithStatusJSON(totpErrorStatus(err), model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func UserTotpRecoveryCodes(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.UserTotpCodeReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	codes, err := user.RegenerateTotpRecoveryCodes(req.Code)
+	if err != nil {
+		log.Errorf("failed to regenerate recovery codes: %v", err)
+		ctx.AbortWithStatusJSON(totpErrorStatus(err), model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(&model.UserTotpRecoveryCodesResp{
+		RecoveryCodes: codes,
+	}))
+}
+
 func handleUserToken(ctx *gin.Context, user *op.User) {
 	log := middlewares.GetLogger(ctx)
 
@@ -150,6 +391,7 @@ func UserRooms(ctx *gin.Context) {
 	}
 
 	scopes := []func(db *gorm.DB) *gorm.DB{
+		db.WithContext(ctx),
 		db.WhereCreatorID(user.ID),
 	}
 
@@ -237,6 +479,7 @@ func UserJoinedRooms(ctx *gin.Context) {
 	}
 
 	scopes := []func(db *gorm.DB) *gorm.DB{
+		db.WithContext(ctx),
 		func(db *gorm.DB) *gorm.DB {
 			return db.
 				InnerJoins(
@@ -384,7 +627,14 @@ func SetUserPassword(ctx *gin.Context) {
 	err := user.SetPassword(req.Password)
 	if err != nil {
 		log.Errorf("failed to set password: %v", err)
+
+		if errors.Is(err, op.ErrLdapUser) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+			return
+		}
+
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+
 		return
 	}
 
@@ -821,11 +1071,18 @@ func UserRetrievePasswordEmail(ctx *gin.Context) {
 	err = user.SetPassword(req.Password)
 	if err != nil {
 		log.Errorf("failed to set password: %v", err)
+
+		if errors.Is(err, op.ErrLdapUser) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+			return
+		}
+
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+
 		return
 	}
 
-	handleUserToken(ctx, user)
+	handleUserLogin(ctx, user)
 }
 
 func UserDeleteRoom(ctx *gin.Context) {
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/synctv-org/synctv/internal/db"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+var errWebAuthnHostNotSet = errors.New("passkeys need the host setting")
+
+// newWebAuthn returns the relying party of the host setting, the host and
+// origin headers of the request are not trusted to name it
+func newWebAuthn() (*webauthn.WebAuthn, error) {
+	host := settings.HOST.Get()
+	if host == "" {
+		return nil, errWebAuthnHostNotSet
+	}
+
+	return op.NewWebAuthn(host)
+}
+
+func webauthnErrorStatus(err error) int {
+	var perr *protocol.Error
+	if errors.As(err, &perr) ||
+		errors.Is(err, op.ErrWebAuthnSessionExpired) ||
+		errors.Is(err, op.ErrWebAuthnClonedKey) {
+		return http.StatusForbidden
+	}
+
+	return totpErrorStatus(err)
+}
+
+func WebAuthnCredentials(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	credentials, err := user.ListWebAuthnCredentials()
+	if err != nil {
+		log.Errorf("failed to get passkeys: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	resp := make([]*model.WebAuthnCredentialResp, len(credentials))
+	for i, c := range credentials {
+		resp[i] = &model.WebAuthnCredentialResp{
+			ID:         c.ID,
+			Name:       c.Name,
+			CreatedAt:  c.CreatedAt.UnixMilli(),
+			LastUsedAt: c.LastUsedAt.UnixMilli(),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(resp))
+}
+
+func WebAuthnRegisterBegin(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	w, err := newWebAuthn()
+	if err != nil {
+		log.Errorf("failed to create webauthn: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	creation, session, err := user.BeginWebAuthnRegistration(w)
+	if err != nil {
+		log.Errorf("failed to begin passkey registration: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(&model.WebAuthnBeginResp{
+		Session: session,
+		Options: creation,
+	}))
+}
+
+func WebAuthnRegister(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.WebAuthnRegisterReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	w, err := newWebAuthn()
+	if err != nil {
+		log.Errorf("failed to create webauthn: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	credential, err := user.FinishWebAuthnRegistration(
+		w,
+		req.Session,
+		req.Name,
+		bytes.NewReader(req.Credential),
+	)
+	if err != nil {
+		log.Errorf("failed to register passkey: %v", err)
+		ctx.AbortWithStatusJSON(webauthnErrorStatus(err), model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(&model.WebAuthnCredentialResp{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		CreatedAt:  credential.CreatedAt.UnixMilli(),
+		LastUsedAt: credential.LastUsedAt.UnixMilli(),
+	}))
+}
+
+func WebAuthnRename(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.RenameWebAuthnCredentialReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.RenameWebAuthnCredential(req.ID, req.Name); err != nil {
+		log.Errorf("failed to rename passkey: %v", err)
+
+		if errors.Is(err, db.NotFoundError(db.ErrWebAuthnCredentialNotFound)) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func WebAuthnDelete(ctx *gin.Context) {
+	user := middlewares.GetUserEntry(ctx).Value()
+	log := middlewares.GetLogger(ctx)
+
+	var req model.IDReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := user.DeleteWebAuthnCredential(req.ID); err != nil {
+		log.Errorf("failed to delete passkey: %v", err)
+
+		if errors.Is(err, db.NotFoundError(db.ErrWebAuthnCredentialNotFound)) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+			return
+		}
+
+		if errors.Is(err, op.ErrTotpRequired) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func WebAuthnLoginBegin(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	w, err := newWebAuthn()
+	if err != nil {
+		log.Errorf("failed to create webauthn: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	assertion, session, err := op.BeginWebAuthnLogin(w)
+	if err != nil {
+		log.Errorf("failed to begin passkey login: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(&model.WebAuthnBeginResp{
+		Session: session,
+		Options: assertion,
+	}))
+}
+
+// WebAuthnLogin issues a token without a second factor, a passkey is
+// verified by the user
+func WebAuthnLogin(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	var req model.WebAuthnLoginReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	w, err := newWebAuthn()
+	if err != nil {
+		log.Errorf("failed to create webauthn: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	user, err := op.FinishWebAuthnLogin(w, req.Session, bytes.NewReader(req.Credential))
+	if err != nil {
+		log.Errorf("passkey login failed: %v", err)
+		ctx.AbortWithStatusJSON(webauthnErrorStatus(err), model.NewAPIErrorResp(err))
+		return
+	}
+
+	handleUserToken(ctx, user.Value())
+}
+
+func LoginUserTwoFactorWebAuthnBegin(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	var req model.TwoFactorChallengeReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	w, err := newWebAuthn()
+	if err != nil {
+		log.Errorf("failed to create webauthn: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	assertion, err := op.BeginTwoFactorWebAuthn(w, req.Challenge)
+	if err != nil {
+		log.Errorf("failed to begin passkey 2fa: %v", err)
+		ctx.AbortWithStatusJSON(webauthnErrorStatus(err), model.NewAPIErrorResp(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewAPIDataResp(&model.WebAuthnBeginResp{
+		Options: assertion,
+	}))
+}
+
+func LoginUserTwoFactorWebAuthn(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	var req model.LoginUserTwoFactorWebAuthnReq
+	if err := model.Decode(ctx, &req); err != nil {
+		log.Errorf("failed to decode request: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	w, err := newWebAuthn()
+	if err != nil {
+		log.Errorf("failed to create webauthn: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	user, err := op.VerifyTwoFactorChallengeWebAuthn(
+		w,
+		req.Challenge,
+		bytes.NewReader(req.Credential),
+	)
+	if err != nil {
+		log.Errorf("passkey 2fa failed: %v", err)
+		ctx.AbortWithStatusJSON(webauthnErrorStatus(err), model.NewAPIErrorResp(err))
+		return
+	}
+
+	handleUserToken(ctx, user.Value())
+}
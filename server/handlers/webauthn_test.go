@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewWebAuthnNeedsHost(t *testing.T) {
+	if _, err := newWebAuthn(); !errors.Is(err, errWebAuthnHostNotSet) {
+		t.Fatalf("relying party without the host setting: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strings"
 	"text/template"
 	"time"
@@ -14,6 +15,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/model"
 	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/settings"
 	pb "github.com/synctv-org/synctv/proto/message"
 	"github.com/synctv-org/synctv/server/middlewares"
 	"github.com/synctv-org/synctv/utils"
@@ -21,7 +23,6 @@ import (
 )
 
 const (
-	maxInterval          = 10
 	MaxChatMessageLength = 4096
 )
 
@@ -37,7 +38,12 @@ func NewWebSocketHandler(wss *utils.WebSocket) gin.HandlerFunc {
 			subprotocols = append(subprotocols, token)
 		}
 
-		_ = wss.Server(ctx.Writer, ctx.Request, subprotocols, NewWSMessageHandler(user, room, log))
+		_ = wss.Server(
+			ctx.Writer,
+			ctx.Request,
+			subprotocols,
+			NewWSMessageHandler(user, room, middlewares.GetAPIToken(ctx), log),
+		)
 	}
 }
 
@@ -50,7 +56,14 @@ func isNormalCloseError(err error) bool {
 	return we.Code == websocket.CloseNormalClosure
 }
 
-func NewWSMessageHandler(u *op.User, r *op.Room, l *log.Entry) func(c *websocket.Conn) error {
+// NewWSMessageHandler serves a client of the room, t is the api token it is
+// authenticated with, nil for a login
+func NewWSMessageHandler(
+	u *op.User,
+	r *op.Room,
+	t *model.APIToken,
+	l *log.Entry,
+) func(c *websocket.Conn) error {
 	return func(c *websocket.Conn) error {
 		client, err := r.NewClient(u, c)
 		if err != nil {
@@ -72,6 +85,10 @@ func NewWSMessageHandler(u *op.User, r *op.Room, l *log.Entry) func(c *websocket
 			return em.Encode(wc)
 		}
 
+		if t != nil {
+			client.SetAPIToken(t)
+		}
+
 		l.Info("ws: connected")
 		defer handleClientDisconnection(r, client, l)
 
@@ -80,6 +97,16 @@ func NewWSMessageHandler(u *op.User, r *op.Room, l *log.Entry) func(c *websocket
 			return err
 		}
 
+		if err := sendLeader(client, r); err != nil {
+			l.Errorf("ws: send leader error: %v", err)
+			return err
+		}
+
+		if err := sendChatHistory(client, r); err != nil {
+			l.Errorf("ws: send chat history error: %v", err)
+			return err
+		}
+
 		go func() {
 			if err := handleReaderMessage(client, l); err != nil {
 				if isNormalCloseError(err) {
@@ -112,6 +139,57 @@ func sendViewerCount(client *op.Client, r *op.Room) error {
 	})
 }
 
+func sendLeader(client *op.Client, r *op.Room) error {
+	leader := r.Leader()
+	if leader == "" {
+		return nil
+	}
+
+	return client.Send(&pb.Message{
+		Type:      pb.MessageType_LEADER,
+		Timestamp: time.Now().UnixMilli(),
+		LeaderId:  leader,
+	})
+}
+
+func sendChatHistory(client *op.Client, r *op.Room) error {
+	messages, err := r.LatestChatMessages()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range messages {
+		err := client.Send(&pb.Message{
+			Type:      pb.MessageType_CHAT,
+			Timestamp: m.CreatedAt.UnixMilli(),
+			ChatId:    m.ID,
+			Sender: &pb.Sender{
+				UserId:   m.UserID,
+				Username: m.Username,
+			},
+			Payload: &pb.Message_ChatContent{
+				ChatContent: m.Content,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	pinned, err := r.PinnedChatMessages()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pinned {
+		if err := client.Send(op.NewChatPinMessage(pb.MessageType_CHAT_PIN, m)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func handleWriterMessage(c *op.Client, l *log.Entry) error {
 	for v := range c.GetReadChan() {
 		if err := writeMessage(c, v); err != nil {
@@ -210,8 +288,29 @@ func readMessage(c *op.Client) (*pb.Message, error) {
 	return &msg, nil
 }
 
+// apiTokenMessageTypes are the messages a client authenticated with a
+// playback:control api token can send, it cannot chat, moderate or lead
+var apiTokenMessageTypes = map[pb.MessageType]struct{}{
+	pb.MessageType_STATUS:       {},
+	pb.MessageType_SYNC:         {},
+	pb.MessageType_EXPIRED:      {},
+	pb.MessageType_CHECK_STATUS: {},
+	pb.MessageType_ENDED:        {},
+	pb.MessageType_PING:         {},
+	pb.MessageType_PONG:         {},
+}
+
 func handleElementMsg(cli *op.Client, msg *pb.Message) error {
-	timeDiff := calculateTimeDiff(msg.GetTimestamp())
+	if cli.APIToken() != nil {
+		if _, ok := apiTokenMessageTypes[msg.GetType()]; !ok {
+			return sendErrorMessage(
+				cli,
+				fmt.Sprintf("api tokens cannot send %v messages", msg.GetType()),
+			)
+		}
+	}
+
+	timeDiff := calculateTimeDiff(cli, msg.GetTimestamp())
 
 	switch msg.GetType() {
 	case pb.MessageType_CHAT:
@@ -224,6 +323,22 @@ func handleElementMsg(cli *op.Client, msg *pb.Message) error {
 		return handleExpiredMessage(cli, msg.GetExpirationId())
 	case pb.MessageType_CHECK_STATUS:
 		return handleCheckStatusMessage(cli, msg, timeDiff)
+	case pb.MessageType_CHAT_DELETE:
+		return handleChatDeleteMessage(cli, msg.GetChatId())
+	case pb.MessageType_CHAT_PIN:
+		return handleChatPinMessage(cli, msg.GetChatId(), true)
+	case pb.MessageType_CHAT_UNPIN:
+		return handleChatPinMessage(cli, msg.GetChatId(), false)
+	case pb.MessageType_LEADER:
+		return handleLeaderMessage(cli, msg.GetLeaderId())
+	case pb.MessageType_ENDED:
+		return handleEndedMessage(cli, msg.GetMovieId())
+	case pb.MessageType_LIVE_STATS:
+		return handleLiveStatsMessage(cli)
+	case pb.MessageType_PING:
+		return handlePingMessage(cli, msg.GetPing())
+	case pb.MessageType_PONG:
+		return handlePongMessage(cli, msg.GetPing())
 	case pb.MessageType_WEBRTC_OFFER:
 		return handleWebRTCOffer(cli, msg.GetWebrtcData())
 	case pb.MessageType_WEBRTC_ANSWER:
@@ -370,12 +485,20 @@ func handleWebRTCLeave(cli *op.Client) error {
 	}, op.WithIgnoreConnID(cli.ConnID()), op.WithRTCJoined())
 }
 
-func calculateTimeDiff(timestamp int64) float64 {
-	if timestamp == 0 {
+// calculateTimeDiff estimates how long ago the client sent the message,
+// half of the measured RTT is preferred over the client clock.
+func calculateTimeDiff(cli *op.Client, timestamp int64) float64 {
+	var timeDiff float64
+
+	switch {
+	case cli.RTT() > 0:
+		timeDiff = cli.RTT().Seconds() / 2
+	case timestamp != 0:
+		timeDiff = time.Since(time.UnixMilli(timestamp)).Seconds()
+	default:
 		return 0.0
 	}
 
-	timeDiff := time.Since(time.UnixMilli(timestamp)).Seconds()
 	if timeDiff < 0 {
 		return 0
 	}
@@ -398,19 +521,58 @@ func handleChatMessage(cli *op.Client, message string) error {
 	}
 
 	err := cli.SendChatMessage(sanitizedMessage)
-	if err != nil && errors.Is(err, model.ErrNoPermission) {
-		return sendErrorMessage(cli, "failed to send message due to permission issue")
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrNoPermission):
+			return sendErrorMessage(cli, "failed to send message due to permission issue")
+		case errors.Is(err, model.ErrMuted):
+			return sendErrorMessage(cli, "failed to send message, you are muted in this room")
+		}
 	}
 
 	return err
 }
 
+func handleChatDeleteMessage(cli *op.Client, id string) error {
+	if id == "" {
+		return sendErrorMessage(cli, "chat id is empty")
+	}
+
+	err := cli.User().DeleteRoomChatMessage(cli.Room(), id)
+	if err != nil {
+		return sendErrorMessage(cli, fmt.Sprintf("delete chat message error: %v", err))
+	}
+
+	return nil
+}
+
+func handleChatPinMessage(cli *op.Client, id string, pinned bool) error {
+	if id == "" {
+		return sendErrorMessage(cli, "chat id is empty")
+	}
+
+	var err error
+	if pinned {
+		err = cli.User().PinRoomChatMessage(cli.Room(), id)
+	} else {
+		err = cli.User().UnpinRoomChatMessage(cli.Room(), id)
+	}
+
+	if err != nil {
+		return sendErrorMessage(cli, fmt.Sprintf("pin chat message error: %v", err))
+	}
+
+	return nil
+}
+
 func handleStatusMessage(cli *op.Client, msg *pb.Message, timeDiff float64) error {
 	playbackStatus := msg.GetPlaybackStatus()
 	if playbackStatus == nil {
 		return sendErrorMessage(cli, "playback status is nil")
 	}
 
+	reportDuration(cli, playbackStatus)
+
 	err := cli.SetStatus(
 		playbackStatus.GetIsPlaying(),
 		playbackStatus.GetCurrentTime(),
@@ -418,12 +580,83 @@ func handleStatusMessage(cli *op.Client, msg *pb.Message, timeDiff float64) erro
 		timeDiff,
 	)
 	if err != nil {
+		if errors.Is(err, model.ErrNotLeader) {
+			return handleStatusSuggestion(cli, playbackStatus)
+		}
+
 		return sendErrorMessage(cli, fmt.Sprintf("set status error: %v", err))
 	}
 
 	return nil
 }
 
+// handleStatusSuggestion forwards the status to the leader and
+// puts the member back in sync with the room.
+func handleStatusSuggestion(cli *op.Client, status *pb.Status) error {
+	if err := cli.SuggestStatus(status); err != nil {
+		return sendErrorMessage(cli, fmt.Sprintf("suggest status error: %v", err))
+	}
+
+	return handleSyncMessage(cli)
+}
+
+func handleLeaderMessage(cli *op.Client, leaderID string) error {
+	if leaderID == "" {
+		return sendErrorMessage(cli, "leader id is empty")
+	}
+
+	if err := cli.User().TransferRoomLeader(cli.Room(), leaderID); err != nil {
+		return sendErrorMessage(cli, fmt.Sprintf("transfer leader error: %v", err))
+	}
+
+	return nil
+}
+
+func reportDuration(cli *op.Client, status *pb.Status) {
+	if status.GetDuration() <= 0 {
+		return
+	}
+
+	room := cli.Room()
+	// every client reports it, only the ones controlling playback are used
+	_ = cli.User().ReportRoomCurrentDuration(
+		room,
+		room.CurrentMovie().ID,
+		status.GetDuration(),
+	)
+}
+
+func handleEndedMessage(cli *op.Client, movieID string) error {
+	if movieID == "" {
+		return sendErrorMessage(cli, "movie id is empty")
+	}
+
+	err := cli.User().EndRoomCurrentMovie(cli.Room(), movieID)
+	switch {
+	case err == nil,
+		// every client reports the end, only the ones controlling playback
+		// advance the playlist
+		errors.Is(err, model.ErrNoPermission),
+		errors.Is(err, model.ErrNotLeader):
+		return nil
+	default:
+		return sendErrorMessage(cli, fmt.Sprintf("end current movie error: %v", err))
+	}
+}
+
+func handleLiveStatsMessage(cli *op.Client) error {
+	stats, err := cli.User().GetRoomLiveStats(cli.Room())
+	if err != nil {
+		return sendErrorMessage(cli, fmt.Sprintf("get live stats error: %v", err))
+	}
+
+	return cli.Send(&pb.Message{
+		Type:      pb.MessageType_LIVE_STATS,
+		Timestamp: time.Now().UnixMilli(),
+		LiveStats: genLiveStatsPb(stats),
+	})
+}
+
 func handleSyncMessage(cli *op.Client) error {
 	status := cli.Room().Current().Status
 
@@ -475,16 +708,32 @@ func handleCheckStatusMessage(cli *op.Client, msg *pb.Message, timeDiff float64)
 		return sendErrorMessage(cli, "playback status is nil")
 	}
 
+	reportDuration(cli, cliStatus)
+
+	if current.Movie.TimeShift && status.IsPlaying {
+		// current time is the delay behind live, it only drifts with the rate
+		timeDiff *= 1 - status.PlaybackRate
+	}
+
 	if needsSync(cliStatus, status, timeDiff) {
 		return sendSyncStatus(cli, &status)
 	}
 
+	if current.Movie.IsLive {
+		return nil
+	}
+
+	if correction := driftCorrection(cliStatus, status, timeDiff); correction != nil {
+		return sendSyncCorrection(cli, &status, correction)
+	}
+
 	return nil
 }
 
 func needsSync(clientStatus *pb.Status, serverStatus model.Status, timeDiff float64) bool {
+	maxInterval := settings.SyncSeekThreshold.Get()
 	if clientStatus.GetIsPlaying() != serverStatus.IsPlaying ||
-		clientStatus.GetPlaybackRate() != serverStatus.PlaybackRate ||
+		!playbackRateInNudge(clientStatus.GetPlaybackRate(), serverStatus.PlaybackRate) ||
 		serverStatus.CurrentTime+maxInterval < clientStatus.GetCurrentTime()+timeDiff ||
 		serverStatus.CurrentTime-maxInterval > clientStatus.GetCurrentTime()+timeDiff {
 		return true
@@ -493,6 +742,78 @@ func needsSync(clientStatus *pb.Status, serverStatus model.Status, timeDiff floa
 	return false
 }
 
+const (
+	// small drifts are corrected over about this many seconds
+	driftCorrectionWindow = 5.0
+	// the nudged playback rate stays within this ratio of the room rate
+	maxPlaybackRateNudge = 0.1
+)
+
+// playbackRateInNudge reports whether rate is the room rate or a rate that
+// driftCorrection may have nudged the client to
+func playbackRateInNudge(rate, roomRate float64) bool {
+	// allow for rounding of the nudged rate by the client
+	const epsilon = 1e-6
+	return math.Abs(rate-roomRate) <= math.Abs(roomRate)*maxPlaybackRateNudge+epsilon
+}
+
+// driftCorrection returns the playback rate nudge for a client that drifted
+// more than the threshold but not enough to be seeked, nil if none is needed.
+func driftCorrection(
+	clientStatus *pb.Status,
+	serverStatus model.Status,
+	timeDiff float64,
+) *pb.SyncCorrection {
+	if !serverStatus.IsPlaying || serverStatus.PlaybackRate <= 0 {
+		return nil
+	}
+
+	drift := clientStatus.GetCurrentTime() + timeDiff - serverStatus.CurrentTime
+	if math.Abs(drift) < settings.SyncDriftThreshold.Get() {
+		return nil
+	}
+
+	nudge := max(-maxPlaybackRateNudge, min(maxPlaybackRateNudge, -drift/driftCorrectionWindow))
+	// the gap closes at PlaybackRate*|nudge| seconds per second
+	duration := math.Abs(drift / (serverStatus.PlaybackRate * nudge))
+
+	return &pb.SyncCorrection{
+		Drift:        drift,
+		PlaybackRate: serverStatus.PlaybackRate * (1 + nudge),
+		DurationMs:   int64(duration * 1000),
+	}
+}
+
+func handlePingMessage(cli *op.Client, ping *pb.Ping) error {
+	now := time.Now().UnixMilli()
+
+	return cli.Send(&pb.Message{
+		Type:      pb.MessageType_PONG,
+		Timestamp: now,
+		Payload: &pb.Message_Ping{
+			Ping: &pb.Ping{
+				SentAt:    ping.GetSentAt(),
+				RepliedAt: now,
+			},
+		},
+	})
+}
+
+func handlePongMessage(cli *op.Client, pong *pb.Ping) error {
+	if pong.GetSentAt() == 0 {
+		return nil
+	}
+
+	rtt := time.Since(time.UnixMilli(pong.GetSentAt()))
+	if rtt < 0 {
+		return nil
+	}
+
+	cli.UpdateRTT(rtt)
+
+	return nil
+}
+
 func sendErrorMessage(c *op.Client, errorMsg string) error {
 	return c.Send(&pb.Message{
 		Type: pb.MessageType_ERROR,
@@ -502,6 +823,25 @@ func sendErrorMessage(c *op.Client, errorMsg string) error {
 	})
 }
 
+func sendSyncCorrection(
+	cli *op.Client,
+	status *model.Status,
+	correction *pb.SyncCorrection,
+) error {
+	return cli.Send(&pb.Message{
+		Type:      pb.MessageType_SYNC,
+		Timestamp: time.Now().UnixMilli(),
+		Payload: &pb.Message_PlaybackStatus{
+			PlaybackStatus: &pb.Status{
+				IsPlaying:    status.IsPlaying,
+				CurrentTime:  status.CurrentTime,
+				PlaybackRate: status.PlaybackRate,
+			},
+		},
+		SyncCorrection: correction,
+	})
+}
+
 func sendSyncStatus(cli *op.Client, status *model.Status) error {
 	return cli.Send(&pb.Message{
 		Type: pb.MessageType_CHECK_STATUS,
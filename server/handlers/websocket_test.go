@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/synctv-org/synctv/internal/model"
+	pb "github.com/synctv-org/synctv/proto/message"
+)
+
+func playingStatus(currentTime, rate float64) model.Status {
+	return model.Status{
+		CurrentTime:  currentTime,
+		PlaybackRate: rate,
+		IsPlaying:    true,
+	}
+}
+
+func TestDriftCorrection(t *testing.T) {
+	// within the drift threshold
+	if c := driftCorrection(
+		&pb.Status{CurrentTime: 100.5, PlaybackRate: 1, IsPlaying: true},
+		playingStatus(100, 1),
+		0,
+	); c != nil {
+		t.Fatalf("small drift was corrected: %v", c)
+	}
+
+	// paused rooms are never nudged
+	if c := driftCorrection(
+		&pb.Status{CurrentTime: 105, PlaybackRate: 1},
+		model.Status{CurrentTime: 100, PlaybackRate: 1},
+		0,
+	); c != nil {
+		t.Fatalf("paused room was corrected: %v", c)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		client   float64
+		timeDiff float64
+		rate     float64
+		want     float64
+	}{
+		{"behind", 98, 0, 1, 1.1},
+		{"ahead", 102, 0, 1, 0.9},
+		{"behind by latency", 99, -1, 2, 2.2},
+	} {
+		c := driftCorrection(
+			&pb.Status{CurrentTime: tc.client, PlaybackRate: tc.rate, IsPlaying: true},
+			playingStatus(100, tc.rate),
+			tc.timeDiff,
+		)
+		if c == nil {
+			t.Fatalf("%s: drift was not corrected", tc.name)
+		}
+
+		if math.Abs(c.GetPlaybackRate()-tc.want) > 1e-9 {
+			t.Errorf("%s: rate %v, want %v", tc.name, c.GetPlaybackRate(), tc.want)
+		}
+
+		// the nudged rate closes the gap by the end of the correction
+		closed := (c.GetPlaybackRate() - tc.rate) * float64(c.GetDurationMs()) / 1000
+		if math.Abs(closed+c.GetDrift()) > 0.01 {
+			t.Errorf("%s: closes %v of drift %v", tc.name, closed, c.GetDrift())
+		}
+	}
+}
+
+func TestNeedsSyncNudgedRate(t *testing.T) {
+	status := playingStatus(100, 1)
+
+	c := driftCorrection(
+		&pb.Status{CurrentTime: 97, PlaybackRate: 1, IsPlaying: true},
+		status,
+		0,
+	)
+	if c == nil {
+		t.Fatal("drift was not corrected")
+	}
+
+	// a client playing at the nudged rate is not hard synced
+	if needsSync(
+		&pb.Status{CurrentTime: 97.5, PlaybackRate: c.GetPlaybackRate(), IsPlaying: true},
+		status,
+		0,
+	) {
+		t.Fatal("nudged client was synced")
+	}
+
+	if !needsSync(&pb.Status{CurrentTime: 100, PlaybackRate: 1.5, IsPlaying: true}, status, 0) {
+		t.Fatal("client with another rate was not synced")
+	}
+
+	if !needsSync(&pb.Status{CurrentTime: 120, PlaybackRate: 1, IsPlaying: true}, status, 0) {
+		t.Fatal("client beyond the seek threshold was not synced")
+	}
+
+	if !needsSync(&pb.Status{CurrentTime: 100, PlaybackRate: 1}, status, 0) {
+		t.Fatal("paused client was not synced")
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/synctv-org/synctv/internal/op"
+	"github.com/synctv-org/synctv/internal/whip"
+	"github.com/synctv-org/synctv/server/middlewares"
+	"github.com/synctv-org/synctv/server/model"
+)
+
+const maxWHIPOfferSize = 64 * 1024
+
+// WHIPPublish starts a WebRTC publication with the publish key as bearer token
+func WHIPPublish(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	srv := whip.Default()
+	if srv == nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(whip.ErrNotEnabled))
+		return
+	}
+
+	if ctx.ContentType() != "application/sdp" {
+		ctx.AbortWithStatusJSON(
+			http.StatusUnsupportedMediaType,
+			model.NewAPIErrorStringResp("content type must be application/sdp"),
+		)
+
+		return
+	}
+
+	movie, err := op.PublishMovie(ctx.Param("roomId"), ctx.GetHeader("Authorization"))
+	if err != nil {
+		log.Errorf("whip: publish auth error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewAPIErrorResp(err))
+		return
+	}
+
+	offer, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxWHIPOfferSize))
+	if err != nil {
+		log.Errorf("whip: read offer error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	answer, id, err := srv.Publish(movie.RoomID, movie.ID, movie.Publish, string(offer))
+	if err != nil {
+		log.Errorf("whip: publish error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
+	}
+
+	log.Infof("whip: publisher login success: %s", ctx.Param("roomId"))
+
+	ctx.Header("Location", strings.TrimSuffix(ctx.Request.URL.Path, "/")+"/"+id)
+	ctx.Data(http.StatusCreated, "application/sdp", []byte(answer))
+}
+
+func WHIPUnpublish(ctx *gin.Context) {
+	log := middlewares.GetLogger(ctx)
+
+	srv := whip.Default()
+	if srv == nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(whip.ErrNotEnabled))
+		return
+	}
+
+	movie, err := op.PublishMovie(ctx.Param("roomId"), ctx.GetHeader("Authorization"))
+	if err != nil {
+		log.Errorf("whip: unpublish auth error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewAPIErrorResp(err))
+		return
+	}
+
+	if err := srv.Unpublish(movie.RoomID, movie.ID, ctx.Param("sessionId")); err != nil {
+		if errors.Is(err, whip.ErrSessionNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, model.NewAPIErrorResp(err))
+			return
+		}
+
+		log.Errorf("whip: unpublish error: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -288,9 +288,19 @@ func AuthUserMiddleware(ctx *gin.Context) {
 		return
 	}
 
-	userE, err := AuthUser(token)
+	var (
+		userE *op.UserEntry
+		err   error
+	)
+
+	if t := apiTokenFromAuthorization(token); t != "" {
+		userE, err = authAPIToken(ctx, t, "")
+	} else {
+		userE, err = AuthUser(token)
+	}
+
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewAPIErrorResp(err))
+		ctx.AbortWithStatusJSON(authStatus(err), model.NewAPIErrorResp(err))
 		return
 	}
 
@@ -307,9 +317,23 @@ func AuthRoomMiddleware(ctx *gin.Context) {
 		return
 	}
 
-	userE, roomE, err := AuthRoom(GetAuthorizationTokenFromContext(ctx), roomID)
+	var (
+		userE *op.UserEntry
+		roomE *op.RoomEntry
+	)
+
+	token := GetAuthorizationTokenFromContext(ctx)
+	if t := apiTokenFromAuthorization(token); t != "" {
+		userE, err = authAPIToken(ctx, t, roomID)
+		if err == nil {
+			roomE, err = authenticateRoomAccess(roomID, userE.Value())
+		}
+	} else {
+		userE, roomE, err = AuthRoom(token, roomID)
+	}
+
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.NewAPIErrorResp(err))
+		ctx.AbortWithStatusJSON(authStatus(err), model.NewAPIErrorResp(err))
 		return
 	}
 
@@ -441,6 +465,17 @@ func AuthAdminMiddleware(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(ErrNotAdmin))
 		return
 	}
+
+	if err := user.CheckTotpRequired(); err != nil {
+		if errors.Is(err, op.ErrTotpRequired) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+
+		return
+	}
 }
 
 func AuthRootMiddleware(ctx *gin.Context) {
@@ -465,6 +500,27 @@ func AuthRootMiddleware(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(ErrNotRoot))
 		return
 	}
+
+	// the admin scope does not cover the root api
+	if GetAPIToken(ctx) != nil {
+		ctx.AbortWithStatusJSON(
+			http.StatusForbidden,
+			model.NewAPIErrorResp(ErrAPITokenNotAllowed),
+		)
+
+		return
+	}
+
+	if err := user.CheckTotpRequired(); err != nil {
+		if errors.Is(err, op.ErrTotpRequired) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, model.NewAPIErrorResp(err))
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+
+		return
+	}
 }
 
 func GetAuthorizationTokenFromContext(ctx *gin.Context) string {
@@ -7,9 +7,17 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/synctv-org/synctv/internal/conf"
 	limiter "github.com/ulule/limiter/v3"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func Init(e *gin.Engine) {
+	if conf.Conf.Tracing.Enable {
+		// handlers pass the gin context on as a context.Context, spans of
+		// outgoing calls only join the request span through it
+		e.ContextWithFallback = true
+		e.Use(otelgin.Middleware(conf.Conf.Tracing.ServiceName))
+	}
+
 	w := log.StandardLogger().Writer()
 	e.
 		Use(NewLog(log.StandardLogger())).
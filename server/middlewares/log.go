@@ -36,8 +36,9 @@ func NewLog(l *logrus.Logger) gin.HandlerFunc {
 		}()
 
 		entry := &logrus.Entry{
-			Logger: l,
-			Data:   fields,
+			Logger:  l,
+			Data:    fields,
+			Context: c.Request.Context(),
 		}
 		c.Set("log", entry)
 
@@ -0,0 +1,108 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+)
+
+var (
+	ErrAPITokenNotAllowed = errors.New("api tokens cannot be used for this action")
+	ErrAPITokenScope      = errors.New("api token does not have the required scope")
+	ErrAPITokenRoom       = errors.New("api token cannot be used in this room")
+)
+
+// apiTokenRoutes are the only routes api tokens can use, keyed by
+// "METHOD /full/path", a path ending with * matches every route under it
+// and the method * matches every method
+var apiTokenRoutes = make(map[string]dbModel.APITokenScope)
+
+// AllowAPIToken lets api tokens with scope use routes, it must be called
+// before the server starts
+func AllowAPIToken(scope dbModel.APITokenScope, routes ...string) {
+	for _, r := range routes {
+		apiTokenRoutes[r] = scope
+	}
+}
+
+func apiTokenScope(ctx *gin.Context) (dbModel.APITokenScope, bool) {
+	path := ctx.FullPath()
+	if scope, ok := apiTokenRoutes[ctx.Request.Method+" "+path]; ok {
+		return scope, true
+	}
+
+	for r, scope := range apiTokenRoutes {
+		method, prefix, _ := strings.Cut(r, " ")
+		if method != "*" && method != ctx.Request.Method {
+			continue
+		}
+
+		if p, ok := strings.CutSuffix(prefix, "*"); ok && strings.HasPrefix(path, p) {
+			return scope, true
+		}
+	}
+
+	return "", false
+}
+
+// authAPIToken authenticates a personal access token for the route of ctx,
+// roomID is empty for the routes of no room
+func authAPIToken(ctx *gin.Context, token, roomID string) (*op.UserEntry, error) {
+	scope, ok := apiTokenScope(ctx)
+	if !ok {
+		return nil, ErrAPITokenNotAllowed
+	}
+
+	t, userE, err := op.AuthAPIToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.HasScope(scope) {
+		return nil, ErrAPITokenScope
+	}
+
+	if t.RoomID != "" && t.RoomID != roomID {
+		return nil, ErrAPITokenRoom
+	}
+
+	user := userE.Value()
+	if err := validateUser(user, user.Version()); err != nil {
+		return nil, err
+	}
+
+	ctx.Set("apiToken", t)
+
+	return userE, nil
+}
+
+// GetAPIToken returns the api token of the request, nil for a login
+func GetAPIToken(ctx *gin.Context) *dbModel.APIToken {
+	t, _ := ctx.Value("apiToken").(*dbModel.APIToken)
+	return t
+}
+
+// apiTokenFromAuthorization returns the api token of authorization, empty
+// when it is the jwt of a login
+func apiTokenFromAuthorization(authorization string) string {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if !op.IsAPIToken(token) {
+		return ""
+	}
+
+	return token
+}
+
+func authStatus(err error) int {
+	if errors.Is(err, ErrAPITokenNotAllowed) ||
+		errors.Is(err, ErrAPITokenScope) ||
+		errors.Is(err, ErrAPITokenRoom) {
+		return http.StatusForbidden
+	}
+
+	return http.StatusUnauthorized
+}
@@ -0,0 +1,175 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/synctv-org/synctv/internal/conf"
+	"github.com/synctv-org/synctv/internal/db"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+	"github.com/synctv-org/synctv/internal/op"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+
+	AllowAPIToken(dbModel.APITokenScopeReadRooms, "GET /api/room/info")
+	AllowAPIToken(dbModel.APITokenScopeAdmin, "* /api/admin/*")
+}
+
+func initTestDB(t *testing.T) {
+	t.Helper()
+
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.Database.Type = conf.DatabaseTypeSqlite3
+
+	d, err := gorm.Open(
+		sqlite.Open(filepath.Join(t.TempDir(), "synctv.db")),
+		&gorm.Config{TranslateError: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Init(d, conf.DatabaseTypeSqlite3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := op.Init(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func serve(e *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestAPITokenScope(t *testing.T) {
+	var (
+		scope dbModel.APITokenScope
+		ok    bool
+	)
+
+	e := gin.New()
+	record := func(ctx *gin.Context) { scope, ok = apiTokenScope(ctx) }
+	e.GET("/api/room/info", record)
+	e.POST("/api/room/info", record)
+	e.POST("/api/admin/settings", record)
+	e.GET("/api/admin/users/:id", record)
+	e.GET("/api/user/tokens", record)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		scope  dbModel.APITokenScope
+	}{
+		{http.MethodGet, "/api/room/info", dbModel.APITokenScopeReadRooms},
+		{http.MethodPost, "/api/room/info", ""},
+		{http.MethodPost, "/api/admin/settings", dbModel.APITokenScopeAdmin},
+		{http.MethodGet, "/api/admin/users/1", dbModel.APITokenScopeAdmin},
+		{http.MethodGet, "/api/user/tokens", ""},
+	} {
+		scope, ok = "", false
+		serve(e, tc.method, tc.path, "")
+
+		if scope != tc.scope || ok != (tc.scope != "") {
+			t.Errorf("%s %s: scope %q %v, want %q", tc.method, tc.path, scope, ok, tc.scope)
+		}
+	}
+}
+
+func TestAuthAPITokenRoom(t *testing.T) {
+	initTestDB(t)
+
+	userE, err := op.CreateUser("user", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roomE, err := userE.Value().CreateRoom("room", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roomID := roomE.Value().ID
+
+	token, _, err := userE.Value().CreateAPIToken(
+		"bot",
+		[]dbModel.APITokenScope{dbModel.APITokenScopeReadRooms},
+		roomID,
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var authErr error
+
+	e := gin.New()
+	e.GET("/api/room/info", func(ctx *gin.Context) {
+		_, authErr = authAPIToken(ctx, token, ctx.Query("roomId"))
+	})
+
+	for _, tc := range []struct {
+		err    error
+		roomID string
+	}{
+		{nil, roomID},
+		{ErrAPITokenRoom, "other"},
+		// routes of no room
+		{ErrAPITokenRoom, ""},
+	} {
+		serve(e, http.MethodGet, "/api/room/info?roomId="+tc.roomID, "")
+
+		if !errors.Is(authErr, tc.err) {
+			t.Errorf("room %q: %v, want %v", tc.roomID, authErr, tc.err)
+		}
+	}
+}
+
+func TestAuthRootRefusesAPIToken(t *testing.T) {
+	initTestDB(t)
+
+	rootE, err := op.CreateUser("owner", "password", db.WithRole(dbModel.RoleRoot))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, _, err := rootE.Value().CreateAPIToken(
+		"bot",
+		[]dbModel.APITokenScope{dbModel.APITokenScopeAdmin},
+		"",
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) }
+
+	e := gin.New()
+	e.GET("/api/admin/users", AuthAdminMiddleware, ok)
+	e.GET("/api/admin/admins", AuthRootMiddleware, ok)
+
+	if w := serve(e, http.MethodGet, "/api/admin/users", token); w.Code != http.StatusNoContent {
+		t.Fatalf("admin api: status %d", w.Code)
+	}
+
+	if w := serve(e, http.MethodGet, "/api/admin/admins", token); w.Code != http.StatusForbidden {
+		t.Fatalf("root api: status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	stdjson "encoding/json"
 	"errors"
 
 	"github.com/gin-gonic/gin"
@@ -195,3 +196,15 @@ func (ster *SendTestEmailReq) Validate() error {
 func (ster *SendTestEmailReq) Decode(ctx *gin.Context) error {
 	return json.NewDecoder(ctx.Request.Body).Decode(ster)
 }
+
+type AuditLogResp struct {
+	ID        uint64              `json:"id"`
+	CreatedAt int64               `json:"createdAt"`
+	ActorID   string              `json:"actorId"`
+	ActorName string              `json:"actorName"`
+	Action    dbModel.AuditAction `json:"action"`
+	RoomID    string              `json:"roomId,omitempty"`
+	TargetID  string              `json:"targetId,omitempty"`
+	Before    stdjson.RawMessage  `json:"before,omitempty"`
+	After     stdjson.RawMessage  `json:"after,omitempty"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+type ChatMessageResp struct {
+	ID        string `json:"id"`
+	UserID    string `json:"userId"`
+	Username  string `json:"username"`
+	Content   string `json:"content"`
+	CreatedAt int64  `json:"createdAt"`
+	Pinned    bool   `json:"pinned"`
+}
+
+type ChatMessageIDReq struct {
+	ID string `json:"id"`
+}
+
+func (c *ChatMessageIDReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(c)
+}
+
+func (c *ChatMessageIDReq) Validate() error {
+	if len(c.ID) != 32 {
+		return errors.New("id is required")
+	}
+	return nil
+}
+
+type RoomMuteMemberReq struct {
+	UserIDReq
+	// seconds
+	Duration int64 `json:"duration"`
+}
+
+func (r *RoomMuteMemberReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *RoomMuteMemberReq) Validate() error {
+	if err := r.UserIDReq.Validate(); err != nil {
+		return err
+	}
+
+	if r.Duration <= 0 {
+		return errors.New("duration must be greater than 0")
+	}
+
+	if r.Duration > int64(dbModel.MaxMuteDuration/time.Second) {
+		return errors.New("duration is too long")
+	}
+
+	return nil
+}
+
+func (r *RoomMuteMemberReq) DurationTime() time.Duration {
+	return time.Duration(r.Duration) * time.Second
+}
+
+type RoomUnmuteMemberReq = UserIDReq
@@ -0,0 +1,16 @@
+package model
+
+type LiveStatsResp struct {
+	MovieID          string  `json:"movieId"`
+	Publishing       bool    `json:"publishing"`
+	PublishedAt      int64   `json:"publishedAt"`
+	VideoCodec       string  `json:"videoCodec"`
+	AudioCodec       string  `json:"audioCodec"`
+	Width            int     `json:"width"`
+	Height           int     `json:"height"`
+	FrameRate        float64 `json:"frameRate"`
+	Bitrate          uint64  `json:"bitrate"`
+	KeyframeInterval int64   `json:"keyframeInterval"`
+	FlvViewers       int     `json:"flvViewers"`
+	HlsViewers       int     `json:"hlsViewers"`
+}
@@ -16,6 +16,7 @@ type RoomMembersResp struct {
 	AdminPermissions dbModel.RoomAdminPermission  `json:"adminPermissions"`
 	Role             dbModel.RoomMemberRole       `json:"role"`
 	Status           dbModel.RoomMemberStatus     `json:"status"`
+	MutedUntil       int64                        `json:"mutedUntil,omitempty"`
 }
 
 type (
@@ -41,6 +42,7 @@ type RoomMeResp struct {
 	Status           dbModel.RoomMemberStatus     `json:"status"`
 	Permissions      dbModel.RoomMemberPermission `json:"permissions"`
 	AdminPermissions dbModel.RoomAdminPermission  `json:"adminPermissions"`
+	MutedUntil       int64                        `json:"mutedUntil,omitempty"`
 }
 
 type RoomSetAdminReq struct {
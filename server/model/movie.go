@@ -215,6 +215,8 @@ type CurrentMovieResp struct {
 	Movie    *Movie       `json:"movie"`
 	Status   model.Status `json:"status"`
 	ExpireID uint64       `json:"expireId"`
+	// status.currentTime is the number of seconds behind live
+	TimeShift bool `json:"timeShift"`
 }
 
 type ClearMoviesReq struct {
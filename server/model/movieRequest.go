@@ -0,0 +1,36 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	"github.com/synctv-org/synctv/internal/model"
+)
+
+type MovieRequestResp struct {
+	ID        string          `json:"id"`
+	Creator   string          `json:"creator"`
+	CreatorID string          `json:"creatorId"`
+	Base      model.MovieBase `json:"base"`
+	CreatedAt int64           `json:"createAt"`
+	Votes     int64           `json:"votes"`
+	Voted     bool            `json:"voted"`
+}
+
+type PushMovieRequestReq = PushMovieReq
+
+type MovieRequestIDReq struct {
+	ID string `json:"id"`
+}
+
+func (m *MovieRequestIDReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(m)
+}
+
+func (m *MovieRequestIDReq) Validate() error {
+	if len(m.ID) != 32 {
+		return errors.New("id is required")
+	}
+	return nil
+}
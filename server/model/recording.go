@@ -0,0 +1,16 @@
+package model
+
+type RecordingResp struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	SourceMovieID string  `json:"sourceMovieId"`
+	MovieID       string  `json:"movieId"`
+	Creator       string  `json:"creator"`
+	CreatorID     string  `json:"creatorId"`
+	Format        string  `json:"format"`
+	Status        string  `json:"status"`
+	CreatedAt     int64   `json:"createdAt"`
+	EndedAt       int64   `json:"endedAt"`
+	Size          int64   `json:"size"`
+	Duration      float64 `json:"duration"`
+}
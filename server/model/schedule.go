@@ -0,0 +1,74 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+)
+
+type ScheduleResp struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	MovieID      string `json:"movieId"`
+	SubPath      string `json:"subPath"`
+	Creator      string `json:"creator"`
+	CreatorID    string `json:"creatorId"`
+	StartAt      int64  `json:"startAt"`
+	RemindBefore int64  `json:"remindBefore"`
+	Subscribed   bool   `json:"subscribed"`
+}
+
+type CreateScheduleReq struct {
+	Title   string `json:"title"`
+	MovieID string `json:"movieId"`
+	SubPath string `json:"subPath"`
+	// unix milliseconds
+	StartAt int64 `json:"startAt"`
+	// seconds before StartAt to send the reminder email, 0 disables it
+	RemindBefore int64 `json:"remindBefore"`
+}
+
+func (c *CreateScheduleReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(c)
+}
+
+func (c *CreateScheduleReq) Validate() error {
+	switch {
+	case c.Title == "":
+		return errors.New("title is required")
+	case len(c.Title) > 256:
+		return errors.New("title is too long")
+	case len(c.MovieID) != 32:
+		return errors.New("movie id is required")
+	case c.StartAt <= 0:
+		return errors.New("start time is required")
+	case c.RemindBefore < 0:
+		return errors.New("remind before must not be negative")
+	}
+	return nil
+}
+
+func (c *CreateScheduleReq) RemindAt() *time.Time {
+	if c.RemindBefore == 0 {
+		return nil
+	}
+	t := time.UnixMilli(c.StartAt).Add(-time.Duration(c.RemindBefore) * time.Second)
+	return &t
+}
+
+type ScheduleIDReq struct {
+	ID string `json:"id"`
+}
+
+func (s *ScheduleIDReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(s)
+}
+
+func (s *ScheduleIDReq) Validate() error {
+	if len(s.ID) != 32 {
+		return errors.New("id is required")
+	}
+	return nil
+}
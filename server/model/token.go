@@ -0,0 +1,61 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+	dbModel "github.com/synctv-org/synctv/internal/model"
+)
+
+type CreateAPITokenReq struct {
+	Name   string                  `json:"name"`
+	Scopes []dbModel.APITokenScope `json:"scopes"`
+	RoomID string                  `json:"roomId"`
+	// unix milli, 0 for a token that never expires
+	ExpiresAt int64 `json:"expiresAt"`
+}
+
+func (c *CreateAPITokenReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(c)
+}
+
+func (c *CreateAPITokenReq) Validate() error {
+	switch {
+	case c.Name == "":
+		return errors.New("name is empty")
+	case len(c.Name) > 64:
+		return errors.New("name too long")
+	case len(c.Scopes) == 0:
+		return errors.New("scopes is empty")
+	case c.RoomID != "" && len(c.RoomID) != 32:
+		return ErrInvalidID
+	case c.ExpiresAt < 0:
+		return errors.New("invalid expiration")
+	}
+
+	for _, s := range c.Scopes {
+		if !s.Valid() {
+			return fmt.Errorf("invalid scope: %s", s)
+		}
+	}
+
+	return nil
+}
+
+type APITokenResp struct {
+	ID         string                  `json:"id"`
+	Name       string                  `json:"name"`
+	Scopes     []dbModel.APITokenScope `json:"scopes"`
+	RoomID     string                  `json:"roomId,omitempty"`
+	CreatedAt  int64                   `json:"createdAt"`
+	LastUsedAt int64                   `json:"lastUsedAt"`
+	ExpiresAt  int64                   `json:"expiresAt,omitempty"`
+}
+
+type CreateAPITokenResp struct {
+	*APITokenResp
+	// only returned once
+	Token string `json:"token"`
+}
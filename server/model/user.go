@@ -280,3 +280,55 @@ func (u *UserRetrievePasswordEmailReq) Validate() error {
 	}
 	return nil
 }
+
+type LoginUserTwoFactorReq struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+func (l *LoginUserTwoFactorReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(l)
+}
+
+func (l *LoginUserTwoFactorReq) Validate() error {
+	if l.Challenge == "" {
+		return errors.New("challenge is empty")
+	}
+
+	if l.Code == "" {
+		return errors.New("code is empty")
+	}
+
+	return nil
+}
+
+type UserTotpCodeReq struct {
+	Code string `json:"code"`
+}
+
+func (u *UserTotpCodeReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(u)
+}
+
+func (u *UserTotpCodeReq) Validate() error {
+	if u.Code == "" {
+		return errors.New("code is empty")
+	}
+
+	return nil
+}
+
+type UserTwoFactorResp struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+type UserTotpSetupResp struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+}
+
+type UserTotpRecoveryCodesResp struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
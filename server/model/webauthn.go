@@ -0,0 +1,131 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+)
+
+var (
+	ErrEmptySession       = errors.New("session is empty")
+	ErrEmptyCredential    = errors.New("credential is empty")
+	ErrPasskeyNameTooLong = errors.New("passkey name too long")
+)
+
+type WebAuthnBeginResp struct {
+	Session string `json:"session,omitempty"`
+	Options any    `json:"options"`
+}
+
+type WebAuthnRegisterReq struct {
+	Session string `json:"session"`
+	Name    string `json:"name"`
+	// the PublicKeyCredential of navigator.credentials.create
+	Credential json.RawMessage `json:"credential"`
+}
+
+func (w *WebAuthnRegisterReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(w)
+}
+
+func (w *WebAuthnRegisterReq) Validate() error {
+	switch {
+	case w.Session == "":
+		return ErrEmptySession
+	case len(w.Credential) == 0:
+		return ErrEmptyCredential
+	case len(w.Name) > 64:
+		return ErrPasskeyNameTooLong
+	case w.Name == "":
+		w.Name = "Passkey"
+	}
+
+	return nil
+}
+
+type WebAuthnLoginReq struct {
+	Session string `json:"session"`
+	// the PublicKeyCredential of navigator.credentials.get
+	Credential json.RawMessage `json:"credential"`
+}
+
+func (w *WebAuthnLoginReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(w)
+}
+
+func (w *WebAuthnLoginReq) Validate() error {
+	switch {
+	case w.Session == "":
+		return ErrEmptySession
+	case len(w.Credential) == 0:
+		return ErrEmptyCredential
+	}
+
+	return nil
+}
+
+type TwoFactorChallengeReq struct {
+	Challenge string `json:"challenge"`
+}
+
+func (t *TwoFactorChallengeReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(t)
+}
+
+func (t *TwoFactorChallengeReq) Validate() error {
+	if t.Challenge == "" {
+		return errors.New("challenge is empty")
+	}
+
+	return nil
+}
+
+type LoginUserTwoFactorWebAuthnReq struct {
+	Challenge  string          `json:"challenge"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+func (l *LoginUserTwoFactorWebAuthnReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(l)
+}
+
+func (l *LoginUserTwoFactorWebAuthnReq) Validate() error {
+	switch {
+	case l.Challenge == "":
+		return errors.New("challenge is empty")
+	case len(l.Credential) == 0:
+		return ErrEmptyCredential
+	}
+
+	return nil
+}
+
+type RenameWebAuthnCredentialReq struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (r *RenameWebAuthnCredentialReq) Decode(ctx *gin.Context) error {
+	return json.NewDecoder(ctx.Request.Body).Decode(r)
+}
+
+func (r *RenameWebAuthnCredentialReq) Validate() error {
+	switch {
+	case len(r.ID) != 32:
+		return ErrID
+	case r.Name == "":
+		return errors.New("name is empty")
+	case len(r.Name) > 64:
+		return ErrPasskeyNameTooLong
+	}
+
+	return nil
+}
+
+type WebAuthnCredentialResp struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	CreatedAt  int64  `json:"createdAt"`
+	LastUsedAt int64  `json:"lastUsedAt"`
+}
@@ -115,7 +115,7 @@ func OAuth2Callback(ctx *gin.Context) {
 	}
 
 	if meta.Value() != nil {
-		meta.Value()(ctx, pi, code)
+		meta.Value()(ctx, pi, ctx.Query("state"), code)
 	} else {
 		log.Errorf("invalid oauth2 handler")
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorStringResp("invalid oauth2 handler"))
@@ -138,6 +138,7 @@ func OAuth2CallbackAPI(ctx *gin.Context) {
 	if err != nil {
 		log.Errorf("failed to get provider: %v", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
+		return
 	}
 
 	meta, loaded := states.LoadAndDelete(req.State)
@@ -152,7 +153,7 @@ func OAuth2CallbackAPI(ctx *gin.Context) {
 	}
 
 	if meta.Value() != nil {
-		meta.Value()(ctx, pi, req.Code)
+		meta.Value()(ctx, pi, req.State, req.Code)
 	} else {
 		log.Errorf("invalid oauth2 handler")
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorStringResp("invalid oauth2 handler"))
@@ -160,12 +161,12 @@ func OAuth2CallbackAPI(ctx *gin.Context) {
 }
 
 func newAuthFunc(redirect string) stateHandler {
-	return func(ctx *gin.Context, pi provider.Interface, code string) {
+	return func(ctx *gin.Context, pi provider.Interface, state, code string) {
 		log := middlewares.GetLogger(ctx)
 
 		ctx.Header("X-OAuth2-Type", CallbackTypeAuth)
 
-		ui, err := pi.GetUserInfo(ctx, code)
+		ui, err := getUserInfo(ctx, pi, state, code)
 		if err != nil {
 			log.Errorf("failed to get user info: %v", err)
 			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
@@ -222,6 +223,18 @@ func newAuthFunc(redirect string) stateHandler {
 
 		user := userE.Value()
 
+		twoFactor, err := user.TwoFactorEnabled()
+		if err != nil {
+			log.Errorf("failed to load 2fa: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+			return
+		}
+
+		if twoFactor {
+			handleTwoFactor(ctx, user, redirect)
+			return
+		}
+
 		token, err := middlewares.NewAuthUserToken(user)
 		if err != nil {
 			if errors.Is(err, middlewares.ErrUserBanned) ||
@@ -257,3 +270,40 @@ func newAuthFunc(redirect string) stateHandler {
 		}
 	}
 }
+
+// handleTwoFactor asks for a code or a passkey instead of issuing a token,
+// the token is issued by /api/user/login/2fa or /api/user/login/2fa/webauthn
+func handleTwoFactor(ctx *gin.Context, user *op.User, redirect string) {
+	log := middlewares.GetLogger(ctx)
+
+	passkey, err := user.HasWebAuthnCredentials()
+	if err != nil {
+		log.Errorf("failed to get passkeys: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	challenge, err := op.NewTwoFactorChallenge(user.ID)
+	if err != nil {
+		log.Errorf("failed to create 2fa challenge: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.NewAPIErrorResp(err))
+		return
+	}
+
+	switch ctx.Request.Method {
+	case http.MethodGet:
+		err = RenderTwoFactor(ctx, redirect, challenge, user.TotpEnabled(), passkey)
+		if err != nil {
+			log.Errorf("failed to render 2fa: %v", err)
+		}
+	case http.MethodPost:
+		ctx.JSON(http.StatusOK, model.NewAPIDataResp(gin.H{
+			"type":      CallbackTypeAuth,
+			"twoFactor": true,
+			"challenge": challenge,
+			"totp":      user.TotpEnabled(),
+			"passkey":   passkey,
+			"redirect":  redirect,
+		}))
+	}
+}
@@ -69,12 +69,12 @@ func UnBindAPI(ctx *gin.Context) {
 }
 
 func newBindFunc(userID, redirect string) stateHandler {
-	return func(ctx *gin.Context, pi provider.Interface, code string) {
+	return func(ctx *gin.Context, pi provider.Interface, state, code string) {
 		log := middlewares.GetLogger(ctx)
 
 		ctx.Header("X-OAuth2-Type", CallbackTypeBind)
 
-		ui, err := pi.GetUserInfo(ctx, code)
+		ui, err := getUserInfo(ctx, pi, state, code)
 		if err != nil {
 			log.Errorf("failed to get user info: %v", err)
 			ctx.AbortWithStatusJSON(http.StatusBadRequest, model.NewAPIErrorResp(err))
@@ -14,12 +14,26 @@ import (
 var temp embed.FS
 
 var (
-	redirectTemplate *template.Template
-	tokenTemplate    *template.Template
-	states           *synccache.SyncCache[string, stateHandler]
+	redirectTemplate  *template.Template
+	tokenTemplate     *template.Template
+	twoFactorTemplate *template.Template
+	states            *synccache.SyncCache[string, stateHandler]
 )
 
-type stateHandler func(ctx *gin.Context, pi provider.Interface, code string)
+type stateHandler func(ctx *gin.Context, pi provider.Interface, state, code string)
+
+// getUserInfo hands the state to the providers that bound data to it
+func getUserInfo(
+	ctx *gin.Context,
+	pi provider.Interface,
+	state, code string,
+) (*provider.UserInfo, error) {
+	if si, ok := pi.(provider.StateInterface); ok {
+		return si.GetUserInfoWithState(ctx, state, code)
+	}
+
+	return pi.GetUserInfo(ctx, code)
+}
 
 func RenderRedirect(ctx *gin.Context, url string) error {
 	ctx.Header("Content-Type", "text/html; charset=utf-8")
@@ -31,8 +45,17 @@ func RenderToken(ctx *gin.Context, url, token string) error {
 	return tokenTemplate.Execute(ctx.Writer, map[string]string{"Url": url, "Token": token})
 }
 
+func RenderTwoFactor(ctx *gin.Context, url, challenge string, totp, passkey bool) error {
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	return twoFactorTemplate.Execute(
+		ctx.Writer,
+		map[string]any{"Url": url, "Challenge": challenge, "Totp": totp, "Passkey": passkey},
+	)
+}
+
 func init() {
 	redirectTemplate = template.Must(template.ParseFS(temp, "templates/redirect.html"))
 	tokenTemplate = template.Must(template.ParseFS(temp, "templates/token.html"))
+	twoFactorTemplate = template.Must(template.ParseFS(temp, "templates/twofactor.html"))
 	states = synccache.NewSyncCache[string, stateHandler](time.Minute * 10)
 }